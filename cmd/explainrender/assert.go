@@ -0,0 +1,241 @@
+/*
+2026 © Postgres.ai
+*/
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/postgres-ai/joe/pkg/pgexplain"
+	"gitlab.com/postgres-ai/joe/pkg/util"
+)
+
+// assertion is a plan property checked with -assert, e.g. "max_time<100ms".
+type assertion struct {
+	text string
+
+	// check returns what violates the assertion in the plan, or an empty
+	// string when it holds.
+	check func(ex *pgexplain.Explain) string
+}
+
+// assertionsError lists the violated assertions.
+type assertionsError []string
+
+func (e assertionsError) Error() string {
+	return fmt.Sprintf("%d assertion(s) failed:\n  - %s", len(e), strings.Join(e, "\n  - "))
+}
+
+// parseAssertions parses a comma-separated list of assertions:
+//
+//	max_time<DURATION           execution time, e.g. 100ms or 2s; a bare number is milliseconds
+//	max_planning_time<DURATION  planning time
+//	max_cost<N                  total cost of the plan
+//	max_buffers<N               shared buffers hit and read, in blocks
+//	no_seqscan[=RELATION]       no Seq Scan at all, or none on the relation
+//	no_tip[=CODE]               no recommendation at all, or none with the code, e.g. SORT_DISK
+//
+// The limits accept "<" and "<=".
+func parseAssertions(spec string) ([]assertion, error) {
+	var assertions []assertion
+
+	for _, text := range strings.Split(spec, ",") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		parsed, err := parseAssertion(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %w", text, err)
+		}
+
+		assertions = append(assertions, parsed)
+	}
+
+	return assertions, nil
+}
+
+func parseAssertion(text string) (assertion, error) {
+	if name, value, found := strings.Cut(text, "<"); found {
+		inclusive := strings.HasPrefix(value, "=")
+		value = strings.TrimPrefix(value, "=")
+
+		return parseLimit(text, strings.TrimSpace(name), strings.TrimSpace(value), inclusive)
+	}
+
+	name, value, _ := strings.Cut(text, "=")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+	switch name {
+	case "no_seqscan":
+		return assertion{text: text, check: func(ex *pgexplain.Explain) string { return seqScanViolation(ex, value) }}, nil
+
+	case "no_tip":
+		return assertion{text: text, check: func(ex *pgexplain.Explain) string { return tipViolation(ex, value) }}, nil
+	}
+
+	return assertion{}, fmt.Errorf("unknown check %q", name)
+}
+
+// parseLimit parses an upper limit on a plan number.
+func parseLimit(text, name, value string, inclusive bool) (assertion, error) {
+	var (
+		limit   float64
+		err     error
+		measure func(ex *pgexplain.Explain) (float64, string)
+	)
+
+	switch name {
+	case "max_time", "max_planning_time":
+		limit, err = parseMilliseconds(value)
+
+		measure = func(ex *pgexplain.Explain) (float64, string) {
+			if name == "max_planning_time" {
+				return ex.PlanningTime, "planning time " + util.MillisecondsToString(ex.PlanningTime)
+			}
+
+			return ex.ExecutionTime, "execution time " + util.MillisecondsToString(ex.ExecutionTime)
+		}
+
+	case "max_cost":
+		limit, err = strconv.ParseFloat(value, 64)
+
+		measure = func(ex *pgexplain.Explain) (float64, string) {
+			return ex.Plan.TotalCost, fmt.Sprintf("total cost %.2f", ex.Plan.TotalCost)
+		}
+
+	case "max_buffers":
+		limit, err = strconv.ParseFloat(value, 64)
+
+		measure = func(ex *pgexplain.Explain) (float64, string) {
+			blocks := ex.SharedHitBlocks + ex.SharedReadBlocks
+			return float64(blocks), fmt.Sprintf("%d shared buffers hit and read", blocks)
+		}
+
+	default:
+		return assertion{}, fmt.Errorf("unknown limit %q", name)
+	}
+
+	if err != nil {
+		return assertion{}, fmt.Errorf("invalid limit %q", value)
+	}
+
+	return assertion{
+		text: text,
+		check: func(ex *pgexplain.Explain) string {
+			if name == "max_time" && ex.Plan.ActualLoops == 0 {
+				return "the plan has no execution time, it must come from EXPLAIN ANALYZE"
+			}
+
+			actual, description := measure(ex)
+
+			if actual < limit || inclusive && actual == limit {
+				return ""
+			}
+
+			return description
+		},
+	}, nil
+}
+
+// parseMilliseconds parses a duration like "100ms" or "1.5s" into
+// milliseconds; a bare number is milliseconds already.
+func parseMilliseconds(value string) (float64, error) {
+	if milliseconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return milliseconds, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(duration) / float64(time.Millisecond), nil
+}
+
+// seqScanViolation lists the Seq Scans on the relation, or on any relation
+// when it is empty. The relation matches with or without the schema.
+func seqScanViolation(ex *pgexplain.Explain, relation string) string {
+	var scans []string
+
+	walkPlan(&ex.Plan, func(plan *pgexplain.Plan) {
+		if plan.NodeType != pgexplain.SequenceScan {
+			return
+		}
+
+		qualified := plan.Schema + "." + plan.RelationName
+		if relation != "" && relation != plan.RelationName && relation != qualified {
+			return
+		}
+
+		if !slices.Contains(scans, plan.RelationName) {
+			scans = append(scans, plan.RelationName)
+		}
+	})
+
+	if len(scans) == 0 {
+		return ""
+	}
+
+	return "Seq Scan on " + strings.Join(scans, ", ")
+}
+
+// tipViolation lists the recommendations with the code, or all of them when
+// it is empty.
+func tipViolation(ex *pgexplain.Explain, code string) string {
+	var tips []string
+
+	for _, tip := range ex.Tips() {
+		if code == "" || strings.EqualFold(code, tip.Code) {
+			tips = append(tips, tip.Code)
+		}
+	}
+
+	if len(tips) == 0 {
+		return ""
+	}
+
+	return "recommendations " + strings.Join(tips, ", ")
+}
+
+// checkAssertions returns the violations of the assertions by the plans; with
+// several plans each violation names its plan.
+func checkAssertions(explains []*pgexplain.Explain, assertions []assertion) error {
+	var violations assertionsError
+
+	for index, ex := range explains {
+		for _, assertion := range assertions {
+			violation := assertion.check(ex)
+			if violation == "" {
+				continue
+			}
+
+			if len(explains) > 1 {
+				violation = fmt.Sprintf("plan %d: %s", index+1, violation)
+			}
+
+			violations = append(violations, assertion.text+": "+violation)
+		}
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+
+	return nil
+}
+
+// walkPlan calls fn for the node and its descendants in depth-first order.
+func walkPlan(plan *pgexplain.Plan, fn func(plan *pgexplain.Plan)) {
+	fn(plan)
+
+	for index := range plan.Plans {
+		walkPlan(&plan.Plans[index], fn)
+	}
+}
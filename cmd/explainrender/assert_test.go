@@ -0,0 +1,101 @@
+/*
+2026 © Postgres.ai
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseAssertions(t *testing.T) {
+	for _, spec := range []string{"max_time<100ms", "max_time<=1.5s, max_planning_time<5", "max_cost<1e6,max_buffers<100",
+		"no_seqscan", "no_seqscan=public.orders,no_tip=SORT_DISK", ""} {
+		if _, err := parseAssertions(spec); err != nil {
+			t.Errorf("parseAssertions(%q) returned an error: %v", spec, err)
+		}
+	}
+
+	for _, spec := range []string{"max_time<soon", "min_time>1ms", "max_rows<10", "no_index=orders"} {
+		if _, err := parseAssertions(spec); err == nil {
+			t.Errorf("parseAssertions(%q) should fail", spec)
+		}
+	}
+}
+
+func TestCheckAssertions(t *testing.T) {
+	explains, err := readExplains(strings.NewReader(seqScanJSON), inputFormatAuto)
+	if err != nil {
+		t.Fatalf("readExplains returned an error: %v", err)
+	}
+
+	testCases := []struct {
+		spec      string
+		violation string
+	}{
+		{spec: "max_time<100ms"},
+		{spec: "max_time<0.1ms", violation: "max_time<0.1ms: execution time 0.199 ms"},
+		{spec: "max_time<0.199", violation: "max_time<0.199: execution time 0.199 ms"},
+		{spec: "max_time<=0.199"},
+		{spec: "max_planning_time<1ms"},
+		{spec: "max_cost<9.25", violation: "max_cost<9.25: total cost 9.25"},
+		{spec: "max_cost<=9.25"},
+		{spec: "max_buffers<3", violation: "max_buffers<3: 3 shared buffers hit and read"},
+		{spec: "no_seqscan", violation: "no_seqscan: Seq Scan on t_items"},
+		{spec: "no_seqscan=joecap.t_items", violation: "no_seqscan=joecap.t_items: Seq Scan on t_items"},
+		{spec: "no_seqscan=orders"},
+		{spec: "no_tip"},
+	}
+
+	for _, tc := range testCases {
+		assertions, err := parseAssertions(tc.spec)
+		if err != nil {
+			t.Fatalf("parseAssertions(%q) returned an error: %v", tc.spec, err)
+		}
+
+		err = checkAssertions(explains, assertions)
+
+		var violations assertionsError
+
+		switch {
+		case tc.violation == "" && err != nil:
+			t.Errorf("%s: unexpected violation: %v", tc.spec, err)
+
+		case tc.violation != "" && !errors.As(err, &violations):
+			t.Errorf("%s: expected a violation, got: %v", tc.spec, err)
+
+		case tc.violation != "" && (len(violations) != 1 || violations[0] != tc.violation):
+			t.Errorf("%s: unexpected violations %q", tc.spec, violations)
+		}
+	}
+}
+
+func TestRenderAssertions(t *testing.T) {
+	assertions, err := parseAssertions("max_time<0.1ms,no_seqscan=t_items,max_cost<100")
+	if err != nil {
+		t.Fatalf("parseAssertions returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = render(strings.NewReader(seqScanJSON), &buf, options{assertions: assertions})
+
+	want := "2 assertion(s) failed:\n  - max_time<0.1ms: execution time 0.199 ms\n  - no_seqscan=t_items: Seq Scan on t_items"
+	if err == nil || err.Error() != want {
+		t.Errorf("unexpected error %v, want:\n%s", err, want)
+	}
+
+	if !strings.Contains(buf.String(), "Seq Scan on joecap.t_items") {
+		t.Errorf("the plan should be rendered even when assertions fail\n--- output ---\n%s", buf.String())
+	}
+
+	// Without ANALYZE there is no execution time to check.
+	planOnly := `[{"Plan": {"Node Type": "Result", "Total Cost": 0.01, "Plan Rows": 1}}]`
+
+	assertions, _ = parseAssertions("max_time<1s")
+	if err := render(strings.NewReader(planOnly), &buf, options{assertions: assertions}); err == nil {
+		t.Error("max_time should fail for a plan without ANALYZE")
+	}
+}
@@ -3,10 +3,11 @@
 */
 
 // Command explainrender converts a PostgreSQL `EXPLAIN (FORMAT JSON)` document
-// into PostgreSQL's standard text plan (and optionally a stats summary) using
-// joe's pkg/pgexplain renderer. joe collects plans as JSON but often needs to
-// show the familiar text plan, and neither psql nor PostgreSQL can convert an
-// existing JSON plan back to text — so pkg/pgexplain performs that translation.
+// (or a YAML, XML or pasted text-format plan) into PostgreSQL's standard text
+// plan (and optionally a stats summary) using joe's pkg/pgexplain renderer.
+// joe collects plans as JSON but often needs to show the familiar text plan,
+// and neither psql nor PostgreSQL can convert an existing JSON plan back to
+// text — so pkg/pgexplain performs that translation.
 //
 // It is handy for debugging that JSON->text translation and for diffing joe's
 // output against PostgreSQL's own text EXPLAIN across server versions; any
@@ -14,17 +15,53 @@
 //
 // Usage:
 //
-//	explainrender [-stats] [file]
+//	explainrender [-stats] [-tips] [-fingerprint] [-misestimates] [-stream]
+//		[-anonymize [-mapping mapping.json]] [-format text|json|html|mermaid|dot]
+//		[-input-format auto|json|yaml|xml|text] [-assert CHECKS] [file]
+//	explainrender -diff before.json after.json
+//	explainrender -restore mapping.json [file]
+//	explainrender -regress [-golden DIR] [-update] DIR|GLOB...
 //
 // The input (a file path argument, or stdin when omitted) must be the output of
-// EXPLAIN (... FORMAT JSON), e.g. EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) ....
+// EXPLAIN (... FORMAT JSON), e.g. EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) ...,
+// or the same plan in FORMAT YAML, FORMAT XML or text as printed by psql or
+// auto_explain; the format is detected unless -input-format names it. Only the
+// first plan of the input is rendered unless -stream is given, which renders
+// every plan (multi-statement EXPLAIN results, auto_explain logs of nested
+// statements, concatenated dumps), each with its own stats and tips. With
+// -fingerprint, the plan fingerprint and the normalized plan shape it is
+// computed from are appended, to spot plan flips and group identical plans.
+// With -misestimates, only the nodes whose row counts the planner got badly
+// wrong are reported, with the columns involved and the statistics to fix.
+// With -anonymize, relation, index, column and alias names are replaced with
+// placeholders and literals are stripped before rendering, so that the plan can
+// be shared; -mapping saves the placeholders with the names they replace, and
+// -restore puts the names back into any text using the placeholders.
+// With -format html, a self-contained HTML page with flame graphs of exclusive
+// node time and buffers is written instead of the text plan; -format mermaid
+// and -format dot write the plan tree as a Mermaid flowchart or a Graphviz
+// digraph for design docs and merge requests. With -format json, the processed
+// plan is written as JSON with everything joe computes from it: totals,
+// exclusive times, outlier nodes, tips, misestimates and parallelism.
+// With -assert, plan properties such as "max_time<100ms,no_seqscan=orders"
+// are checked after rendering and the command exits non-zero when one fails,
+// to gate CI pipelines on plans.
+// With -diff, the two plans are aligned node by node and printed as an
+// annotated tree with time, rows, cost and buffers deltas.
+// With -regress, every plan capture in the given directories or globs is
+// rendered and compared with its golden .txt file like the golden tests of
+// pkg/pgexplain do, a unified diff is printed for each mismatch, and the
+// command exits non-zero unless all plans match; -update rewrites the goldens.
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"gitlab.com/postgres-ai/joe/pkg/pgexplain"
 )
@@ -38,12 +75,78 @@ Useful for debugging that JSON->text translation and for diffing joe's output
 against PostgreSQL's own text EXPLAIN across versions (any difference is a bug).
 
 Usage:
-  explainrender [-stats] [file]
+  explainrender [-stats] [-tips] [-fingerprint] [-misestimates] [-stream]
+      [-anonymize [-mapping FILE]] [-format text|json|html|mermaid|dot]
+      [-input-format FORMAT] [-assert CHECKS] [file]
+  explainrender -diff before.json after.json
+  explainrender -restore mapping.json [file]
+  explainrender -regress [-golden DIR] [-update] DIR|GLOB...
 
 The input must be the output of EXPLAIN (... FORMAT JSON), for example:
   EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) SELECT ...
+FORMAT YAML, FORMAT XML and text EXPLAIN output (as printed by psql or logged
+by auto_explain) are parsed as well, so pasted plans get the stats summary and
+tips too. The input format is detected unless -input-format names it.
 
-With no file argument, the JSON is read from stdin.
+Only the first plan of the input is rendered; with -stream every plan is, each
+under its own "===== PLAN N =====" header with its own stats and tips. Use it
+for multi-statement EXPLAIN results, auto_explain logs and concatenated dumps.
+
+With -fingerprint, the plan fingerprint is appended together with the plan
+shape it hashes: node types, relations, indexes, join types and conditions
+without costs, timings and literals. Equal fingerprints mean the same plan.
+
+With -misestimates, the cardinality misestimate report is written instead of
+the plan: the nodes whose actual rows are furthest off the planned ones, the
+conditions and columns the estimates come from, and the ANALYZE, SET
+STATISTICS and CREATE STATISTICS statements that may fix them.
+
+With -anonymize, schema, relation, index, column, alias, CTE and trigger names
+are replaced with placeholders (table_1, column_2, ...) and literal values with
+"?" before anything is rendered; the tree and all numbers stay intact. Use it
+to share plans publicly or with vendors. -mapping FILE saves the placeholders
+with the names they replace as JSON; -restore FILE reads such a mapping and
+puts the names back into the text read from the input, e.g. advice received
+about the anonymized plan.
+
+With no file argument, the JSON is read from stdin. With -format html, a
+self-contained HTML page with flame graphs of exclusive node time and shared
+buffers is written instead of the text plan; -format mermaid and -format dot
+write the plan tree as a Mermaid flowchart or a Graphviz DOT digraph, nodes
+labelled with rows and time and the slowest, costliest and largest nodes
+highlighted.
+
+With -diff, two plans are aligned node by node and printed as an annotated
+tree: "=" unchanged, "~" changed, "+" added and "-" removed nodes, with
+time/rows/cost/buffers deltas.
+
+With -format json, the processed plan is written as JSON for tools: the plan
+tree with the computed fields of every node (exclusive time and buffers,
+slowest/costliest/largest flags, estimate factors), the totals, the
+fingerprint, tips, misestimates and parallelism. With -stream, the plans are
+written as a JSON array.
+
+With -assert, the rendered plans are checked against a comma-separated list of
+properties, and the command exits with status 1 listing the violations, e.g.
+  explainrender -assert 'max_time<100ms,no_seqscan=orders' plan.json
+Checks:
+  max_time<DURATION           execution time, e.g. 100ms or 2s (bare numbers are ms)
+  max_planning_time<DURATION  planning time
+  max_cost<N                  total cost of the plan
+  max_buffers<N               shared buffers hit and read, in blocks
+  no_seqscan[=RELATION]       no Seq Scan at all, or none on the relation
+  no_tip[=CODE]               no recommendation at all, or none with the code
+Limits accept "<" and "<=".
+
+With -regress, the renderer is checked against a set of captured plans, e.g.
+a directory per PostgreSQL version, outside go test:
+  explainrender -regress testdata/pg18 'testdata/pg1[67]/*.json'
+Each JSON, YAML or XML capture <root>/<version>/<name>.json is rendered as the
+text plan and the stats under a "--- stats ---" line and compared with the
+golden file <root>/golden/<version>/<name>.txt (or <DIR>/<version>/<name>.txt
+with -golden DIR). Mismatches are printed as unified diffs, followed by a
+summary; the exit status is 1 if any plan failed, had no golden file or did
+not parse. -update writes the golden files from the current output instead.
 
 Flags:
 `
@@ -52,25 +155,188 @@ Flags:
 // the combined output produced with -stats.
 const statsSeparator = "\n===== STATS =====\n"
 
-// render reads an EXPLAIN (FORMAT JSON) document from in, renders it with joe's
-// pgexplain renderer, and writes the text plan to out. When withStats is true it
-// also appends the stats summary under statsSeparator.
-func render(in io.Reader, out io.Writer, withStats bool) error {
-	data, err := io.ReadAll(in)
-	if err != nil {
-		return fmt.Errorf("failed to read input: %w", err)
+// tipsSeparator delimits the recommendations produced with -tips from the
+// preceding output.
+const tipsSeparator = "\n===== TIPS =====\n"
+
+// fingerprintSeparator delimits the plan fingerprint and shape produced with
+// -fingerprint from the preceding output.
+const fingerprintSeparator = "\n===== FINGERPRINT =====\n"
+
+// planHeader heads each plan rendered with -stream.
+const planHeader = "===== PLAN %d =====\n"
+
+// Output formats accepted by -format.
+const (
+	formatText    = "text"
+	formatJSON    = "json"
+	formatHTML    = "html"
+	formatMermaid = "mermaid"
+	formatDOT     = "dot"
+)
+
+// inputFormatAuto makes readExplains detect the input format.
+const inputFormatAuto = "auto"
+
+// options selects the output format and the optional sections render appends
+// after the text plan.
+type options struct {
+	format          string
+	inputFormat     string
+	withStats       bool
+	withTips        bool
+	withFingerprint bool
+	misestimates    bool
+	stream          bool
+	anonymize       bool
+	mappingPath     string
+	assertions      []assertion
+}
+
+// render reads an EXPLAIN document from in, renders it with joe's pgexplain
+// renderer, and writes the text plan to out. With opts.withStats it also
+// appends the stats summary under statsSeparator, with opts.withTips the
+// plan recommendations under tipsSeparator, and with opts.withFingerprint the
+// plan fingerprint and shape under fingerprintSeparator. With opts.misestimates
+// it writes the misestimate report instead of the plan and its sections.
+// With opts.format set to formatHTML it writes the flame graph page instead,
+// with formatMermaid or formatDOT the plan tree diagram. With opts.stream every
+// plan of the input is rendered under planHeader, not just the first one. With
+// opts.anonymize the plans are anonymized first, and the mapping is saved to
+// opts.mappingPath when it is set. With formatJSON the processed plans are
+// written as JSON. The rendered plans are checked against opts.assertions
+// last, so that the output is complete even when some of them fail.
+func render(in io.Reader, out io.Writer, opts options) error {
+	switch opts.format {
+	case "", formatText, formatJSON:
+	case formatHTML, formatMermaid, formatDOT:
+		if opts.stream {
+			return fmt.Errorf("-format %s renders a single plan and cannot be combined with -stream", opts.format)
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", opts.format)
+	}
+
+	if opts.misestimates && opts.format != "" && opts.format != formatText {
+		return fmt.Errorf("-misestimates writes a text report and cannot be combined with -format %s", opts.format)
+	}
+
+	if opts.mappingPath != "" && !opts.anonymize {
+		return errors.New("-mapping saves the mapping of -anonymize and needs it")
 	}
 
-	ex, err := pgexplain.NewExplain(string(data))
+	explains, err := readExplains(in, opts.inputFormat)
 	if err != nil {
-		return fmt.Errorf("failed to parse EXPLAIN JSON: %w", err)
+		return err
+	}
+
+	if opts.anonymize {
+		if err := anonymize(explains, opts.mappingPath); err != nil {
+			return err
+		}
+	}
+
+	if !opts.stream {
+		explains = explains[:1]
+	}
+
+	if err := writePlans(out, explains, opts); err != nil {
+		return err
+	}
+
+	return checkAssertions(explains, opts.assertions)
+}
+
+// writePlans writes the plans in the format selected by opts, each under its
+// planHeader when streaming.
+func writePlans(out io.Writer, explains []*pgexplain.Explain, opts options) error {
+	if opts.format == formatJSON {
+		return writeAnalyses(out, explains, opts.stream)
+	}
+
+	if !opts.stream {
+		return writeExplain(out, explains[0], opts)
+	}
+
+	for index, ex := range explains {
+		header := fmt.Sprintf(planHeader, index+1)
+		if index > 0 {
+			header = "\n" + header
+		}
+
+		if _, err := io.WriteString(out, header); err != nil {
+			return fmt.Errorf("failed to write plan header: %w", err)
+		}
+
+		if err := writeExplain(out, ex, opts); err != nil {
+			return fmt.Errorf("plan %d: %w", index+1, err)
+		}
+	}
+
+	return nil
+}
+
+// writeAnalyses writes the analyses of the plans as an indented JSON object,
+// or as an array of them when streaming.
+func writeAnalyses(out io.Writer, explains []*pgexplain.Explain, stream bool) error {
+	analyses := make([]*pgexplain.Analysis, 0, len(explains))
+	for _, ex := range explains {
+		analyses = append(analyses, ex.Analysis())
+	}
+
+	var document any = analyses[0]
+	if stream {
+		document = analyses
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("failed to write JSON analysis: %w", err)
+	}
+
+	return nil
+}
+
+// writeExplain writes one plan with the sections selected by opts.
+func writeExplain(out io.Writer, ex *pgexplain.Explain, opts options) error {
+	switch opts.format {
+	case formatHTML:
+		if _, err := io.WriteString(out, ex.RenderFlameGraphHTML()); err != nil {
+			return fmt.Errorf("failed to write flame graph: %w", err)
+		}
+
+		return nil
+
+	case formatMermaid:
+		if _, err := io.WriteString(out, ex.RenderMermaid()); err != nil {
+			return fmt.Errorf("failed to write Mermaid diagram: %w", err)
+		}
+
+		return nil
+
+	case formatDOT:
+		if _, err := io.WriteString(out, ex.RenderDOT()); err != nil {
+			return fmt.Errorf("failed to write DOT diagram: %w", err)
+		}
+
+		return nil
+	}
+
+	if opts.misestimates {
+		if _, err := io.WriteString(out, ex.RenderMisestimates()); err != nil {
+			return fmt.Errorf("failed to write misestimates: %w", err)
+		}
+
+		return nil
 	}
 
 	if _, err := io.WriteString(out, ex.RenderPlanText()); err != nil {
 		return fmt.Errorf("failed to write plan: %w", err)
 	}
 
-	if withStats {
+	if opts.withStats {
 		if _, err := io.WriteString(out, statsSeparator); err != nil {
 			return fmt.Errorf("failed to write stats separator: %w", err)
 		}
@@ -80,13 +346,139 @@ func render(in io.Reader, out io.Writer, withStats bool) error {
 		}
 	}
 
+	if opts.withTips {
+		if _, err := io.WriteString(out, tipsSeparator); err != nil {
+			return fmt.Errorf("failed to write tips separator: %w", err)
+		}
+
+		if _, err := io.WriteString(out, ex.RenderTips()); err != nil {
+			return fmt.Errorf("failed to write tips: %w", err)
+		}
+	}
+
+	if opts.withFingerprint {
+		if _, err := io.WriteString(out, fingerprintSeparator+ex.Fingerprint()+"\n\n"+ex.Shape()); err != nil {
+			return fmt.Errorf("failed to write fingerprint: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// anonymize anonymizes the plans consistently and, when mappingPath is set,
+// saves the mapping there.
+func anonymize(explains []*pgexplain.Explain, mappingPath string) error {
+	anonymizer := pgexplain.NewAnonymizer()
+
+	for _, ex := range explains {
+		anonymizer.Anonymize(ex)
+	}
+
+	if mappingPath == "" {
+		return nil
+	}
+
+	mapping, err := json.MarshalIndent(anonymizer.Mapping(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode mapping: %w", err)
+	}
+
+	if err := os.WriteFile(mappingPath, append(mapping, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to save mapping: %w", err)
+	}
+
+	return nil
+}
+
+// restore reads an anonymization mapping from mappingPath and writes the text
+// read from in to out with the placeholders replaced by the original names.
+func restore(mappingPath string, in io.Reader, out io.Writer) error {
+	data, err := os.ReadFile(mappingPath)
+	if err != nil {
+		return fmt.Errorf("failed to read mapping: %w", err)
+	}
+
+	var mapping pgexplain.Anonymization
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return fmt.Errorf("failed to parse mapping %s: %w", mappingPath, err)
+	}
+
+	text, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if _, err := io.WriteString(out, mapping.Restore(string(text))); err != nil {
+		return fmt.Errorf("failed to write restored text: %w", err)
+	}
+
+	return nil
+}
+
+// readExplains reads an EXPLAIN document in the given input format from in and
+// processes all of its plans; an empty or "auto" format is detected from the
+// input.
+func readExplains(in io.Reader, inputFormat string) ([]*pgexplain.Explain, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	format := pgexplain.Format(inputFormat)
+	if inputFormat == "" || inputFormat == inputFormatAuto {
+		format = pgexplain.DetectFormat(string(data))
+	}
+
+	explains, err := pgexplain.ParseExplains(string(data), format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse EXPLAIN %s: %w", strings.ToUpper(string(format)), err)
+	}
+
+	return explains, nil
+}
+
+// openExplain reads and processes the EXPLAIN document at path.
+func openExplain(path string) (*pgexplain.Explain, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	defer func() { _ = f.Close() }()
+
+	explains, err := readExplains(f, inputFormatAuto)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return explains[0], nil
+}
+
+// diff aligns the plans stored at beforePath and afterPath and writes the
+// annotated structural diff to out.
+func diff(beforePath, afterPath string, out io.Writer) error {
+	before, err := openExplain(beforePath)
+	if err != nil {
+		return err
+	}
+
+	after, err := openExplain(afterPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(out, pgexplain.DiffExplains(before, after).Render()); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+
 	return nil
 }
 
 // run resolves the input source (the given file path, or stdin when path is
-// empty) and hands it to render. It is split out from main so that a deferred
-// file close runs before main decides on the process exit code.
-func run(path string, out io.Writer, withStats bool) error {
+// empty) and hands it to render, or to restore when restorePath is set. It is
+// split out from main so that a deferred file close runs before main decides
+// on the process exit code.
+func run(path string, out io.Writer, opts options, restorePath string) error {
 	var in io.Reader = os.Stdin
 
 	if path != "" {
@@ -100,7 +492,11 @@ func run(path string, out io.Writer, withStats bool) error {
 		in = f
 	}
 
-	return render(in, out, withStats)
+	if restorePath != "" {
+		return restore(restorePath, in, out)
+	}
+
+	return render(in, out, opts)
 }
 
 func main() {
@@ -111,9 +507,60 @@ func main() {
 	}
 
 	withStats := flag.Bool("stats", false, "also render joe's stats summary after the plan")
+	withTips := flag.Bool("tips", false, "also render joe's plan recommendations")
+	withFingerprint := flag.Bool("fingerprint", false, "also render the plan fingerprint and the plan shape it hashes")
+	misestimates := flag.Bool("misestimates", false, "render the cardinality misestimate report instead of the plan")
+	diffMode := flag.Bool("diff", false, "compare two plans (before.json after.json) node by node")
+	format := flag.String("format", formatText, "output format: text, json (processed plan for tools), html (flame graph page), mermaid or dot (plan tree diagram)")
+	stream := flag.Bool("stream", false, "render every plan of the input, not just the first one")
+	inputFormat := flag.String("input-format", inputFormatAuto, "input format: auto, json, yaml, xml or text")
+	anonymizePlans := flag.Bool("anonymize", false, "replace names with placeholders and strip literals before rendering")
+	mappingPath := flag.String("mapping", "", "with -anonymize, save the placeholder-to-name mapping to this JSON file")
+	restorePath := flag.String("restore", "", "put the names of this mapping file back into the input text instead of rendering")
+	regressMode := flag.Bool("regress", false, "compare the plans in the given directories or globs with their golden .txt files")
+	goldenDir := flag.String("golden", "", "with -regress, the directory of the golden files (default: golden next to the version directories)")
+	update := flag.Bool("update", false, "with -regress, write the golden files instead of comparing")
+	assertSpec := flag.String("assert", "", "comma-separated plan checks, e.g. 'max_time<100ms,no_seqscan=orders'; exit 1 when one fails")
 
 	flag.Parse()
 
+	if *diffMode {
+		if flag.NArg() != 2 {
+			flag.Usage()
+
+			os.Exit(1)
+		}
+
+		if err := diff(flag.Arg(0), flag.Arg(1), os.Stdout); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if *regressMode {
+		if flag.NArg() == 0 {
+			flag.Usage()
+
+			os.Exit(1)
+		}
+
+		ok, err := regress(flag.Args(), os.Stdout, *goldenDir, *update)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+
+			os.Exit(1)
+		}
+
+		if !ok {
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	// flag stops parsing at the first non-flag argument, so a flag placed after
 	// the file path (e.g. `explainrender file.json -stats`) would be silently
 	// swallowed as a second positional. Reject extra arguments instead so the
@@ -124,7 +571,27 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := run(flag.Arg(0), os.Stdout, *withStats); err != nil {
+	assertions, err := parseAssertions(*assertSpec)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+
+		os.Exit(1)
+	}
+
+	opts := options{
+		format:          *format,
+		inputFormat:     *inputFormat,
+		withStats:       *withStats,
+		withTips:        *withTips,
+		withFingerprint: *withFingerprint,
+		misestimates:    *misestimates,
+		stream:          *stream,
+		anonymize:       *anonymizePlans,
+		mappingPath:     *mappingPath,
+		assertions:      assertions,
+	}
+
+	if err := run(flag.Arg(0), os.Stdout, opts, *restorePath); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, "error:", err)
 
 		os.Exit(1)
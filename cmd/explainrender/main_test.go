@@ -6,6 +6,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -39,10 +40,34 @@ const seqScanJSON = `[
   }
 ]`
 
+// sortDiskJSON is a single Sort node that spilled to disk, which the plan
+// analyzer reports as a SORT_DISK tip.
+const sortDiskJSON = `[
+  {
+    "Plan": {
+      "Node Type": "Sort",
+      "Startup Cost": 7824.37,
+      "Total Cost": 7949.76,
+      "Plan Rows": 50157,
+      "Plan Width": 22,
+      "Actual Startup Time": 21.495,
+      "Actual Total Time": 25.116,
+      "Actual Rows": 50000,
+      "Actual Loops": 1,
+      "Sort Key": ["items.descr"],
+      "Sort Method": "external merge",
+      "Sort Space Used": 1648,
+      "Sort Space Type": "Disk"
+    },
+    "Planning Time": 2.116,
+    "Execution Time": 26.219
+  }
+]`
+
 func TestRender(t *testing.T) {
 	t.Run("plan", func(t *testing.T) {
 		var buf bytes.Buffer
-		if err := render(strings.NewReader(seqScanJSON), &buf, false); err != nil {
+		if err := render(strings.NewReader(seqScanJSON), &buf, options{}); err != nil {
 			t.Fatalf("render returned an error: %v", err)
 		}
 
@@ -70,7 +95,7 @@ func TestRender(t *testing.T) {
 
 	t.Run("stats", func(t *testing.T) {
 		var buf bytes.Buffer
-		if err := render(strings.NewReader(seqScanJSON), &buf, true); err != nil {
+		if err := render(strings.NewReader(seqScanJSON), &buf, options{withStats: true}); err != nil {
 			t.Fatalf("render returned an error: %v", err)
 		}
 
@@ -89,9 +114,265 @@ func TestRender(t *testing.T) {
 		}
 	})
 
+	t.Run("tips", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := render(strings.NewReader(sortDiskJSON), &buf, options{withTips: true}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		out := buf.String()
+		if !strings.Contains(out, tipsSeparator) {
+			t.Errorf("tips output missing the tips separator %q\n--- output ---\n%s", tipsSeparator, out)
+		}
+		if !strings.Contains(out, "(SORT_DISK)") {
+			t.Errorf("tips output missing the SORT_DISK tip\n--- output ---\n%s", out)
+		}
+		if strings.Contains(out, statsSeparator) {
+			t.Errorf("tips-only output should not include the stats separator\n--- output ---\n%s", out)
+		}
+	})
+
+	t.Run("text input", func(t *testing.T) {
+		const planText = ` Seq Scan on joecap.t_items  (cost=0.00..9.25 rows=200 width=8) (actual time=0.006..0.036 rows=200 loops=1)
+   Filter: (t_items.val > 5)
+   Rows Removed by Filter: 300
+   Buffers: shared hit=3
+ Planning Time: 0.462 ms
+ Execution Time: 0.199 ms
+`
+
+		var buf bytes.Buffer
+		if err := render(strings.NewReader(planText), &buf, options{withStats: true}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{
+			"Seq Scan on joecap.t_items  (cost=0.00..9.25 rows=200 width=8)",
+			"Rows Removed by Filter: 300",
+			"planning: 0.462 ms",
+			"execution: 0.199 ms",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("text input output missing %q\n--- output ---\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("input formats", func(t *testing.T) {
+		const planYAML = "- Plan: \n    Node Type: \"Seq Scan\"\n    Relation Name: \"t_items\"\n" +
+			"    Schema: \"joecap\"\n    Alias: \"t_items\"\n    Startup Cost: 0.00\n    Total Cost: 9.25\n" +
+			"    Plan Rows: 200\n    Plan Width: 8\n"
+		const planXML = `<explain xmlns="http://www.postgresql.org/2009/explain"><Query><Plan>` +
+			`<Node-Type>Seq Scan</Node-Type><Relation-Name>t_items</Relation-Name><Schema>joecap</Schema>` +
+			`<Alias>t_items</Alias><Startup-Cost>0.00</Startup-Cost><Total-Cost>9.25</Total-Cost>` +
+			`<Plan-Rows>200</Plan-Rows><Plan-Width>8</Plan-Width></Plan></Query></explain>`
+
+		for _, tc := range []struct {
+			input       string
+			inputFormat string
+		}{
+			{planYAML, ""},
+			{planYAML, "yaml"},
+			{planXML, inputFormatAuto},
+			{planXML, "xml"},
+		} {
+			var buf bytes.Buffer
+			if err := render(strings.NewReader(tc.input), &buf, options{inputFormat: tc.inputFormat}); err != nil {
+				t.Fatalf("render(-input-format %q) returned an error: %v", tc.inputFormat, err)
+			}
+
+			if want := "Seq Scan on joecap.t_items  (cost=0.00..9.25 rows=200 width=8)"; !strings.Contains(buf.String(), want) {
+				t.Errorf("render(-input-format %q) output missing %q\n--- output ---\n%s", tc.inputFormat, want, buf.String())
+			}
+		}
+
+		var buf bytes.Buffer
+		err := render(strings.NewReader(planYAML), &buf, options{inputFormat: "json"})
+		if err == nil || !strings.Contains(err.Error(), "parse EXPLAIN JSON") {
+			t.Errorf("forcing JSON on a YAML plan should fail to parse as JSON, got: %v", err)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		input := seqScanJSON + "\n" + sortDiskJSON
+
+		var buf bytes.Buffer
+		if err := render(strings.NewReader(input), &buf, options{stream: true, withStats: true}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{
+			"===== PLAN 1 =====\n Seq Scan on joecap.t_items",
+			"\n===== PLAN 2 =====\n Sort  (cost=7824.37..7949.76",
+			"execution: 0.199 ms",
+			"execution: 26.219 ms",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("stream output missing %q\n--- output ---\n%s", want, out)
+			}
+		}
+		if strings.Count(out, statsSeparator) != 2 {
+			t.Errorf("stream output should carry one stats section per plan\n--- output ---\n%s", out)
+		}
+
+		// Without -stream only the first plan is rendered.
+		buf.Reset()
+		if err := render(strings.NewReader(input), &buf, options{}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+		if strings.Contains(buf.String(), "Sort") || strings.Contains(buf.String(), "===== PLAN") {
+			t.Errorf("non-stream output should only carry the first plan\n--- output ---\n%s", buf.String())
+		}
+
+		if err := render(strings.NewReader(input), &buf, options{stream: true, format: formatHTML}); err == nil {
+			t.Error("render should reject -stream with -format html")
+		}
+	})
+
+	t.Run("fingerprint", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := render(strings.NewReader(seqScanJSON), &buf, options{withFingerprint: true}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		_, section, found := strings.Cut(buf.String(), fingerprintSeparator)
+		if !found {
+			t.Fatalf("output missing the fingerprint section\n--- output ---\n%s", buf.String())
+		}
+
+		fingerprint, shape, _ := strings.Cut(section, "\n\n")
+		if len(fingerprint) != 16 {
+			t.Errorf("unexpected fingerprint %q", fingerprint)
+		}
+		if !strings.HasPrefix(shape, "Seq Scan on joecap.t_items [Filter: (t_items.val > ?)]") {
+			t.Errorf("unexpected plan shape %q", shape)
+		}
+	})
+
+	t.Run("misestimates", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := render(strings.NewReader(seqScanJSON), &buf, options{misestimates: true}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		if got := buf.String(); got != "No significant row count misestimates.\n" {
+			t.Errorf("unexpected report for an accurate plan\n--- output ---\n%s", got)
+		}
+
+		buf.Reset()
+
+		input := strings.Replace(seqScanJSON, `"Plan Rows": 200`, `"Plan Rows": 2`, 1)
+		if err := render(strings.NewReader(input), &buf, options{misestimates: true}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{
+			"  - Seq Scan on joecap.t_items: 100x underestimated (planned 2, actual 200)\n",
+			"    Relations: joecap.t_items (val)\n",
+			"      ALTER TABLE joecap.t_items ALTER COLUMN val SET STATISTICS 1000;\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("misestimate report missing %q\n--- output ---\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "cost=") {
+			t.Errorf("misestimate report should not include the text plan\n--- output ---\n%s", out)
+		}
+
+		if err := render(strings.NewReader(input), &buf, options{misestimates: true, format: formatHTML}); err == nil {
+			t.Error("render should reject -misestimates with -format html")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := render(strings.NewReader(seqScanJSON), &buf, options{format: formatJSON}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		var analysis struct {
+			Plan struct {
+				NodeType       string `json:"Node Type"`
+				ActualDuration float64
+				Slowest        bool
+			}
+			ContainsSeqScan bool
+			Fingerprint     string
+			Tips            []any
+		}
+		if err := json.Unmarshal(buf.Bytes(), &analysis); err != nil {
+			t.Fatalf("output is not a JSON object: %v\n--- output ---\n%s", err, buf.String())
+		}
+
+		if analysis.Plan.NodeType != "Seq Scan" || analysis.Plan.ActualDuration != 0.036 || !analysis.Plan.Slowest ||
+			!analysis.ContainsSeqScan || len(analysis.Fingerprint) != 16 || analysis.Tips == nil {
+			t.Errorf("unexpected analysis %+v\n--- output ---\n%s", analysis, buf.String())
+		}
+
+		buf.Reset()
+
+		input := seqScanJSON + "\n" + sortDiskJSON
+		if err := render(strings.NewReader(input), &buf, options{format: formatJSON, stream: true}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		var analyses []map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &analyses); err != nil || len(analyses) != 2 {
+			t.Errorf("-stream should write an array of both plans, got %d (%v)\n--- output ---\n%s", len(analyses), err, buf.String())
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := render(strings.NewReader(seqScanJSON), &buf, options{format: formatHTML}); err != nil {
+			t.Fatalf("render returned an error: %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{"<!DOCTYPE html>", "<svg", "Seq Scan on joecap.t_items"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("html output missing %q\n--- output ---\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "cost=0.00..9.25") {
+			t.Errorf("html output should not include the text plan\n--- output ---\n%s", out)
+		}
+	})
+
+	t.Run("diagrams", func(t *testing.T) {
+		for format, want := range map[string]string{
+			formatMermaid: `n1["Seq Scan on joecap.t_items<br/>rows: 200 actual / 200 planned`,
+			formatDOT:     `n1 [label="Seq Scan on joecap.t_items\nrows: 200 actual / 200 planned`,
+		} {
+			var buf bytes.Buffer
+			if err := render(strings.NewReader(seqScanJSON), &buf, options{format: format}); err != nil {
+				t.Fatalf("render -format %s returned an error: %v", format, err)
+			}
+
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s output missing %q\n--- output ---\n%s", format, want, buf.String())
+			}
+
+			if err := render(strings.NewReader(seqScanJSON), &buf, options{format: format, stream: true}); err == nil {
+				t.Errorf("-format %s should not be combined with -stream", format)
+			}
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := render(strings.NewReader(seqScanJSON), &buf, options{format: "pdf"})
+		if err == nil || !strings.Contains(err.Error(), `"pdf"`) {
+			t.Fatalf("render should reject an unknown format, got: %v", err)
+		}
+	})
+
 	t.Run("parse error", func(t *testing.T) {
 		var buf bytes.Buffer
-		err := render(strings.NewReader("{ this is not valid explain json"), &buf, false)
+		err := render(strings.NewReader("{ this is not valid explain json"), &buf, options{})
 		if err == nil {
 			t.Fatal("render should return an error for malformed JSON, got nil")
 		}
@@ -113,7 +394,7 @@ func TestRender(t *testing.T) {
 		} {
 			t.Run(name, func(t *testing.T) {
 				var buf bytes.Buffer
-				if err := render(strings.NewReader(input), &buf, false); err == nil {
+				if err := render(strings.NewReader(input), &buf, options{}); err == nil {
 					t.Errorf("render(%q) should return an error, got nil", input)
 				}
 			})
@@ -132,7 +413,7 @@ func TestRun(t *testing.T) {
 		}
 
 		var buf bytes.Buffer
-		if err := run(path, &buf, false); err != nil {
+		if err := run(path, &buf, options{}, ""); err != nil {
 			t.Fatalf("run returned an error: %v", err)
 		}
 
@@ -145,7 +426,7 @@ func TestRun(t *testing.T) {
 		path := filepath.Join(t.TempDir(), "does-not-exist.json")
 
 		var buf bytes.Buffer
-		err := run(path, &buf, false)
+		err := run(path, &buf, options{}, "")
 		if err == nil {
 			t.Fatal("run should return an error for a nonexistent file, got nil")
 		}
@@ -154,3 +435,85 @@ func TestRun(t *testing.T) {
 		}
 	})
 }
+
+// TestAnonymize covers -anonymize with -mapping and restoring the names with
+// -restore.
+func TestAnonymize(t *testing.T) {
+	mappingPath := filepath.Join(t.TempDir(), "mapping.json")
+
+	var anonymized bytes.Buffer
+	if err := render(strings.NewReader(seqScanJSON), &anonymized, options{anonymize: true, mappingPath: mappingPath}); err != nil {
+		t.Fatalf("render returned an error: %v", err)
+	}
+
+	out := anonymized.String()
+	if !strings.Contains(out, "Seq Scan on schema_1.table_1") || !strings.Contains(out, "Filter: (table_1.column_1 > ?)") {
+		t.Errorf("output is not anonymized\n--- output ---\n%s", out)
+	}
+
+	for _, name := range []string{"joecap", "t_items", "val"} {
+		if strings.Contains(out, name) {
+			t.Errorf("output leaks %q\n--- output ---\n%s", name, out)
+		}
+	}
+
+	var restored bytes.Buffer
+	if err := restore(mappingPath, strings.NewReader(out), &restored); err != nil {
+		t.Fatalf("restore returned an error: %v", err)
+	}
+
+	if !strings.Contains(restored.String(), "Seq Scan on joecap.t_items") || !strings.Contains(restored.String(), "Filter: (t_items.val > ?)") {
+		t.Errorf("names are not restored\n--- output ---\n%s", restored.String())
+	}
+
+	if err := render(strings.NewReader(seqScanJSON), &bytes.Buffer{}, options{mappingPath: mappingPath}); err == nil {
+		t.Error("-mapping without -anonymize should return an error")
+	}
+}
+
+// TestDiff covers the -diff mode: two plan files in, an annotated diff out.
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+
+	beforePath := filepath.Join(dir, "before.json")
+	if err := os.WriteFile(beforePath, []byte(seqScanJSON), 0o600); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+
+	afterJSON := strings.Replace(seqScanJSON, `"Node Type": "Seq Scan",`,
+		`"Node Type": "Index Scan", "Index Name": "t_items_val_idx",`, 1)
+
+	afterPath := filepath.Join(dir, "after.json")
+	if err := os.WriteFile(afterPath, []byte(afterJSON), 0o600); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+
+	t.Run("changed", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := diff(beforePath, afterPath, &buf); err != nil {
+			t.Fatalf("diff returned an error: %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{
+			"~ Seq Scan on joecap.t_items -> Index Scan using t_items_val_idx on joecap.t_items",
+			"node type: Seq Scan -> Index Scan",
+			"index: (none) -> t_items_val_idx",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("diff output missing %q\n--- output ---\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := diff(beforePath, filepath.Join(dir, "does-not-exist.json"), &buf)
+		if err == nil {
+			t.Fatal("diff should return an error for a nonexistent file, got nil")
+		}
+		if !strings.Contains(err.Error(), "failed to open") {
+			t.Errorf("error should mention failing to open the file, got: %v", err)
+		}
+	})
+}
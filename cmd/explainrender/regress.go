@@ -0,0 +1,336 @@
+/*
+2026 © Postgres.ai
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// regressSeparator delimits the plan from the stats in a golden file, like in
+// the golden tests of pkg/pgexplain.
+const regressSeparator = "\n--- stats ---\n"
+
+// regressContext is the number of unchanged lines around changes in diffs.
+const regressContext = 3
+
+// regressExtensions are the plan captures a directory is searched for.
+var regressExtensions = []string{".json", ".yaml", ".xml"}
+
+// regressSettingsLine matches the "Settings:" line, whose entries are rendered
+// from a map in varying order.
+var regressSettingsLine = regexp.MustCompile(`(?m)^Settings: (.+)$`)
+
+// regressResult counts the outcomes of a regression run.
+type regressResult struct {
+	passed, failed, missing, errors, updated int
+}
+
+func (r regressResult) ok() bool {
+	return r.failed == 0 && r.missing == 0 && r.errors == 0
+}
+
+// regress renders every plan capture matched by the patterns, directories or
+// globs, and compares the output with its golden file: a plan at
+// <root>/<version>/<name>.json has its golden at <root>/golden/<version>/<name>.txt,
+// or at <goldenDir>/<version>/<name>.txt when goldenDir is set. YAML and XML
+// captures share the golden of the JSON capture of the same name. A unified
+// diff is written for every mismatch, then a summary; with update, the golden
+// files are (re)written instead. It reports whether all plans matched.
+func regress(patterns []string, out io.Writer, goldenDir string, update bool) (bool, error) {
+	paths, err := regressPaths(patterns)
+	if err != nil {
+		return false, err
+	}
+
+	if len(paths) == 0 {
+		return false, fmt.Errorf("no plan captures found in %s", strings.Join(patterns, ", "))
+	}
+
+	var result regressResult
+
+	for _, path := range paths {
+		name := regressName(path)
+		goldenPath := regressGoldenPath(path, goldenDir)
+
+		rendered, err := renderGolden(path)
+		if err != nil {
+			result.errors++
+			_, _ = fmt.Fprintf(out, "ERROR   %s: %v\n", name, err)
+
+			continue
+		}
+
+		if update {
+			if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+				return false, fmt.Errorf("failed to create golden directory: %w", err)
+			}
+
+			if err := os.WriteFile(goldenPath, []byte(rendered), 0o644); err != nil {
+				return false, fmt.Errorf("failed to write golden %s: %w", goldenPath, err)
+			}
+
+			result.updated++
+			_, _ = fmt.Fprintf(out, "UPDATED %s\n", name)
+
+			continue
+		}
+
+		want, err := os.ReadFile(goldenPath)
+		if err != nil {
+			result.missing++
+			_, _ = fmt.Fprintf(out, "MISSING %s: no golden %s\n", name, goldenPath)
+
+			continue
+		}
+
+		if string(want) == rendered {
+			result.passed++
+			_, _ = fmt.Fprintf(out, "ok      %s\n", name)
+
+			continue
+		}
+
+		result.failed++
+		_, _ = fmt.Fprintf(out, "FAIL    %s\n%s", name, unifiedDiff(goldenPath, path, string(want), rendered))
+	}
+
+	if update {
+		_, _ = fmt.Fprintf(out, "\n%d plans: %d golden files updated, %d errors\n", len(paths), result.updated, result.errors)
+
+		return result.errors == 0, nil
+	}
+
+	_, _ = fmt.Fprintf(out, "\n%d plans: %d ok, %d failed, %d missing golden, %d errors\n",
+		len(paths), result.passed, result.failed, result.missing, result.errors)
+
+	return result.ok(), nil
+}
+
+// regressPaths expands the patterns into the sorted plan capture paths; a
+// directory stands for the captures in it and in its subdirectories.
+func regressPaths(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+
+	var paths []string
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", match, err)
+			}
+
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			err = filepath.WalkDir(match, func(path string, entry os.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				// Golden files live next to the captures; never take them for input.
+				if entry.IsDir() && entry.Name() == "golden" {
+					return filepath.SkipDir
+				}
+
+				for _, extension := range regressExtensions {
+					if !entry.IsDir() && filepath.Ext(path) == extension {
+						add(path)
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", match, err)
+			}
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// regressGoldenPath returns the golden file of a plan capture.
+func regressGoldenPath(path, goldenDir string) string {
+	dir := filepath.Dir(path)
+	version := filepath.Base(dir)
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	if goldenDir == "" {
+		goldenDir = filepath.Join(filepath.Dir(dir), "golden")
+	}
+
+	return filepath.Join(goldenDir, version, name+".txt")
+}
+
+// regressName names a capture in the report like "pg18/nested_loop.json".
+func regressName(path string) string {
+	return filepath.Base(filepath.Dir(path)) + "/" + filepath.Base(path)
+}
+
+// renderGolden renders the first plan of the capture at path as its golden
+// file stores it: the text plan, regressSeparator and the stats, with the
+// settings sorted.
+func renderGolden(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	defer func() { _ = f.Close() }()
+
+	explains, err := readExplains(f, inputFormatAuto)
+	if err != nil {
+		return "", err
+	}
+
+	out := explains[0].RenderPlanText() + regressSeparator + explains[0].RenderStats()
+
+	return regressSettingsLine.ReplaceAllStringFunc(out, func(line string) string {
+		const prefix = "Settings: "
+
+		entries := strings.Split(strings.TrimPrefix(line, prefix), ", ")
+		sort.Strings(entries)
+
+		return prefix + strings.Join(entries, ", ")
+	}), nil
+}
+
+// diffLine is a line of a line-based diff: ' ' unchanged, '-' removed, '+' added.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff returns the unified diff, as printed by `diff -u`, that turns
+// want into got; it is empty when they are equal.
+func unifiedDiff(wantName, gotName, want, got string) string {
+	dmp := diffmatchpatch.New()
+
+	wantChars, gotChars, lines := dmp.DiffLinesToChars(want, got)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(wantChars, gotChars, false), lines)
+
+	var diffLines []diffLine
+
+	for _, diff := range diffs {
+		kind := byte(' ')
+
+		switch diff.Type {
+		case diffmatchpatch.DiffDelete:
+			kind = '-'
+		case diffmatchpatch.DiffInsert:
+			kind = '+'
+		}
+
+		for _, line := range strings.SplitAfter(diff.Text, "\n") {
+			if line != "" {
+				diffLines = append(diffLines, diffLine{kind: kind, text: line})
+			}
+		}
+	}
+
+	var changes []int
+
+	for index, line := range diffLines {
+		if line.kind != ' ' {
+			changes = append(changes, index)
+		}
+	}
+
+	if len(changes) == 0 {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", wantName, gotName)
+
+	// Changes closer than twice the context share a hunk.
+	for first := 0; first < len(changes); {
+		last := first
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*regressContext {
+			last++
+		}
+
+		start := max(changes[first]-regressContext, 0)
+		end := min(changes[last]+regressContext+1, len(diffLines))
+
+		writeHunk(&sb, diffLines, start, end)
+
+		first = last + 1
+	}
+
+	return sb.String()
+}
+
+// writeHunk writes the lines [start, end) of a diff as a hunk with its header.
+func writeHunk(sb *strings.Builder, diffLines []diffLine, start, end int) {
+	wantLine, gotLine := 1, 1
+
+	for _, line := range diffLines[:start] {
+		if line.kind != '+' {
+			wantLine++
+		}
+
+		if line.kind != '-' {
+			gotLine++
+		}
+	}
+
+	var wantCount, gotCount int
+
+	for _, line := range diffLines[start:end] {
+		if line.kind != '+' {
+			wantCount++
+		}
+
+		if line.kind != '-' {
+			gotCount++
+		}
+	}
+
+	// An empty side starts before its first line, as in diff -u.
+	if wantCount == 0 {
+		wantLine--
+	}
+
+	if gotCount == 0 {
+		gotLine--
+	}
+
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", wantLine, wantCount, gotLine, gotCount)
+
+	for _, line := range diffLines[start:end] {
+		sb.WriteByte(line.kind)
+		sb.WriteString(line.text)
+
+		if !strings.HasSuffix(line.text, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+2026 © Postgres.ai
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegress(t *testing.T) {
+	root := t.TempDir()
+
+	for path, content := range map[string]string{
+		"pg17/seq_scan.json": seqScanJSON,
+		"pg17/sort.json":     sortDiskJSON,
+		"pg18/broken.json":   "{ not a plan",
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pg17 := filepath.Join(root, "pg17")
+
+	var buf bytes.Buffer
+
+	ok, err := regress([]string{pg17}, &buf, "", false)
+	if err != nil || ok {
+		t.Fatalf("regress without golden files should fail, got ok=%v, err=%v", ok, err)
+	}
+
+	if !strings.Contains(buf.String(), "MISSING pg17/seq_scan.json") ||
+		!strings.HasSuffix(buf.String(), "\n2 plans: 0 ok, 0 failed, 2 missing golden, 0 errors\n") {
+		t.Errorf("unexpected report\n--- output ---\n%s", buf.String())
+	}
+
+	buf.Reset()
+
+	if ok, err := regress([]string{pg17}, &buf, "", true); err != nil || !ok {
+		t.Fatalf("regress -update failed: ok=%v, err=%v\n--- output ---\n%s", ok, err, buf.String())
+	}
+
+	golden, err := os.ReadFile(filepath.Join(root, "golden", "pg17", "seq_scan.txt"))
+	if err != nil {
+		t.Fatalf("-update should write the golden next to the version directory: %v", err)
+	}
+
+	if !strings.Contains(string(golden), "Seq Scan on joecap.t_items") || !strings.Contains(string(golden), regressSeparator) {
+		t.Errorf("unexpected golden\n%s", golden)
+	}
+
+	buf.Reset()
+
+	if ok, err := regress([]string{filepath.Join(root, "pg17", "*.json")}, &buf, "", false); err != nil || !ok {
+		t.Fatalf("regress should pass after -update: ok=%v, err=%v\n--- output ---\n%s", ok, err, buf.String())
+	}
+
+	// A renderer change shows up as a diff against the golden file.
+	changed := strings.Replace(string(golden), "rows=200 width=8", "rows=100 width=8", 1)
+	if err := os.WriteFile(filepath.Join(root, "golden", "pg17", "seq_scan.txt"), []byte(changed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+
+	ok, err = regress([]string{root}, &buf, "", false)
+	if err != nil || ok {
+		t.Fatalf("regress should fail on a mismatch, got ok=%v, err=%v", ok, err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"FAIL    pg17/seq_scan.json\n--- " + filepath.Join(root, "golden", "pg17", "seq_scan.txt") + "\n",
+		"\n@@ -1,",
+		"\n- Seq Scan on joecap.t_items  (cost=0.00..9.25 rows=100 width=8)",
+		"\n+ Seq Scan on joecap.t_items  (cost=0.00..9.25 rows=200 width=8)",
+		"ok      pg17/sort.json\n",
+		"ERROR   pg18/broken.json: ",
+		"\n3 plans: 1 ok, 1 failed, 0 missing golden, 1 errors\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q\n--- output ---\n%s", want, out)
+		}
+	}
+
+	if _, err := regress([]string{filepath.Join(root, "nothing", "*.json")}, &buf, "", false); err == nil {
+		t.Error("regress should fail when no plans match")
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	want := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	got := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+
+	if diff := unifiedDiff("want", "got", want, want); diff != "" {
+		t.Errorf("equal texts should have no diff, got:\n%s", diff)
+	}
+
+	expected := `--- want
++++ got
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`
+
+	if diff := unifiedDiff("want", "got", want, got); diff != expected {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", diff, expected)
+	}
+
+	expected = "--- want\n+++ got\n@@ -1,1 +1,1 @@\n-x\n\\ No newline at end of file\n+y\n\\ No newline at end of file\n"
+	if diff := unifiedDiff("want", "got", "x", "y"); diff != expected {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
@@ -19,7 +19,15 @@ type CommandFactoryMethod func(*platform.Command, *models.Message, *pgxpool.Pool
 
 var commandBuilder CommandFactoryMethod
 
+// commandDescriptions describes the Enterprise commands the builder creates.
+var commandDescriptions []definition.CommandDescription
+
 // GetBuilder gets builder initialized Enterprise command builder.
 func GetBuilder() CommandFactoryMethod {
 	return commandBuilder
 }
+
+// GetCommands gets descriptions of the Enterprise commands to register.
+func GetCommands() []definition.CommandDescription {
+	return commandDescriptions
+}
@@ -6,6 +6,10 @@
 package definition
 
 // CmdBuilder provides a builder for Enterprise commands.
+//
+// The builder is created for every received Enterprise command; to run the
+// command, it has to implement Executor, e.g. by dispatching on the name of
+// the received command.
 type CmdBuilder interface {
 }
 
@@ -13,3 +17,12 @@ type CmdBuilder interface {
 type Executor interface {
 	Execute() error
 }
+
+// CommandDescription describes an Enterprise command for the command registry.
+type CommandDescription struct {
+	Name         string
+	Aliases      []string
+	Usage        string // The arguments shown in the help message after each name, e.g. "[period]".
+	Help         string // Shown in the help message after the names, e.g. "show the quota usage".
+	NeedsSession bool
+}
@@ -24,3 +24,8 @@ var _ definition.CmdBuilder = (*CommunityBuilder)(nil)
 func NewBuilder(_ *platform.Command, _ *models.Message, _ *pgxpool.Pool, _ connection.Messenger) definition.CmdBuilder {
 	return &CommunityBuilder{}
 }
+
+// Commands describes the commands the builder creates; the Community edition has no Enterprise commands.
+func Commands() []definition.CommandDescription {
+	return nil
+}
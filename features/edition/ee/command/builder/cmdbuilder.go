@@ -35,3 +35,8 @@ func NewBuilder(apiCmd *platform.Command, msg *models.Message, db *pgxpool.Pool,
 		messenger:  msgSvc,
 	}
 }
+
+// Commands describes the Enterprise commands the builder creates.
+func Commands() []definition.CommandDescription {
+	return nil
+}
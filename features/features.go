@@ -11,18 +11,21 @@ import (
 // Pack defines enterprise feature helpers.
 type Pack struct {
 	cmdBuilder  CommandFactoryMethod
+	commands    []definition.CommandDescription
 	entertainer definition.Entertainer
 }
 
 // NewPack creates a new features pack.
 func NewPack() *Pack {
-	return buildPack(GetBuilder(), GetEntertainer())
+	return BuildPack(GetBuilder(), GetCommands(), GetEntertainer())
 }
 
-// buildPack builds a new features pack.
-func buildPack(cmdBuilder CommandFactoryMethod, entertainer definition.Entertainer) *Pack {
+// BuildPack builds a features pack of the given parts, e.g. to provide extra commands.
+func BuildPack(cmdBuilder CommandFactoryMethod, commands []definition.CommandDescription,
+	entertainer definition.Entertainer) *Pack {
 	return &Pack{
 		cmdBuilder:  cmdBuilder,
+		commands:    commands,
 		entertainer: entertainer,
 	}
 }
@@ -32,6 +35,11 @@ func (p *Pack) CmdBuilder() CommandFactoryMethod {
 	return p.cmdBuilder
 }
 
+// Commands provides descriptions of the Enterprise commands built by CmdBuilder.
+func (p *Pack) Commands() []definition.CommandDescription {
+	return p.commands
+}
+
 // Entertainer provides an entertainer service.
 func (p *Pack) Entertainer() definition.Entertainer {
 	return p.entertainer
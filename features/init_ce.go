@@ -17,6 +17,7 @@ import (
 // nolint:gochecknoinits
 func init() {
 	commandBuilder = builder.NewBuilder
+	commandDescriptions = builder.Commands()
 	optionProvider = &options.Extra{}
 	entertainerService = entertainer.New()
 }
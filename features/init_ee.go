@@ -16,6 +16,7 @@ import (
 // nolint:gochecknoinits
 func init() {
 	commandBuilder = builder.NewBuilder
+	commandDescriptions = builder.Commands()
 	optionProvider = &options.Provider{}
 	entertainerService = entertainer.New()
 }
@@ -239,7 +239,7 @@ func (a *App) setupChannels(ctx context.Context, assistant connection.Assistant,
 
 		a.dblabMu.RUnlock()
 		dbLabInstance.SetCfg(channel.DBLabParams)
-		assistant.AddChannel(channel.ChannelID, dbLabInstance)
+		assistant.AddChannel(channel, dbLabInstance)
 
 		log.Dbg("Set up channel:", channel.ChannelID)
 	}
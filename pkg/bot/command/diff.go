@@ -0,0 +1,93 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/log"
+
+	"gitlab.com/postgres-ai/joe/features/definition"
+	"gitlab.com/postgres-ai/joe/pkg/connection"
+	"gitlab.com/postgres-ai/joe/pkg/models"
+	"gitlab.com/postgres-ai/joe/pkg/pgexplain"
+	"gitlab.com/postgres-ai/joe/pkg/services/platform"
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
+	"gitlab.com/postgres-ai/joe/pkg/util/text"
+)
+
+// msgDiffNotEnoughPlans describes a diff error.
+const msgDiffNotEnoughPlans = "Run `explain` at least twice in this session to compare plans with `diff`"
+
+// DiffCmd defines the diff command.
+type DiffCmd struct {
+	command   *platform.Command
+	message   *models.Message
+	history   []usermanager.ExplainResult
+	messenger connection.Messenger
+}
+
+var _ definition.Executor = (*DiffCmd)(nil)
+
+// NewDiffCmd returns a new diff command comparing the last two explain results of the session.
+func NewDiffCmd(cmd *platform.Command, msg *models.Message, session usermanager.UserSession,
+	messengerSvc connection.Messenger) *DiffCmd {
+	return &DiffCmd{
+		command:   cmd,
+		message:   msg,
+		history:   session.ExplainHistory,
+		messenger: messengerSvc,
+	}
+}
+
+// Execute runs the diff command.
+func (c *DiffCmd) Execute() error {
+	if len(c.history) < 2 {
+		return errors.New(msgDiffNotEnoughPlans)
+	}
+
+	beforeResult, afterResult := c.history[len(c.history)-2], c.history[len(c.history)-1]
+
+	before, err := pgexplain.NewExplain(beforeResult.PlanJSON)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the previous plan")
+	}
+
+	after, err := pgexplain.NewExplain(afterResult.PlanJSON)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the last plan")
+	}
+
+	planDiff := pgexplain.DiffExplains(before, after).Render()
+	c.command.Response = planDiff
+
+	diffPreview, isTruncated := text.CutText(planDiff, PlanSize, SeparatorPlan)
+
+	c.message.AppendText(fmt.Sprintf("*Plan diff (previous → last explain):*\n```%s```", diffPreview))
+	if err := c.messenger.UpdateText(c.message); err != nil {
+		log.Err("Show plan diff: ", err)
+		return err
+	}
+
+	fileDiffPermalink, err := c.messenger.AddArtifact("plan-diff", planDiff, c.message.ChannelID, c.message.MessageID)
+	if err != nil {
+		log.Err("File upload failed:", err)
+		return err
+	}
+
+	detailsText := ""
+	if isTruncated {
+		detailsText = " " + CutText
+	}
+
+	c.message.AppendText(fmt.Sprintf("<%s|Full plan diff>%s", fileDiffPermalink, detailsText))
+	if err := c.messenger.UpdateText(c.message); err != nil {
+		log.Err("File: ", err)
+		return err
+	}
+
+	return nil
+}
@@ -6,9 +6,13 @@ package command
 
 import (
 	"context"
+	"encoding/csv"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/dustin/go-humanize/english"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
@@ -17,6 +21,8 @@ import (
 	dblabmodels "gitlab.com/postgres-ai/database-lab/v3/pkg/models"
 	"gitlab.com/postgres-ai/database-lab/v3/pkg/util"
 
+	"gitlab.com/postgres-ai/joe/pkg/bot/querier"
+	"gitlab.com/postgres-ai/joe/pkg/config"
 	"gitlab.com/postgres-ai/joe/pkg/connection"
 	"gitlab.com/postgres-ai/joe/pkg/models"
 	"gitlab.com/postgres-ai/joe/pkg/services/platform"
@@ -26,28 +32,49 @@ import (
 const (
 	// msgExecOptionReq describes an exec error.
 	msgExecOptionReq = "Use `exec` to run query, e.g. `exec drop index some_index_name`"
+
+	// execMaxCellLength caps the values of the result shown in the message; the CSV file has them in full.
+	execMaxCellLength = 100
 )
 
+// rowCountingTags are the commands whose tags count the rows they affect.
+var rowCountingTags = []string{"INSERT", "UPDATE", "DELETE", "MERGE", "COPY", "SELECT"}
+
 // ExecCmd defines the exec command.
 type ExecCmd struct {
 	command   *platform.Command
 	message   *models.Message
 	pool      *pgxpool.Pool
 	userConn  *pgx.Conn
+	running   *usermanager.RunningCommands
 	messenger connection.Messenger
 	clone     *dblabmodels.Clone
+	session   *usermanager.UserSession
+	timeout   StatementTimeout
+	maxRows   int
+
+	// maxFileRows and maxFileBytes cap the rows read from a result and attached as a CSV file.
+	maxFileRows  int
+	maxFileBytes int
 }
 
 // NewExec return a new exec command.
-func NewExec(command *platform.Command, msg *models.Message, session usermanager.UserSession,
-	messengerSvc connection.Messenger) *ExecCmd {
+func NewExec(command *platform.Command, msg *models.Message, session *usermanager.UserSession,
+	messengerSvc connection.Messenger, timeout StatementTimeout, results config.ExecResults) *ExecCmd {
 	return &ExecCmd{
 		command:   command,
 		message:   msg,
 		pool:      session.Pool,
 		userConn:  session.CloneConnection,
+		running:   session.Running,
 		clone:     session.Clone,
 		messenger: messengerSvc,
+		session:   session,
+		timeout:   timeout,
+		maxRows:   results.RowLimit(),
+
+		maxFileRows:  results.FileRowLimit(),
+		maxFileBytes: results.FileByteLimit(),
 	}
 }
 
@@ -65,11 +92,24 @@ func (cmd ExecCmd) Execute(ctx context.Context) error {
 
 	defer serviceConn.Release()
 
+	// Let `stop` cancel the query.
+	if err := cmd.running.SetBackendPID(cmd.message, int(cmd.userConn.PgConn().PID())); err != nil {
+		return err
+	}
+
+	restoreTimeout, err := cmd.timeout.set(ctx, cmd.session)
+	if err != nil {
+		return err
+	}
+
+	defer restoreTimeout()
+
 	start := time.Now()
 
-	if _, err := cmd.userConn.Exec(ctx, cmd.command.Query); err != nil {
+	results, err := querier.ExecStatements(ctx, cmd.userConn.PgConn(), cmd.command.Query, cmd.maxFileRows, cmd.maxFileBytes)
+	if err != nil {
 		log.Err("Failed to exec command: ", err)
-		return err
+		return cmd.timeout.wrapError(err)
 	}
 
 	totalTime := util.DurationToString(time.Since(start))
@@ -79,7 +119,33 @@ func (cmd ExecCmd) Execute(ctx context.Context) error {
 		return err
 	}
 
-	result := fmt.Sprintf("The query has been executed. Duration: %s", totalTime)
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "The query has been executed. Duration: %s", totalTime)
+
+	for index, statementResult := range results {
+		sb.WriteString("\n")
+		sb.WriteString(renderStatementResult(statementResult, cmd.maxRows))
+
+		if len(statementResult.Columns) == 0 {
+			continue
+		}
+
+		permalink, err := cmd.messenger.AddArtifact(resultTitle(statementResult, index, len(results)),
+			resultCSV(statementResult), cmd.message.ChannelID, cmd.message.MessageID)
+		if err != nil {
+			log.Err("File upload failed:", err)
+			return err
+		}
+
+		linkText := "Full result (CSV)"
+		if statementResult.Truncated {
+			linkText = fmt.Sprintf("Result (CSV), the first %s", english.Plural(len(statementResult.Rows), "row", ""))
+		}
+
+		fmt.Fprintf(&sb, "\n<%s|%s>", permalink, linkText)
+	}
+
+	result := sb.String()
 
 	cmd.command.Response = result
 	cmd.message.AppendText(result)
@@ -92,6 +158,86 @@ func (cmd ExecCmd) Execute(ctx context.Context) error {
 	return nil
 }
 
+// renderStatementResult describes the outcome of a statement: the rows it returns, up to maxRows,
+// the number of the rows it affects, or its command tag.
+func renderStatementResult(result querier.StatementResult, maxRows int) string {
+	tag := result.CommandTag.String()
+
+	if len(result.Columns) == 0 {
+		verb, _, _ := strings.Cut(tag, " ")
+		if !slices.Contains(rowCountingTags, verb) {
+			return fmt.Sprintf("`%s`", tag)
+		}
+
+		return fmt.Sprintf("`%s`: %s affected.", tag, english.Plural(int(result.CommandTag.RowsAffected()), "row", ""))
+	}
+
+	sb := &strings.Builder{}
+
+	if len(result.Rows) > maxRows {
+		fmt.Fprintf(sb, "`%s`, the first %s:\n", tag, english.Plural(maxRows, "row", ""))
+	} else {
+		fmt.Fprintf(sb, "`%s`:\n", tag)
+	}
+
+	table := [][]string{result.Columns}
+
+	for _, row := range result.Rows[:min(len(result.Rows), maxRows)] {
+		cells := make([]string, 0, len(row))
+		for _, value := range row {
+			cells = append(cells, cutValue(value))
+		}
+
+		table = append(table, cells)
+	}
+
+	querier.RenderTable(sb, table)
+
+	if result.Truncated {
+		fmt.Fprintf(sb, "\nThe result is too large, it is cut to the first %s.", english.Plural(len(result.Rows), "row", ""))
+	}
+
+	return sb.String()
+}
+
+// resultTitle names the CSV file of the result of the statement with the index out of the total,
+// telling whether the result has been cut.
+func resultTitle(result querier.StatementResult, index, total int) string {
+	title := "exec-result"
+
+	if total > 1 {
+		title += fmt.Sprintf("-%d", index+1)
+	}
+
+	if result.Truncated {
+		title += "-truncated"
+	}
+
+	return title + "-csv"
+}
+
+// cutValue cuts a value longer than execMaxCellLength characters.
+func cutValue(value string) string {
+	runes := []rune(value)
+	if len(runes) <= execMaxCellLength {
+		return value
+	}
+
+	return string(runes[:execMaxCellLength-1]) + "…"
+}
+
+// resultCSV renders the rows returned by a statement as CSV with a header.
+func resultCSV(result querier.StatementResult) string {
+	sb := &strings.Builder{}
+	writer := csv.NewWriter(sb)
+
+	// Writing to a strings.Builder does not fail.
+	_ = writer.Write(result.Columns)
+	_ = writer.WriteAll(result.Rows)
+
+	return sb.String()
+}
+
 // getConn returns an acquired connection and Postgres backend PID.
 func getConn(ctx context.Context, db *pgxpool.Pool) (*pgxpool.Conn, error) {
 	conn, err := db.Acquire(ctx)
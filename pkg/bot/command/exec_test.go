@@ -0,0 +1,68 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/postgres-ai/joe/pkg/bot/querier"
+)
+
+func TestRenderStatementResultWithoutRows(t *testing.T) {
+	assert.Equal(t, "`UPDATE 5`: 5 rows affected.",
+		renderStatementResult(querier.StatementResult{CommandTag: pgconn.NewCommandTag("UPDATE 5")}, 20))
+	assert.Equal(t, "`INSERT 0 1`: 1 row affected.",
+		renderStatementResult(querier.StatementResult{CommandTag: pgconn.NewCommandTag("INSERT 0 1")}, 20))
+	assert.Equal(t, "`CREATE INDEX`",
+		renderStatementResult(querier.StatementResult{CommandTag: pgconn.NewCommandTag("CREATE INDEX")}, 20))
+}
+
+func TestRenderStatementResultWithRows(t *testing.T) {
+	result := querier.StatementResult{
+		Columns:    []string{"id", "name"},
+		Rows:       [][]string{{"1", "alice"}, {"2", strings.Repeat("b", 150)}, {"3", ""}},
+		CommandTag: pgconn.NewCommandTag("SELECT 3"),
+	}
+
+	text := renderStatementResult(result, 2)
+	assert.True(t, strings.HasPrefix(text, "`SELECT 3`, the first 2 rows:\n```"), text)
+	assert.Contains(t, text, "alice")
+	assert.Contains(t, text, strings.Repeat("b", 99)+"…")
+	assert.NotContains(t, text, strings.Repeat("b", 100))
+	assert.NotContains(t, text, " 3 ")
+
+	text = renderStatementResult(result, 20)
+	assert.True(t, strings.HasPrefix(text, "`SELECT 3`:\n```"), text)
+}
+
+func TestResultCSV(t *testing.T) {
+	result := querier.StatementResult{
+		Columns: []string{"id", "note"},
+		Rows:    [][]string{{"1", "plain"}, {"2", "with, comma and \"quotes\""}},
+	}
+
+	assert.Equal(t, "id,note\n1,plain\n2,\"with, comma and \"\"quotes\"\"\"\n", resultCSV(result))
+}
+
+func TestRenderStatementResultTruncated(t *testing.T) {
+	result := querier.StatementResult{
+		Columns:    []string{"id"},
+		Rows:       [][]string{{"1"}, {"2"}, {"3"}},
+		CommandTag: pgconn.NewCommandTag("SELECT 1000000"),
+		Truncated:  true,
+	}
+
+	text := renderStatementResult(result, 2)
+	assert.True(t, strings.HasPrefix(text, "`SELECT 1000000`, the first 2 rows:\n```"), text)
+	assert.True(t, strings.HasSuffix(text, "```\nThe result is too large, it is cut to the first 3 rows."), text)
+
+	assert.Equal(t, "exec-result-truncated-csv", resultTitle(result, 0, 1))
+	assert.Equal(t, "exec-result-2-truncated-csv", resultTitle(result, 1, 2))
+	assert.Equal(t, "exec-result-csv", resultTitle(querier.StatementResult{}, 0, 1))
+}
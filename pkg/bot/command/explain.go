@@ -6,6 +6,7 @@ package command
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -16,6 +17,7 @@ import (
 	"gitlab.com/postgres-ai/database-lab/v3/pkg/log"
 
 	"gitlab.com/postgres-ai/joe/pkg/bot/querier"
+	"gitlab.com/postgres-ai/joe/pkg/config"
 	"gitlab.com/postgres-ai/joe/pkg/connection"
 	"gitlab.com/postgres-ai/joe/pkg/models"
 	"gitlab.com/postgres-ai/joe/pkg/pgexplain"
@@ -28,8 +30,16 @@ const (
 	// MsgExplainOptionReq describes an explain error.
 	MsgExplainOptionReq = "Use `explain` to see the query's plan, e.g. `explain select 1`"
 
+	// msgPlanFlip is shown when the same query got a plan of another shape earlier in the session.
+	msgPlanFlip = ":warning: *Plan flip:* this query had a different plan earlier in this session " +
+		"(fingerprint `%s` → `%s`), e.g. because data, statistics or settings changed"
+
+	// msgNoRecommendations is shown when the plan analyzer finds nothing to report.
+	msgNoRecommendations = ":white_check_mark: Looks good"
+
 	// Query Explain prefixes. The ANALYZE form and its version-gated options live in
-	// pkg/pgexplain (pgexplain.ExplainAnalyzeQuery/ExplainSettingsOption/ExplainWALOption)
+	// pkg/pgexplain (pgexplain.ExplainAnalyzeQuery/ExplainSettingsOption/ExplainWALOption/
+	// ExplainSerializeOption/ExplainMemoryOption)
 	// alongside the parser for the JSON they produce; analyzePrefix below applies the
 	// per-version policy.
 	queryExplain = "EXPLAIN (FORMAT TEXT) "
@@ -37,6 +47,8 @@ const (
 	postgresNumDiv = 10000 // Divider to get version from server_version_num.
 	pgVersion12    = 12    // Explain Settings are available starting with Postgres 12.
 	pgVersion13    = 13    // Explain WAL are available starting with Postgres 13.
+	pgVersion16    = 16    // Explain Generic Plan is available starting with Postgres 16.
+	pgVersion17    = 17    // Explain Serialize and Memory are available starting with Postgres 17.
 
 	// locksTitle shows locks for a single query analyzed with EXPLAIN.
 	// locksTitle = "*Query heavy locks:*\n".
@@ -44,53 +56,43 @@ const (
 
 // Explain runs an explain query.
 func Explain(ctx context.Context, msgSvc connection.Messenger, command *platform.Command, msg *models.Message,
-	session usermanager.UserSession) error {
+	session usermanager.UserSession, artifacts config.PlanArtifacts, timeout StatementTimeout) error {
 	if command.Query == "" {
 		return errors.New(MsgExplainOptionReq)
 	}
 
-	serviceConn, err := getConn(ctx, session.Pool)
+	tx, txPID, rollback, err := explainScope(ctx, session)
 	if err != nil {
-		log.Err("failed to get connection:", err)
 		return err
 	}
 
-	defer func() {
-		if err := serviceConn.Conn().Close(ctx); err != nil {
-			log.Err("failed to close connection: ", err)
-		}
-
-		serviceConn.Release()
-	}()
+	defer rollback()
 
-	tx, err := serviceConn.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
-		log.Err("failed to begin transaction:", err)
+	if err := timeout.setLocal(ctx, tx); err != nil {
 		return err
 	}
 
-	defer func() {
-		if err := tx.Rollback(ctx); err != nil {
-			log.Err("failed to rollback transaction:", err)
-		}
-	}()
-
-	txPID, err := querier.GetBackendPID(ctx, tx)
-	if err != nil {
-		log.Err("failed to get backend PID:", err)
+	// Let `stop` cancel the planning, which runs on the clone connection.
+	if err := session.Running.SetBackendPID(msg, int(session.CloneConnection.PgConn().PID())); err != nil {
 		return err
 	}
 
-	cmd := NewPlan(command, msg, session.CloneConnection, msgSvc)
+	cmd := NewPlan(command, msg, session.CloneConnection, session.DBVersion, msgSvc)
+	cmd.timeout = timeout
 
 	msgInitText, err := cmd.explainWithoutExecution(ctx)
 	if err != nil {
-		return errors.Wrap(err, "failed to run explain without execution")
+		return errors.Wrap(timeout.wrapError(err), "failed to run explain without execution")
+	}
+
+	// Then the query, which runs in the transaction.
+	if err := session.Running.SetBackendPID(msg, txPID); err != nil {
+		return err
 	}
 
 	explainAnalyze, err := querier.DBQueryWithResponse(ctx, tx, analyzePrefix(session.DBVersion)+command.Query)
 	if err != nil {
-		return err
+		return timeout.wrapError(err)
 	}
 
 	// Observe query locks.
@@ -114,8 +116,16 @@ func Explain(ctx context.Context, msgSvc connection.Messenger, command *platform
 
 	planExecPreview, isTruncated := text.CutText(planText, PlanSize, SeparatorPlan)
 
+	fingerprint := explain.Fingerprint()
+	command.PlanFingerprint = fingerprint
+
 	msg.SetText(msgInitText)
 	msg.AppendText(fmt.Sprintf("*Plan with execution:*\n```%s```", planExecPreview))
+	msg.AppendText(fmt.Sprintf("*Plan fingerprint:* `%s`", fingerprint))
+
+	if notice := planFlipNotice(session.ExplainHistory, command.Query, fingerprint); notice != "" {
+		msg.AppendText(notice)
+	}
 
 	// Show query locks.
 	tableString := &strings.Builder{}
@@ -145,6 +155,23 @@ func Explain(ctx context.Context, msgSvc connection.Messenger, command *platform
 		return err
 	}
 
+	if _, err := msgSvc.AddArtifact("plan-flamegraph-html", explain.RenderFlameGraphHTML(), msg.ChannelID, msg.MessageID); err != nil {
+		log.Err("File upload failed:", err)
+		return err
+	}
+
+	if _, err := msgSvc.AddArtifact("plan-mermaid", explain.RenderMermaid(), msg.ChannelID, msg.MessageID); err != nil {
+		log.Err("File upload failed:", err)
+		return err
+	}
+
+	if artifacts.Anonymize {
+		if err := addAnonymizedArtifacts(msgSvc, msg, explainAnalyze); err != nil {
+			log.Err("File upload failed:", err)
+			return err
+		}
+	}
+
 	detailsText := ""
 	if isTruncated {
 		detailsText = " " + CutText
@@ -168,9 +195,124 @@ func Explain(ctx context.Context, msgSvc connection.Messenger, command *platform
 		return err
 	}
 
+	// Recommendations.
+	recommendations := explain.RenderTips()
+	command.Recommendations = recommendations
+
+	if recommendations == "" {
+		recommendations = msgNoRecommendations
+	}
+
+	msg.AppendText(fmt.Sprintf("*Recommendations:*\n%s", recommendations))
+	if err = msgSvc.UpdateText(msg); err != nil {
+		log.Err("Show recommendations: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// addAnonymizedArtifacts attaches the anonymized text plan, fit for sharing
+// outside the team, and the mapping to restore its names.
+func addAnonymizedArtifacts(msgSvc connection.Messenger, msg *models.Message, explainAnalyze string) error {
+	// Parse a copy, the plan shown in the message keeps its names.
+	explain, err := pgexplain.NewExplain(explainAnalyze)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse plan to anonymize")
+	}
+
+	mapping, err := json.MarshalIndent(explain.Anonymize(), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode anonymization mapping")
+	}
+
+	if _, err := msgSvc.AddArtifact("plan-anonymized-text", explain.RenderPlanText(), msg.ChannelID, msg.MessageID); err != nil {
+		return err
+	}
+
+	if _, err := msgSvc.AddArtifact("plan-anonymization-mapping-json", string(mapping), msg.ChannelID, msg.MessageID); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// planFlipNotice warns when the latest earlier explain of the same query in the
+// session had a plan of another shape; it is empty otherwise.
+func planFlipNotice(history []usermanager.ExplainResult, query, fingerprint string) string {
+	query = strings.Join(strings.Fields(query), " ")
+
+	for index := len(history) - 1; index >= 0; index-- {
+		previous := history[index]
+
+		if strings.Join(strings.Fields(previous.Query), " ") != query {
+			continue
+		}
+
+		if previous.Fingerprint == "" || previous.Fingerprint == fingerprint {
+			return ""
+		}
+
+		return fmt.Sprintf(msgPlanFlip, previous.Fingerprint, fingerprint)
+	}
+
+	return ""
+}
+
+// explainScope returns the connection to run EXPLAIN ANALYZE on, the PID of its backend and the function
+// rolling the query back. The query runs inside the transaction the user has opened with `begin`, rolled
+// back to a savepoint afterwards; otherwise, in a transaction on a connection of the pool.
+func explainScope(ctx context.Context, session usermanager.UserSession) (sessionQuerier, int, func(), error) {
+	if session.TxStatus() != usermanager.TxStatusIdle {
+		rollback, err := rolledBackScope(ctx, session.CloneConnection)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		return session.CloneConnection, int(session.CloneConnection.PgConn().PID()), rollback, nil
+	}
+
+	serviceConn, err := getConn(ctx, session.Pool)
+	if err != nil {
+		log.Err("failed to get connection:", err)
+		return nil, 0, nil, err
+	}
+
+	releaseConn := func() {
+		if err := serviceConn.Conn().Close(ctx); err != nil {
+			log.Err("failed to close connection: ", err)
+		}
+
+		serviceConn.Release()
+	}
+
+	tx, err := serviceConn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		log.Err("failed to begin transaction:", err)
+		releaseConn()
+
+		return nil, 0, nil, err
+	}
+
+	rollback := func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Err("failed to rollback transaction:", err)
+		}
+
+		releaseConn()
+	}
+
+	txPID, err := querier.GetBackendPID(ctx, tx)
+	if err != nil {
+		log.Err("failed to get backend PID:", err)
+		rollback()
+
+		return nil, 0, nil, err
+	}
+
+	return tx, txPID, rollback, nil
+}
+
 func analyzePrefix(dbVersionNum int) string {
 	settingsValue := ""
 
@@ -182,6 +324,10 @@ func analyzePrefix(dbVersionNum int) string {
 		settingsValue += pgexplain.ExplainWALOption
 	}
 
+	if (dbVersionNum / postgresNumDiv) >= pgVersion17 {
+		settingsValue += pgexplain.ExplainSerializeOption + pgexplain.ExplainMemoryOption
+	}
+
 	return fmt.Sprintf(pgexplain.ExplainAnalyzeQuery, settingsValue)
 }
 
@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
 )
 
 func TestAnalyzePrefix(t *testing.T) {
@@ -33,15 +35,15 @@ func TestAnalyzePrefix(t *testing.T) {
 		},
 		{
 			input:          170000,
-			expectedOutput: "EXPLAIN (ANALYZE, COSTS, VERBOSE, BUFFERS, FORMAT JSON , SETTINGS TRUE, WAL) ",
+			expectedOutput: "EXPLAIN (ANALYZE, COSTS, VERBOSE, BUFFERS, FORMAT JSON , SETTINGS TRUE, WAL, SERIALIZE, MEMORY) ",
 		},
 		{
 			input:          180000,
-			expectedOutput: "EXPLAIN (ANALYZE, COSTS, VERBOSE, BUFFERS, FORMAT JSON , SETTINGS TRUE, WAL) ",
+			expectedOutput: "EXPLAIN (ANALYZE, COSTS, VERBOSE, BUFFERS, FORMAT JSON , SETTINGS TRUE, WAL, SERIALIZE, MEMORY) ",
 		},
 		{
 			input:          190000,
-			expectedOutput: "EXPLAIN (ANALYZE, COSTS, VERBOSE, BUFFERS, FORMAT JSON , SETTINGS TRUE, WAL) ",
+			expectedOutput: "EXPLAIN (ANALYZE, COSTS, VERBOSE, BUFFERS, FORMAT JSON , SETTINGS TRUE, WAL, SERIALIZE, MEMORY) ",
 		},
 	}
 
@@ -50,3 +52,21 @@ func TestAnalyzePrefix(t *testing.T) {
 		assert.Equal(t, tc.expectedOutput, output)
 	}
 }
+
+func TestPlanFlipNotice(t *testing.T) {
+	history := []usermanager.ExplainResult{
+		{Query: "select * from orders where id = 1", Fingerprint: "aaaaaaaaaaaaaaaa"},
+		{Query: "select 1", Fingerprint: "cccccccccccccccc"},
+	}
+
+	assert.Empty(t, planFlipNotice(nil, "select 1", "cccccccccccccccc"))
+	assert.Empty(t, planFlipNotice(history, "select 1", "cccccccccccccccc"))
+	assert.Empty(t, planFlipNotice(history, "select 2", "dddddddddddddddd"))
+	assert.Equal(t, ":warning: *Plan flip:* this query had a different plan earlier in this session "+
+		"(fingerprint `aaaaaaaaaaaaaaaa` → `bbbbbbbbbbbbbbbb`), e.g. because data, statistics or settings changed",
+		planFlipNotice(history, "select *\n  from orders where id = 1", "bbbbbbbbbbbbbbbb"))
+
+	// Only the latest run of the query counts.
+	history = append(history, usermanager.ExplainResult{Query: "select * from orders where id = 1", Fingerprint: "bbbbbbbbbbbbbbbb"})
+	assert.Empty(t, planFlipNotice(history, "select * from orders where id = 1", "bbbbbbbbbbbbbbbb"))
+}
@@ -24,6 +24,7 @@ const (
 	hypoDesc   = "desc"
 	hypoDrop   = "drop"
 	hypoReset  = "reset"
+	hypoAdvise = "advise"
 )
 
 // HypoPGCaption contains caption for rendered tables.
@@ -119,6 +120,9 @@ func (h *HypoCmd) Execute() error {
 
 	case hypoReset:
 		return h.reset(ctx)
+
+	case hypoAdvise:
+		return h.advise(ctx, commandTail)
 	}
 
 	return errors.New("invalid args given for the `hypo` command")
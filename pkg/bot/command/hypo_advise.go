@@ -0,0 +1,263 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/dustin/go-humanize/english"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/log"
+
+	"gitlab.com/postgres-ai/joe/pkg/bot/querier"
+	"gitlab.com/postgres-ai/joe/pkg/pgexplain"
+)
+
+// MsgHypoAdviseReq describes a hypo advise without a query error.
+const MsgHypoAdviseReq = "Use `hypo advise` with a query to get index suggestions, e.g. `hypo advise select * from orders where status = 'new'`"
+
+// queryAdviseExplain plans a query for the index advice; VERBOSE qualifies the columns and the relations.
+const queryAdviseExplain = "EXPLAIN (VERBOSE, FORMAT JSON) "
+
+const (
+	// hypoAdviseMaxCandidates caps the number of hypothetical indexes tried: each costs a planning of the query.
+	hypoAdviseMaxCandidates = 20
+
+	// hypoAdviseTop is the number of the best indexes reported.
+	hypoAdviseTop = 3
+
+	// hypoAdviseMinImprovement is the share of the planned cost an index must cut to be reported.
+	hypoAdviseMinImprovement = 0.1
+)
+
+// indexAdvice is the outcome of planning a query with a hypothetical index.
+type indexAdvice struct {
+	candidate pgexplain.IndexCandidate
+	cost      float64
+	size      int64
+}
+
+// advise tries the index candidates of the plan of the query as hypothetical indexes
+// and reports the ones that cut the planned cost the most.
+func (h *HypoCmd) advise(ctx context.Context, query string) error {
+	if query == "" {
+		return errors.New(MsgHypoAdviseReq)
+	}
+
+	// Hypothetical indexes exist only in the backend that has created them.
+	conn, err := getConn(ctx, h.pool)
+	if err != nil {
+		return errors.Wrap(err, "failed to acquire connection")
+	}
+
+	defer conn.Release()
+
+	baseline, tried, advice, err := tryIndexCandidates(ctx, conn, query)
+	if err != nil {
+		return err
+	}
+
+	h.message.AppendText(renderIndexAdvice(baseline.Plan.TotalCost, tried, rankIndexAdvice(baseline.Plan.TotalCost, advice)))
+
+	if err := h.messenger.UpdateText(h.message); err != nil {
+		return errors.Wrap(err, "failed to publish message")
+	}
+
+	return nil
+}
+
+// tryIndexCandidates plans the query, then tries the index candidates of the plan as hypothetical indexes.
+// It returns the plan, the number of the candidates tried and the outcomes of the ones the planner uses.
+func tryIndexCandidates(ctx context.Context, conn *pgxpool.Conn, query string) (*pgexplain.Explain, int, []indexAdvice, error) {
+	baseline, err := planQuery(ctx, conn, query)
+	if err != nil {
+		return nil, 0, nil, errors.Wrap(err, "failed to plan the query")
+	}
+
+	candidates := baseline.IndexCandidates()
+	candidates = candidates[:min(len(candidates), hypoAdviseMaxCandidates)]
+
+	advice := make([]indexAdvice, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		result, used, err := tryHypoIndex(ctx, conn, query, candidate)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		if used {
+			advice = append(advice, result)
+		}
+	}
+
+	return baseline, len(candidates), advice, nil
+}
+
+// planQuery plans the query without executing it.
+func planQuery(ctx context.Context, conn *pgxpool.Conn, query string) (*pgexplain.Explain, error) {
+	result, err := querier.DBQueryWithResponse(ctx, conn, queryAdviseExplain+query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgexplain.NewExplain(result)
+}
+
+// tryHypoIndex plans the query with the candidate as a hypothetical index and tells whether the plan uses it.
+// A candidate HypoPG cannot create, e.g. on a column without a B-tree operator class, is skipped; other failures,
+// e.g. of a missing HypoPG extension, are returned.
+func tryHypoIndex(ctx context.Context, conn *pgxpool.Conn, query string, candidate pgexplain.IndexCandidate) (indexAdvice, bool, error) {
+	var (
+		indexID   uint32
+		indexName string
+	)
+
+	if err := conn.QueryRow(ctx, "select indexrelid, indexname from hypopg_create_index($1)",
+		candidate.Definition(false)).Scan(&indexID, &indexName); err != nil {
+		if !isCandidateError(err) {
+			return indexAdvice{}, false, errors.Wrap(err, "failed to create a hypothetical index")
+		}
+
+		log.Dbg(fmt.Sprintf("failed to create hypothetical index %q: %v", candidate.Definition(false), err))
+
+		return indexAdvice{}, false, nil
+	}
+
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "select hypopg_drop_index($1)", indexID); err != nil {
+			log.Err("failed to drop hypothetical index:", err)
+		}
+	}()
+
+	explain, err := planQuery(ctx, conn, query)
+	if err != nil {
+		return indexAdvice{}, false, errors.Wrapf(err, "failed to plan the query with %s", candidate.Definition(false))
+	}
+
+	if !slices.Contains(explain.IndexNames(), indexName) {
+		return indexAdvice{}, false, nil
+	}
+
+	var size int64
+
+	if err := conn.QueryRow(ctx, "select hypopg_relation_size($1)", indexID).Scan(&size); err != nil {
+		return indexAdvice{}, false, errors.Wrap(err, "failed to get the size of the hypothetical index")
+	}
+
+	return indexAdvice{candidate: candidate, cost: explain.Plan.TotalCost, size: size}, true, nil
+}
+
+// isCandidateError tells whether creating a hypothetical index failed because of the candidate itself,
+// e.g. a column type without an operator class, rather than because of HypoPG or the connection.
+func isCandidateError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	switch pgErr.Code {
+	case querier.UndefinedFunctionPQErrorCode, querier.InsufficientPrivilegePQErrorCode:
+		return false
+
+	case querier.FeatureNotSupportedPQErrorCode:
+		return true
+	}
+
+	// Class 42 covers the syntax errors and the access rule violations of the index definition.
+	return strings.HasPrefix(pgErr.Code, "42")
+}
+
+// rankIndexAdvice returns the indexes that cut the cost by hypoAdviseMinImprovement or more,
+// the cheapest plans first, preferring narrower and smaller indexes on a tie. An index is left
+// out when an index on its leading columns is already reported with a cost as low.
+func rankIndexAdvice(baselineCost float64, advice []indexAdvice) []indexAdvice {
+	if baselineCost <= 0 {
+		return nil
+	}
+
+	var ranked []indexAdvice
+
+	for _, result := range advice {
+		if result.cost <= baselineCost*(1-hypoAdviseMinImprovement) {
+			ranked = append(ranked, result)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].cost != ranked[j].cost {
+			return ranked[i].cost < ranked[j].cost
+		}
+
+		if len(ranked[i].candidate.Columns) != len(ranked[j].candidate.Columns) {
+			return len(ranked[i].candidate.Columns) < len(ranked[j].candidate.Columns)
+		}
+
+		return ranked[i].size < ranked[j].size
+	})
+
+	best := make([]indexAdvice, 0, hypoAdviseTop)
+
+	for _, result := range ranked {
+		if len(best) == hypoAdviseTop {
+			break
+		}
+
+		if !slices.ContainsFunc(best, func(picked indexAdvice) bool { return coversIndex(picked, result) }) {
+			best = append(best, result)
+		}
+	}
+
+	return best
+}
+
+// coversIndex tells whether the picked index is on the leading columns of the other one
+// and makes the plan as cheap, so the wider index is not worth it.
+func coversIndex(picked, other indexAdvice) bool {
+	return picked.candidate.Name() == other.candidate.Name() &&
+		len(picked.candidate.Columns) <= len(other.candidate.Columns) &&
+		slices.Equal(picked.candidate.Columns, other.candidate.Columns[:len(picked.candidate.Columns)]) &&
+		picked.cost <= other.cost
+}
+
+// renderIndexAdvice renders the best indexes with the statements to create them.
+func renderIndexAdvice(baselineCost float64, tried int, advice []indexAdvice) string {
+	sb := &strings.Builder{}
+
+	fmt.Fprintf(sb, "*HypoPG index advice:* the planned cost of the query is %.2f without new indexes, %s tried.\n",
+		baselineCost, english.Plural(tried, "index", "indexes"))
+
+	if len(advice) == 0 {
+		fmt.Fprintf(sb, "No index cuts the planned cost by %.0f%% or more.", hypoAdviseMinImprovement*100)
+		return sb.String()
+	}
+
+	table := [][]string{{"Index", "Cost", "Improvement", "Size", "Found in"}}
+	statements := make([]string, 0, len(advice))
+
+	for _, result := range advice {
+		table = append(table, []string{
+			fmt.Sprintf("%s (%s)", result.candidate.Name(), strings.Join(result.candidate.Columns, ", ")),
+			fmt.Sprintf("%.2f", result.cost),
+			fmt.Sprintf("%.1f%%", 100*(baselineCost-result.cost)/baselineCost),
+			humanize.IBytes(uint64(max(result.size, 0))),
+			strings.Join(result.candidate.Sources, ", "),
+		})
+
+		statements = append(statements, result.candidate.Definition(true)+";")
+	}
+
+	querier.RenderTable(sb, table)
+	fmt.Fprintf(sb, "\n*Statements:*\n```%s```", strings.Join(statements, "\n"))
+
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/postgres-ai/joe/pkg/pgexplain"
+)
+
+func TestRankIndexAdvice(t *testing.T) {
+	orders := func(columns ...string) pgexplain.IndexCandidate {
+		return pgexplain.IndexCandidate{Schema: "shop", Relation: "orders", Columns: columns, Sources: []string{"Filter"}}
+	}
+
+	advice := []indexAdvice{
+		{candidate: orders("region"), cost: 950, size: 8192},
+		{candidate: orders("status"), cost: 400, size: 8192},
+		{candidate: orders("status", "region"), cost: 400, size: 16384},
+		{candidate: orders("created_at"), cost: 600, size: 8192},
+		{candidate: orders("status", "created_at"), cost: 100, size: 16384},
+		{candidate: orders("customer_id"), cost: 500, size: 8192},
+	}
+
+	var ranked [][]string
+	for _, result := range rankIndexAdvice(1000, advice) {
+		ranked = append(ranked, result.candidate.Columns)
+	}
+
+	// The index on region cuts less than 10%, and the one on (status, region) is no better than the one on status.
+	assert.Equal(t, [][]string{{"status", "created_at"}, {"status"}, {"customer_id"}}, ranked)
+
+	assert.Empty(t, rankIndexAdvice(0, advice))
+}
+
+func TestRenderIndexAdvice(t *testing.T) {
+	candidate := pgexplain.IndexCandidate{
+		Schema: "shop", Relation: "orders", Columns: []string{"status", "created_at"}, Sources: []string{"Filter", "Sort Key"},
+	}
+
+	text := renderIndexAdvice(1000, 4, []indexAdvice{{candidate: candidate, cost: 100, size: 2 << 20}})
+
+	assert.Contains(t, text, "*HypoPG index advice:* the planned cost of the query is 1000.00 without new indexes, 4 indexes tried.\n")
+	assert.Contains(t, text, "shop.orders (status, created_at)")
+	assert.Contains(t, text, "90.0%")
+	assert.Contains(t, text, "2.0 MiB")
+	assert.Contains(t, text, "Filter, Sort Key")
+	assert.Contains(t, text, "*Statements:*\n```CREATE INDEX CONCURRENTLY ON shop.orders (status, created_at);```")
+
+	assert.Equal(t, "*HypoPG index advice:* the planned cost of the query is 1000.00 without new indexes, 1 index tried.\n"+
+		"No index cuts the planned cost by 10% or more.", renderIndexAdvice(1000, 1, nil))
+}
+
+func TestIsCandidateError(t *testing.T) {
+	// E.g. a column type without a B-tree operator class.
+	assert.True(t, isCandidateError(&pgconn.PgError{Code: "42704"}))
+	assert.True(t, isCandidateError(errors.Wrap(&pgconn.PgError{Code: "0A000"}, "failed")))
+
+	// A missing HypoPG extension, missing privileges or a broken connection fail the advice.
+	assert.False(t, isCandidateError(&pgconn.PgError{Code: "42883"}))
+	assert.False(t, isCandidateError(&pgconn.PgError{Code: "42501"}))
+	assert.False(t, isCandidateError(&pgconn.PgError{Code: "57014"}))
+	assert.False(t, isCandidateError(errors.New("conn closed")))
+}
@@ -9,6 +9,7 @@ package command
 import (
 	"context"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 
@@ -168,6 +169,87 @@ func TestDescribeHypoIndexes_One_Integration(t *testing.T) {
 	require.Empty(t, none, "a non-matching indexrelid must yield no rows")
 }
 
+// TestTryHypoIndex_Integration covers `hypo advise`: an index on the filter column
+// is used by the plan, cuts its cost and has a size; the candidate is dropped after.
+func TestTryHypoIndex_Integration(t *testing.T) {
+	ctx, conn := hypoTestConn(t)
+
+	_, err := conn.Exec(ctx, `create table if not exists joe_hypo_advise as
+		select i as id, 'value ' || i as val from generate_series(1, 100000) as i`)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _, _ = conn.Exec(ctx, "drop table if exists joe_hypo_advise") })
+
+	_, err = conn.Exec(ctx, "analyze joe_hypo_advise")
+	require.NoError(t, err)
+
+	const query = "select * from joe_hypo_advise where val = 'value 42'"
+
+	baseline, err := planQuery(ctx, conn, query)
+	require.NoError(t, err)
+
+	candidates := baseline.IndexCandidates()
+	require.NotEmpty(t, candidates)
+	require.Equal(t, []string{"val"}, candidates[0].Columns)
+
+	advice, used, err := tryHypoIndex(ctx, conn, query, candidates[0])
+	require.NoError(t, err)
+	require.True(t, used)
+	require.Less(t, advice.cost, baseline.Plan.TotalCost)
+	require.Positive(t, advice.size)
+
+	indexes, err := describeHypoIndexes(ctx, conn, "")
+	require.NoError(t, err)
+	require.NotContains(t, flattenRows(indexes), "joe_hypo_advise", "the candidate must be dropped")
+}
+
+// TestAdviseIndexes_Integration covers `hypo advise` with two predicates: the composite candidate
+// wins, and a wider index is reported only when it beats the index on its leading column.
+func TestAdviseIndexes_Integration(t *testing.T) {
+	ctx, conn := hypoTestConn(t)
+
+	_, err := conn.Exec(ctx, `create table if not exists joe_hypo_advise2 as
+		select i as id, i % 100 as a, (i / 100) % 1000 as b, 'value ' || i as val from generate_series(1, 100000) as i`)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _, _ = conn.Exec(ctx, "drop table if exists joe_hypo_advise2") })
+
+	_, err = conn.Exec(ctx, "analyze joe_hypo_advise2")
+	require.NoError(t, err)
+
+	// Each predicate alone matches hundreds of rows, both together one.
+	baseline, tried, advice, err := tryIndexCandidates(ctx, conn, "select * from joe_hypo_advise2 where a = 5 and b = 7")
+	require.NoError(t, err)
+	require.Equal(t, 3, tried, "want the candidates (a), (b) and (a, b)")
+
+	best := rankIndexAdvice(baseline.Plan.TotalCost, advice)
+	require.NotEmpty(t, best)
+	require.Equal(t, []string{"a", "b"}, best[0].candidate.Columns)
+	require.Contains(t, renderIndexAdvice(baseline.Plan.TotalCost, tried, best),
+		"```CREATE INDEX CONCURRENTLY ON joe_hypo_advise2 (a, b);")
+
+	// The unique id alone finds the row: (id, val) must not be reported unless it plans cheaper than (id).
+	baseline, _, advice, err = tryIndexCandidates(ctx, conn, "select * from joe_hypo_advise2 where id = 42 and val = 'value 42'")
+	require.NoError(t, err)
+
+	costs := make(map[string]float64, len(advice))
+	for _, result := range advice {
+		costs[strings.Join(result.candidate.Columns, ",")] = result.cost
+	}
+
+	require.Contains(t, costs, "id")
+	require.Contains(t, costs, "id,val")
+
+	best = rankIndexAdvice(baseline.Plan.TotalCost, advice)
+	require.NotEmpty(t, best)
+
+	for _, result := range best {
+		if slices.Equal(result.candidate.Columns, []string{"id", "val"}) {
+			require.Less(t, result.cost, costs["id"], "(id, val) is covered by (id)")
+		}
+	}
+}
+
 func flattenRows(res [][]string) string {
 	var b strings.Builder
 
@@ -0,0 +1,649 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize/english"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/log"
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/util"
+
+	"gitlab.com/postgres-ai/joe/pkg/bot/querier"
+	"gitlab.com/postgres-ai/joe/pkg/connection"
+	"gitlab.com/postgres-ai/joe/pkg/models"
+	"gitlab.com/postgres-ai/joe/pkg/services/platform"
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
+)
+
+// msgMigrationOptionReq describes a migration error.
+const msgMigrationOptionReq = "Use `migration` with a DDL script, e.g. `migration alter table orders add column note text; " +
+	"create index concurrently on orders (note);`"
+
+const (
+	// migrationSampleInterval is how often the locks of a running statement are sampled.
+	migrationSampleInterval = 20 * time.Millisecond
+
+	// migrationLongLock is how long an AccessExclusiveLock may be held before it is flagged.
+	migrationLongLock = time.Second
+
+	// migrationStatementPreview caps the statements shown in the report.
+	migrationStatementPreview = 80
+)
+
+// lockModes are the table lock modes from the weakest to the strongest.
+var lockModes = []string{
+	"AccessShareLock",
+	"RowShareLock",
+	"RowExclusiveLock",
+	"ShareUpdateExclusiveLock",
+	"ShareLock",
+	"ShareRowExclusiveLock",
+	"ExclusiveLock",
+	"AccessExclusiveLock",
+}
+
+// nonTransactional matches the statements that cannot run in a transaction block, normalized by statementKeywords.
+var nonTransactional = regexp.MustCompile(`^(create (unique )?index concurrently|drop index concurrently|` +
+	`reindex (\([^)]*\) )?(index|table|schema|database|system) concurrently|alter table .* detach partition .* concurrently|` +
+	`vacuum|alter system|create database|drop database|create tablespace|drop tablespace)\b`)
+
+// relationFilesQuery lists the files of the tables; a new file of a table means it has been rewritten.
+const relationFilesQuery = `select c.oid::int8, c.oid::regclass::text, c.relfilenode::int8
+from pg_catalog.pg_class as c
+where c.relkind in ('r', 'm')
+  and c.relnamespace not in ('pg_catalog'::regnamespace, 'information_schema'::regnamespace)`
+
+// migrationRisk is a risky pattern of a DDL statement.
+type migrationRisk struct {
+	match func(statement string) bool
+	text  string
+}
+
+var (
+	alterColumnType   = regexp.MustCompile(`^alter table .*\balter (column )?("[^"]+"|\S+) (set data )?type\b`)
+	setNotNull        = regexp.MustCompile(`^alter table .*\balter (column )?("[^"]+"|\S+) set not null\b`)
+	addConstraint     = regexp.MustCompile(`^alter table .*\badd (constraint ("[^"]+"|\S+) )?(foreign key|check)\b`)
+	createIndexPrefix = regexp.MustCompile(`^create (unique )?index\b`)
+	concurrentIndex   = regexp.MustCompile(`^create (unique )?index concurrently\b`)
+)
+
+// migrationRisks are the risky patterns of DDL statements, normalized by statementKeywords.
+var migrationRisks = []migrationRisk{
+	{
+		match: func(statement string) bool {
+			return createIndexPrefix.MatchString(statement) && !concurrentIndex.MatchString(statement)
+		},
+		text: "The index is built without CONCURRENTLY: writes to the table wait until it is done. Use CREATE INDEX CONCURRENTLY.",
+	},
+	{
+		match: alterColumnType.MatchString,
+		text: "ALTER COLUMN TYPE may rewrite the table and its indexes under an AccessExclusiveLock, " +
+			"which blocks reads and writes.",
+	},
+	{
+		match: setNotNull.MatchString,
+		text: "SET NOT NULL scans the table under an AccessExclusiveLock. " +
+			"Add a CHECK (column IS NOT NULL) NOT VALID constraint and validate it first.",
+	},
+	{
+		match: func(statement string) bool {
+			return addConstraint.MatchString(statement) && !strings.Contains(statement, " not valid")
+		},
+		text: "The constraint is validated against all rows while the lock is held. Add it NOT VALID, then VALIDATE CONSTRAINT.",
+	},
+}
+
+// relationLock is the strongest lock a statement has taken on a relation and how long it has been held
+// since first sampled.
+type relationLock struct {
+	Relation string
+	Mode     string
+	Held     time.Duration
+}
+
+// migrationStep is the outcome of a statement of a migration.
+type migrationStep struct {
+	Statement string
+	Duration  time.Duration
+	Locks     []relationLock // The strongest first.
+	Rewritten []string
+	Risks     []string
+}
+
+// MigrationCmd defines the migration command that runs a DDL script statement by statement
+// and reports the locks, the durations and the table rewrites of the statements.
+type MigrationCmd struct {
+	command   *platform.Command
+	message   *models.Message
+	pool      *pgxpool.Pool
+	userConn  *pgx.Conn
+	running   *usermanager.RunningCommands
+	messenger connection.Messenger
+	session   *usermanager.UserSession
+	timeout   StatementTimeout
+}
+
+// NewMigrationCmd returns a new migration command.
+func NewMigrationCmd(command *platform.Command, msg *models.Message, session *usermanager.UserSession,
+	messengerSvc connection.Messenger, timeout StatementTimeout) *MigrationCmd {
+	return &MigrationCmd{
+		command:   command,
+		message:   msg,
+		pool:      session.Pool,
+		userConn:  session.CloneConnection,
+		running:   session.Running,
+		messenger: messengerSvc,
+		session:   session,
+		timeout:   timeout,
+	}
+}
+
+// Execute runs the statements of the script on the clone one by one, stopping at the first failure.
+func (cmd *MigrationCmd) Execute(ctx context.Context) error {
+	statements := splitStatements(cmd.command.Query)
+	if len(statements) == 0 {
+		return errors.New(msgMigrationOptionReq)
+	}
+
+	pid := int(cmd.userConn.PgConn().PID())
+
+	// Let `stop` cancel the statements.
+	if err := cmd.running.SetBackendPID(cmd.message, pid); err != nil {
+		return err
+	}
+
+	restoreTimeout, err := cmd.timeout.set(ctx, cmd.session)
+	if err != nil {
+		return err
+	}
+
+	defer restoreTimeout()
+
+	inTransaction := cmd.userConn.PgConn().TxStatus() != usermanager.TxStatusIdle
+
+	if inTransaction {
+		for index, statement := range statements {
+			if nonTransactional.MatchString(statementKeywords(statement)) {
+				return errors.Errorf("statement %d (`%s`) cannot run inside a transaction block, "+
+					"`commit` or `rollback` the open transaction first", index+1, previewStatement(statement))
+			}
+		}
+	}
+
+	steps := make([]migrationStep, 0, len(statements))
+
+	var stepErr error
+
+	for index, statement := range statements {
+		step, err := cmd.runStatement(ctx, statement, pid, inTransaction)
+		if err != nil {
+			stepErr = errors.Wrapf(cmd.timeout.wrapError(err), "statement %d failed", index+1)
+			break
+		}
+
+		steps = append(steps, step)
+	}
+
+	result := renderMigration(steps, len(statements), cmd.userConn.PgConn().TxStatus())
+
+	cmd.command.Response = result
+	cmd.message.AppendText(result)
+
+	if err := cmd.messenger.UpdateText(cmd.message); err != nil {
+		log.Err("failed to update text while running the migration command:", err)
+		return err
+	}
+
+	return stepErr
+}
+
+// runStatement runs a statement in a transaction of its own, unless it cannot run in one or the user has
+// opened one, so that its locks are still held and seen once it is done.
+func (cmd *MigrationCmd) runStatement(ctx context.Context, statement string, pid int, inTransaction bool) (migrationStep, error) {
+	step := migrationStep{Statement: statement, Risks: statementRisks(statement)}
+	transactional := !inTransaction && !nonTransactional.MatchString(statementKeywords(statement))
+
+	if transactional {
+		if _, err := cmd.userConn.Exec(ctx, "begin"); err != nil {
+			return step, errors.Wrap(err, "failed to start a transaction")
+		}
+
+		defer func() {
+			if cmd.userConn.PgConn().TxStatus() == usermanager.TxStatusIdle {
+				return
+			}
+
+			if _, err := cmd.userConn.Exec(context.WithoutCancel(ctx), "rollback"); err != nil {
+				log.Err("failed to roll back the migration statement:", err)
+			}
+		}()
+	}
+
+	filesBefore, err := relationFiles(ctx, cmd.userConn)
+	if err != nil {
+		return step, err
+	}
+
+	sampler := startLockSampler(ctx, cmd.pool, pid)
+	start := time.Now()
+
+	_, err = cmd.userConn.Exec(ctx, statement)
+
+	step.Duration = time.Since(start)
+	sampler.stop(ctx)
+
+	if err != nil {
+		step.Locks = sampler.heldLocks(time.Now())
+		return step, err
+	}
+
+	filesAfter, err := relationFiles(ctx, cmd.userConn)
+	if err != nil {
+		return step, err
+	}
+
+	step.Rewritten = rewrittenRelations(filesBefore, filesAfter)
+
+	if transactional {
+		if _, err := cmd.userConn.Exec(ctx, "commit"); err != nil {
+			return step, errors.Wrap(err, "failed to commit the statement")
+		}
+	}
+
+	// The locks of a statement run in a transaction of its own are released by the commit; the ones
+	// in the transaction of the user are still held.
+	step.Locks = sampler.heldLocks(time.Now())
+
+	if lock, ok := longestExclusiveLock(step.Locks); ok && lock.Held >= migrationLongLock {
+		step.Risks = append(step.Risks, fmt.Sprintf("AccessExclusiveLock on `%s` held for about %s: all queries to it wait meanwhile.",
+			lock.Relation, util.DurationToString(lock.Held)))
+	}
+
+	return step, nil
+}
+
+// longestExclusiveLock returns the AccessExclusiveLock held the longest.
+func longestExclusiveLock(locks []relationLock) (relationLock, bool) {
+	var (
+		longest relationLock
+		found   bool
+	)
+
+	for _, lock := range locks {
+		if lock.Mode == "AccessExclusiveLock" && (!found || lock.Held > longest.Held) {
+			longest, found = lock, true
+		}
+	}
+
+	return longest, found
+}
+
+// relationFile is the file of a table.
+type relationFile struct {
+	name     string
+	filenode int64
+}
+
+// relationFiles returns the files of the tables by their OIDs.
+func relationFiles(ctx context.Context, conn *pgx.Conn) (map[int64]relationFile, error) {
+	rows, err := conn.Query(ctx, relationFilesQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list relation files")
+	}
+
+	files := make(map[int64]relationFile)
+
+	var (
+		oid  int64
+		file relationFile
+	)
+
+	if _, err := pgx.ForEachRow(rows, []any{&oid, &file.name, &file.filenode}, func() error {
+		files[oid] = file
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to list relation files")
+	}
+
+	return files, nil
+}
+
+// rewrittenRelations returns the tables that have got a new file, sorted by name.
+func rewrittenRelations(before, after map[int64]relationFile) []string {
+	var rewritten []string
+
+	for oid, file := range after {
+		if previous, ok := before[oid]; ok && previous.filenode != file.filenode {
+			rewritten = append(rewritten, file.name)
+		}
+	}
+
+	sort.Strings(rewritten)
+
+	return rewritten
+}
+
+// sampledLock is the strongest mode of the locks sampled on a relation, when it was first seen and when last.
+type sampledLock struct {
+	mode      string
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// lockSampler samples the locks of a backend while it runs a statement and keeps the strongest
+// mode per relation.
+type lockSampler struct {
+	db  querier.Querier
+	pid int
+
+	mu    sync.Mutex
+	locks map[string]sampledLock
+
+	// lastSample is the time of the sample taken once the statement is done.
+	lastSample time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startLockSampler(ctx context.Context, db querier.Querier, pid int) *lockSampler {
+	sampleCtx, cancel := context.WithCancel(ctx)
+
+	sampler := &lockSampler{db: db, pid: pid, locks: make(map[string]sampledLock), cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(sampler.done)
+
+		ticker := time.NewTicker(migrationSampleInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sampleCtx.Done():
+				return
+
+			case <-ticker.C:
+				sampler.sample(sampleCtx)
+			}
+		}
+	}()
+
+	return sampler
+}
+
+// sample adds the locks the backend holds or waits for.
+func (s *lockSampler) sample(ctx context.Context) {
+	sampledAt := time.Now()
+
+	locks, err := querier.ObserveLocks(ctx, s.db, s.pid)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Dbg("failed to sample locks:", err)
+		}
+
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mergeLocks(s.locks, locks, sampledAt)
+}
+
+// stop stops sampling once the statement is done. It samples once more: the locks of a statement run
+// in a transaction are held until its end.
+func (s *lockSampler) stop(ctx context.Context) {
+	s.cancel()
+	<-s.done
+
+	s.lastSample = time.Now()
+
+	locks, err := querier.ObserveLocks(context.WithoutCancel(ctx), s.db, s.pid)
+	if err != nil {
+		log.Dbg("failed to sample locks:", err)
+		return
+	}
+
+	mergeLocks(s.locks, locks, s.lastSample)
+}
+
+// heldLocks returns the strongest lock per relation, the strongest first, with the time it has been held:
+// until the time for the locks still held once the statement is done, until the last sample that saw them
+// for the others.
+func (s *lockSampler) heldLocks(until time.Time) []relationLock {
+	return heldLocks(s.locks, s.lastSample, until)
+}
+
+// heldLocks lists the sampled locks, the strongest first, the ones seen in the last sample held until the time.
+func heldLocks(sampled map[string]sampledLock, lastSample, until time.Time) []relationLock {
+	locks := make([]relationLock, 0, len(sampled))
+
+	for relation, lock := range sampled {
+		releasedAt := lock.lastSeen
+		if !lock.lastSeen.Before(lastSample) {
+			releasedAt = until
+		}
+
+		locks = append(locks, relationLock{Relation: relation, Mode: lock.mode, Held: releasedAt.Sub(lock.firstSeen)})
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		if strengthI, strengthJ := lockStrength(locks[i].Mode), lockStrength(locks[j].Mode); strengthI != strengthJ {
+			return strengthI > strengthJ
+		}
+
+		return locks[i].Relation < locks[j].Relation
+	})
+
+	return locks
+}
+
+// mergeLocks keeps the strongest mode per relation of the locks listed by querier.ObserveLocks at the time,
+// with the times it was first and last seen. The locks on the system catalogs are left out.
+func mergeLocks(sampled map[string]sampledLock, locks [][]string, sampledAt time.Time) {
+	if len(locks) == 0 {
+		return
+	}
+
+	namespaceColumn := slices.Index(locks[0], "relnamespace")
+	relationColumn := slices.Index(locks[0], "relname")
+	modeColumn := slices.Index(locks[0], "mode")
+
+	if namespaceColumn < 0 || relationColumn < 0 || modeColumn < 0 {
+		return
+	}
+
+	for _, lock := range locks[1:] {
+		namespace, relation, mode := lock[namespaceColumn], lock[relationColumn], lock[modeColumn]
+
+		if namespace == "pg_catalog" || namespace == "information_schema" || strings.HasPrefix(namespace, "pg_toast") {
+			continue
+		}
+
+		if namespace != "public" {
+			relation = namespace + "." + relation
+		}
+
+		current, ok := sampled[relation]
+
+		switch {
+		case !ok || lockStrength(mode) > lockStrength(current.mode):
+			sampled[relation] = sampledLock{mode: mode, firstSeen: sampledAt, lastSeen: sampledAt}
+
+		case mode == current.mode:
+			current.lastSeen = sampledAt
+			sampled[relation] = current
+		}
+	}
+}
+
+// lockStrength orders the lock modes, -1 for an unknown one.
+func lockStrength(mode string) int {
+	return slices.Index(lockModes, mode)
+}
+
+// statementRisks returns the risky patterns of the statement.
+func statementRisks(statement string) []string {
+	normalized := statementKeywords(statement)
+
+	var risks []string
+
+	for _, risk := range migrationRisks {
+		if risk.match(normalized) {
+			risks = append(risks, risk.text)
+		}
+	}
+
+	return risks
+}
+
+// renderMigration renders the report of the statements run out of the total, telling where their changes are
+// by the transaction status of the connection once they are done.
+func renderMigration(steps []migrationStep, total int, txStatus byte) string {
+	var duration time.Duration
+	for _, step := range steps {
+		duration += step.Duration
+	}
+
+	sb := &strings.Builder{}
+
+	fmt.Fprintf(sb, "*Migration check:* %d of %s run in %s.\n", len(steps), english.Plural(total, "statement", ""),
+		util.DurationToString(duration))
+
+	for index, step := range steps {
+		fmt.Fprintf(sb, "\n*%d.* `%s` — %s\n", index+1, previewStatement(step.Statement), util.DurationToString(step.Duration))
+
+		if len(step.Locks) == 0 {
+			sb.WriteString("• No relation locks observed.\n")
+		} else {
+			fmt.Fprintf(sb, "• Strongest lock: `%s` on `%s`\n", step.Locks[0].Mode, step.Locks[0].Relation)
+
+			relations := make([]string, 0, len(step.Locks))
+			for _, lock := range step.Locks {
+				relations = append(relations, fmt.Sprintf("`%s` (%s)", lock.Relation, lock.Mode))
+			}
+
+			fmt.Fprintf(sb, "• Relations: %s\n", strings.Join(relations, ", "))
+		}
+
+		if len(step.Rewritten) > 0 {
+			fmt.Fprintf(sb, "• :warning: Table rewritten: `%s`\n", strings.Join(step.Rewritten, "`, `"))
+		}
+
+		for _, risk := range step.Risks {
+			fmt.Fprintf(sb, "• :warning: %s\n", risk)
+		}
+	}
+
+	switch {
+	case txStatus == usermanager.TxStatusFailed:
+		sb.WriteString("\n" + usermanager.MsgTransactionFailed)
+
+	case txStatus == usermanager.TxStatusInTransaction:
+		sb.WriteString("\nThe changes are in the open transaction; `commit` or `rollback` it.")
+
+	case len(steps) > 0:
+		sb.WriteString("\nThe changes have been applied to the clone; use `reset` to revert them.")
+	}
+
+	return sb.String()
+}
+
+// statementKeywords normalizes a statement for matching: comments are left out, whitespace is collapsed
+// and the statement is in lower case.
+func statementKeywords(statement string) string {
+	sb := strings.Builder{}
+
+	for index := 0; index < len(statement); index++ {
+		start := index
+
+		switch {
+		case statement[index] == '\'' || statement[index] == '"':
+			index = skipQuoted(statement, index, statement[index])
+
+		case strings.HasPrefix(statement[index:], "--"):
+			index = skipUntil(statement, index, "\n")
+			sb.WriteByte(' ')
+
+			continue
+
+		case strings.HasPrefix(statement[index:], "/*"):
+			index = skipUntil(statement, index, "*/")
+			sb.WriteByte(' ')
+
+			continue
+
+		case statement[index] == '$':
+			index = skipDollarQuoted(statement, index)
+		}
+
+		sb.WriteString(statement[start:min(index+1, len(statement))])
+	}
+
+	return strings.Join(strings.Fields(strings.ToLower(sb.String())), " ")
+}
+
+// previewStatement shortens a statement to a line for the report.
+func previewStatement(statement string) string {
+	preview := []rune(strings.Join(strings.Fields(statement), " "))
+	if len(preview) <= migrationStatementPreview {
+		return string(preview)
+	}
+
+	return string(preview[:migrationStatementPreview-1]) + "…"
+}
+
+// splitStatements splits a script into statements at the semicolons outside of string literals,
+// quoted identifiers, dollar-quoted strings and comments.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		start      int
+	)
+
+	add := func(statement string) {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+
+	for index := 0; index < len(script); index++ {
+		switch {
+		case script[index] == '\'' || script[index] == '"':
+			index = skipQuoted(script, index, script[index])
+
+		case strings.HasPrefix(script[index:], "--"):
+			index = skipUntil(script, index, "\n")
+
+		case strings.HasPrefix(script[index:], "/*"):
+			index = skipUntil(script, index, "*/")
+
+		case script[index] == '$':
+			index = skipDollarQuoted(script, index)
+
+		case script[index] == ';':
+			add(script[start:index])
+			start = index + 1
+		}
+	}
+
+	if start < len(script) {
+		add(script[start:])
+	}
+
+	return statements
+}
@@ -0,0 +1,200 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
+)
+
+func TestSplitStatements(t *testing.T) {
+	testCases := []struct {
+		script     string
+		statements []string
+	}{
+		{script: "", statements: nil},
+		{script: " ;; ", statements: nil},
+		{script: "create table t (id int)", statements: []string{"create table t (id int)"}},
+		{
+			script:     "alter table t add column a text;\ncreate index concurrently on t (a);",
+			statements: []string{"alter table t add column a text", "create index concurrently on t (a)"},
+		},
+		{
+			script:     "comment on table t is 'a; b'; alter table \"x;y\" add column a int",
+			statements: []string{"comment on table t is 'a; b'", "alter table \"x;y\" add column a int"},
+		},
+		{
+			script:     "-- drop; this\ncreate table t (id int); /* ; */ select 1",
+			statements: []string{"-- drop; this\ncreate table t (id int)", "/* ; */ select 1"},
+		},
+		{
+			script: "create function f() returns int as $$ select 1; $$ language sql; drop function f",
+			statements: []string{
+				"create function f() returns int as $$ select 1; $$ language sql",
+				"drop function f",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.statements, splitStatements(tc.script), tc.script)
+	}
+}
+
+func TestStatementRisks(t *testing.T) {
+	testCases := []struct {
+		statement string
+		risks     int
+	}{
+		{statement: "create index on orders (status)", risks: 1},
+		{statement: "CREATE UNIQUE INDEX orders_uq ON orders (id)", risks: 1},
+		{statement: "create index concurrently on orders (status)", risks: 0},
+		{statement: "/* concurrently */ create index on orders (status)", risks: 1},
+		{statement: "alter table orders alter column amount type numeric", risks: 1},
+		{statement: "ALTER TABLE orders ALTER amount SET DATA TYPE bigint", risks: 1},
+		{statement: "alter table orders alter column amount set not null", risks: 1},
+		{statement: "alter table orders add constraint orders_fk foreign key (user_id) references users", risks: 1},
+		{statement: "alter table orders add constraint orders_fk foreign key (user_id) references users not valid", risks: 0},
+		{statement: "alter table orders add check (amount > 0)", risks: 1},
+		{statement: "alter table orders add column note text", risks: 0},
+	}
+
+	for _, tc := range testCases {
+		assert.Len(t, statementRisks(tc.statement), tc.risks, tc.statement)
+	}
+}
+
+func TestMergeLocks(t *testing.T) {
+	header := []string{"#", "relkind", "relnamespace", "relname", "belongs_to_relation", "locktype", "mode", "granted", "fastpath"}
+	first := time.Now()
+	second := first.Add(time.Second)
+	third := second.Add(time.Second)
+
+	sampled := map[string]sampledLock{"orders": {mode: "ShareLock", firstSeen: first, lastSeen: first}}
+
+	mergeLocks(sampled, [][]string{
+		header,
+		{"1", "r", "public", "orders", "", "relation", "AccessExclusiveLock", "t", "f"},
+		{"2", "i", "public", "orders_pkey", "orders", "relation", "AccessShareLock", "t", "t"},
+		{"3", "r", "shop", "items", "", "relation", "RowExclusiveLock", "t", "t"},
+		{"4", "r", "pg_catalog", "pg_class", "", "relation", "RowExclusiveLock", "t", "t"},
+	}, first)
+
+	mergeLocks(sampled, [][]string{
+		header,
+		{"1", "r", "public", "orders", "", "relation", "AccessExclusiveLock", "t", "f"},
+		{"2", "i", "public", "orders_pkey", "orders", "relation", "AccessExclusiveLock", "t", "f"},
+	}, second)
+
+	// The last sample, once the statement is done, sees only the lock on the index.
+	mergeLocks(sampled, [][]string{
+		header,
+		{"1", "r", "public", "orders", "", "relation", "AccessShareLock", "t", "t"},
+		{"2", "i", "public", "orders_pkey", "orders", "relation", "AccessExclusiveLock", "t", "f"},
+	}, third)
+
+	assert.Equal(t, map[string]sampledLock{
+		"orders":      {mode: "AccessExclusiveLock", firstSeen: first, lastSeen: second},
+		"orders_pkey": {mode: "AccessExclusiveLock", firstSeen: second, lastSeen: third},
+		"shop.items":  {mode: "RowExclusiveLock", firstSeen: first, lastSeen: first},
+	}, sampled)
+
+	// The lock still held once the statement is done is released at the commit, two seconds later.
+	locks := heldLocks(sampled, third, third.Add(2*time.Second))
+	assert.Equal(t, []relationLock{
+		{Relation: "orders", Mode: "AccessExclusiveLock", Held: time.Second},
+		{Relation: "orders_pkey", Mode: "AccessExclusiveLock", Held: 3 * time.Second},
+		{Relation: "shop.items", Mode: "RowExclusiveLock", Held: 0},
+	}, locks)
+
+	longest, ok := longestExclusiveLock(locks)
+	assert.True(t, ok)
+	assert.Equal(t, "orders_pkey", longest.Relation)
+
+	_, ok = longestExclusiveLock(locks[2:])
+	assert.False(t, ok)
+}
+
+func TestNonTransactional(t *testing.T) {
+	testCases := []struct {
+		statement     string
+		transactional bool
+	}{
+		{statement: "create index concurrently on orders (status)"},
+		{statement: "-- a comment\nCREATE UNIQUE INDEX\n  CONCURRENTLY orders_uq ON orders (id)"},
+		{statement: "drop index concurrently orders_status_idx"},
+		{statement: "reindex (verbose) index concurrently orders_pkey"},
+		{statement: "alter table orders detach partition orders_2020 concurrently"},
+		{statement: "vacuum analyze orders"},
+		{statement: "create index on orders (status)", transactional: true},
+		{statement: "/* concurrently */ create index on orders (status)", transactional: true},
+		{statement: "create index on orders (concurrently)", transactional: true},
+		{statement: "comment on table orders is 'built concurrently'", transactional: true},
+		{statement: "refresh materialized view concurrently orders_stats", transactional: true},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.transactional, !nonTransactional.MatchString(statementKeywords(tc.statement)), tc.statement)
+	}
+}
+
+func TestStatementKeywords(t *testing.T) {
+	assert.Equal(t, "create index on t (a)", statementKeywords("-- build it\nCREATE  INDEX /* now */ ON t\n(a)"))
+	assert.Equal(t, "comment on table t is 'a -- b /* c */'", statementKeywords("COMMENT ON TABLE t IS 'A -- B /* C */'"))
+}
+
+func TestRewrittenRelations(t *testing.T) {
+	before := map[int64]relationFile{1: {name: "orders", filenode: 10}, 2: {name: "items", filenode: 20}, 3: {name: "users", filenode: 30}}
+	after := map[int64]relationFile{1: {name: "orders", filenode: 11}, 2: {name: "items", filenode: 20}, 4: {name: "new", filenode: 40}}
+
+	assert.Equal(t, []string{"orders"}, rewrittenRelations(before, after))
+	assert.Empty(t, rewrittenRelations(before, before))
+}
+
+func TestRenderMigration(t *testing.T) {
+	steps := []migrationStep{
+		{
+			Statement: "alter table orders\n  alter column amount type numeric",
+			Duration:  1500 * time.Millisecond,
+			Locks: []relationLock{
+				{Relation: "orders", Mode: "AccessExclusiveLock"},
+				{Relation: "orders_pkey", Mode: "AccessExclusiveLock"},
+			},
+			Rewritten: []string{"orders"},
+			Risks:     statementRisks("alter table orders alter column amount type numeric"),
+		},
+		{Statement: "create index concurrently on orders (status)", Duration: 20 * time.Millisecond},
+	}
+
+	assert.Equal(t, "*Migration check:* 2 of 3 statements run in 1.520 s.\n"+
+		"\n*1.* `alter table orders alter column amount type numeric` — 1.500 s\n"+
+		"• Strongest lock: `AccessExclusiveLock` on `orders`\n"+
+		"• Relations: `orders` (AccessExclusiveLock), `orders_pkey` (AccessExclusiveLock)\n"+
+		"• :warning: Table rewritten: `orders`\n"+
+		"• :warning: ALTER COLUMN TYPE may rewrite the table and its indexes under an AccessExclusiveLock, "+
+		"which blocks reads and writes.\n"+
+		"\n*2.* `create index concurrently on orders (status)` — 20.000 ms\n"+
+		"• No relation locks observed.\n"+
+		"\nThe changes have been applied to the clone; use `reset` to revert them.",
+		renderMigration(steps, 3, usermanager.TxStatusIdle))
+
+	assert.Equal(t, "*Migration check:* 0 of 1 statement run in 0.000 ms.\n", renderMigration(nil, 1, usermanager.TxStatusIdle))
+
+	assert.True(t, strings.HasSuffix(renderMigration(steps[1:], 2, usermanager.TxStatusFailed),
+		"\n"+usermanager.MsgTransactionFailed), "a failed statement fails the transaction of the user")
+	assert.True(t, strings.HasSuffix(renderMigration(steps[1:], 1, usermanager.TxStatusInTransaction),
+		"\nThe changes are in the open transaction; `commit` or `rollback` it."))
+}
+
+func TestLockStrength(t *testing.T) {
+	assert.Greater(t, lockStrength("AccessExclusiveLock"), lockStrength("ShareLock"))
+	assert.Greater(t, lockStrength("ShareUpdateExclusiveLock"), lockStrength("RowExclusiveLock"))
+	assert.Equal(t, -1, lockStrength("AdvisoryLock"))
+}
@@ -0,0 +1,178 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// ParamsOption is the option of the plan command that binds values to the placeholders of the query,
+// e.g. `plan --params 42,'abc' select * from t where id = $1 and name = $2`.
+const ParamsOption = "--params"
+
+// paramValue matches the values allowed for a placeholder: a number, a quoted string, NULL, TRUE or FALSE,
+// optionally with a cast. Expressions are not allowed since EXPLAIN EXECUTE evaluates them.
+var paramValue = regexp.MustCompile(`(?i)^([-+]?(\d+\.?\d*|\.\d+)(e[-+]?\d+)?|'([^']|'')*'|null|true|false)(::[a-z_][a-z0-9_.]*(\[])*)?$`)
+
+// ParseParamsOption extracts ParamsOption from the beginning of the query of the plan command.
+// It reports whether the option is given and returns the values as SQL literals and the query without the option.
+func ParseParamsOption(query string) ([]string, string, bool, error) {
+	rest, found := strings.CutPrefix(query, ParamsOption)
+	if !found || rest != "" && rest[0] != ' ' && rest[0] != '=' {
+		return nil, query, false, nil
+	}
+
+	rest = strings.TrimLeft(rest, " =")
+
+	// The values end at the first whitespace outside of quotes; doubled quotes toggle twice.
+	var (
+		params []string
+		quoted bool
+		start  int
+		end    = len(rest)
+	)
+
+	for index, char := range rest {
+		if !quoted && unicode.IsSpace(char) {
+			end = index
+			break
+		}
+
+		switch {
+		case char == '\'':
+			quoted = !quoted
+
+		case char == ',' && !quoted:
+			params = append(params, rest[start:index])
+			start = index + 1
+		}
+	}
+
+	if quoted {
+		return nil, query, false, errors.New("unterminated quoted string in the parameters")
+	}
+
+	params = append(params, rest[start:end])
+
+	for _, param := range params {
+		if !paramValue.MatchString(param) {
+			return nil, query, false, errors.Errorf("invalid parameter %q, use a number, a quoted string, NULL, TRUE or FALSE, "+
+				"optionally with a cast, e.g. `%s 42,'abc'::text`", param, ParamsOption)
+		}
+	}
+
+	return params, strings.TrimSpace(rest[end:]), true, nil
+}
+
+// countParameters returns the number of the highest $n placeholder of the query, skipping
+// string literals, quoted identifiers and comments.
+func countParameters(query string) int {
+	count := 0
+
+	for index := 0; index < len(query); index++ {
+		switch {
+		case query[index] == '\'' || query[index] == '"':
+			index = skipQuoted(query, index, query[index])
+
+		case strings.HasPrefix(query[index:], "--"):
+			index = skipUntil(query, index, "\n")
+
+		case strings.HasPrefix(query[index:], "/*"):
+			index = skipUntil(query, index, "*/")
+
+		case query[index] == '$':
+			digits := index + 1
+			for digits < len(query) && query[digits] >= '0' && query[digits] <= '9' {
+				digits++
+			}
+
+			if digits > index+1 {
+				if number, err := strconv.Atoi(query[index+1 : digits]); err == nil && !isIdentifierPart(query, index-1) {
+					count = max(count, number)
+				}
+
+				index = digits - 1
+
+				continue
+			}
+
+			index = skipDollarQuoted(query, index)
+		}
+	}
+
+	return count
+}
+
+// skipQuoted returns the position of the quote closing the literal or identifier started at the index.
+func skipQuoted(query string, index int, quote byte) int {
+	for index++; index < len(query); index++ {
+		if query[index] != quote {
+			continue
+		}
+
+		// A doubled quote stands for the quote itself.
+		if index+1 < len(query) && query[index+1] == quote {
+			index++
+			continue
+		}
+
+		break
+	}
+
+	return index
+}
+
+// skipUntil returns the position of the last byte of the terminator following the index, or the end of the query.
+func skipUntil(query string, index int, terminator string) int {
+	end := strings.Index(query[index+len(terminator):], terminator)
+	if end < 0 {
+		return len(query)
+	}
+
+	return index + len(terminator) + end + len(terminator) - 1
+}
+
+// skipDollarQuoted returns the position of the end of the dollar-quoted string started at the index,
+// e.g. $$text$$ or $tag$text$tag$, or the index itself when the dollar sign starts no string.
+func skipDollarQuoted(query string, index int) int {
+	if isIdentifierPart(query, index-1) {
+		return index
+	}
+
+	end := strings.IndexByte(query[index+1:], '$')
+	if end < 0 {
+		return index
+	}
+
+	tag := query[index : index+end+2]
+	for _, char := range tag[1 : len(tag)-1] {
+		if !(char == '_' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9') {
+			return index
+		}
+	}
+
+	closing := strings.Index(query[index+len(tag):], tag)
+	if closing < 0 {
+		return len(query)
+	}
+
+	return index + len(tag) + closing + len(tag) - 1
+}
+
+// isIdentifierPart tells whether the byte at the index continues an identifier, as in "a$1".
+func isIdentifierPart(query string, index int) bool {
+	if index < 0 {
+		return false
+	}
+
+	char := query[index]
+
+	return char == '_' || char == '$' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9'
+}
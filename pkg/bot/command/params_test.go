@@ -0,0 +1,92 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParamsOption(t *testing.T) {
+	testCases := []struct {
+		query  string
+		params []string
+		rest   string
+		given  bool
+	}{
+		{query: "select 1", rest: "select 1"},
+		{query: "--paramsx select 1", rest: "--paramsx select 1"},
+		{query: "--params 42 select $1", params: []string{"42"}, rest: "select $1", given: true},
+		{
+			query:  "--params 42,'a, b c',NULL,-1.5e3,'2026-01-01'::date,'it''s' select $1, $2, $3, $4, $5, $6",
+			params: []string{"42", "'a, b c'", "NULL", "-1.5e3", "'2026-01-01'::date", "'it''s'"},
+			rest:   "select $1, $2, $3, $4, $5, $6",
+			given:  true,
+		},
+		{query: "--params=true,'{1,2}'::int[]\nselect $1, $2", params: []string{"true", "'{1,2}'::int[]"}, rest: "select $1, $2", given: true},
+	}
+
+	for _, tc := range testCases {
+		params, rest, given, err := ParseParamsOption(tc.query)
+		require.NoError(t, err, tc.query)
+		assert.Equal(t, tc.params, params, tc.query)
+		assert.Equal(t, tc.rest, rest, tc.query)
+		assert.Equal(t, tc.given, given, tc.query)
+	}
+}
+
+func TestParseParamsOptionInvalid(t *testing.T) {
+	for _, query := range []string{
+		"--params 'abc select $1",
+		"--params pg_sleep(10) select $1",
+		"--params 1,,2 select $1",
+		"--params",
+	} {
+		_, _, _, err := ParseParamsOption(query)
+		assert.Error(t, err, query)
+	}
+}
+
+func TestCountParameters(t *testing.T) {
+	testCases := []struct {
+		query string
+		count int
+	}{
+		{query: "select 1", count: 0},
+		{query: "select * from t where a = $1 and b = $2", count: 2},
+		{query: "select * from t where a = $2", count: 2},
+		{query: "select $10, $1", count: 10},
+		{query: "select '$1', \"$2\", $3", count: 3},
+		{query: "select 1 -- $1\n, 2 /* $2 */", count: 0},
+		{query: "select $$ $1 $$, $tag$ $2 $tag$, $1", count: 1},
+		{query: "select a$1 from t", count: 0},
+		{query: "select 'it''s $1', $2", count: 2},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.count, countParameters(tc.query), tc.query)
+	}
+}
+
+func TestPlanTitleAndExecuteStatement(t *testing.T) {
+	assert.Equal(t, "Plan", (&PlanCmd{query: "select 1"}).planTitle())
+	assert.Equal(t, "Generic plan", (&PlanCmd{query: "select $1", dbVersion: 150004}).planTitle())
+	assert.Equal(t, "Custom plan for NULL parameters", (&PlanCmd{query: "select $1", dbVersion: 110022}).planTitle())
+	assert.Equal(t, "Custom plan for $1 = 42, $2 = 'abc'",
+		(&PlanCmd{query: "select $1, $2", params: []string{"42", "'abc'"}}).planTitle())
+
+	assert.Equal(t, "EXECUTE joe_plan(NULL, NULL)", executeStatement(nil, 2))
+	assert.Equal(t, "EXECUTE joe_plan(42, 'abc')", executeStatement([]string{"42", "'abc'"}, 2))
+}
+
+func TestPlanNeedsScope(t *testing.T) {
+	assert.False(t, (&PlanCmd{}).needsScope(false, nil))
+	assert.True(t, (&PlanCmd{}).needsScope(true, nil))
+	assert.True(t, (&PlanCmd{}).needsScope(false, []string{"hypopg.enabled = false"}))
+	assert.True(t, (&PlanCmd{timeout: StatementTimeout{Timeout: time.Minute}}).needsScope(false, nil))
+}
@@ -7,9 +7,12 @@ package command
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/dustin/go-humanize/english"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pkg/errors"
 	"gitlab.com/postgres-ai/database-lab/v3/pkg/log"
 
@@ -23,27 +26,54 @@ import (
 // MsgPlanOptionReq describes an explain without execution error.
 const MsgPlanOptionReq = "Use `plan` to see the query's plan without execution, e.g. `plan select 1`"
 
+const (
+	// queryExplainGeneric plans a query with placeholders for any values of its parameters.
+	queryExplainGeneric = "EXPLAIN (GENERIC_PLAN, FORMAT TEXT) "
+
+	// planStatementName names the prepared statement planned for a query with placeholders.
+	planStatementName = "joe_plan"
+)
+
 // PlanCmd defines the plan command.
 type PlanCmd struct {
 	command   *platform.Command
 	message   *models.Message
 	userConn  *pgx.Conn
+	dbVersion int
 	messenger connection.Messenger
+
+	// timeout limits the planning, e.g. the one of `explain`.
+	timeout StatementTimeout
+
+	// query is the query to plan, without the options.
+	query string
+
+	// params are the values bound to the placeholders of the query with ParamsOption, nil for a generic plan.
+	params []string
 }
 
 // NewPlan return a new plan command.
-func NewPlan(cmd *platform.Command, msg *models.Message, db *pgx.Conn, messengerSvc connection.Messenger) *PlanCmd {
+func NewPlan(cmd *platform.Command, msg *models.Message, db *pgx.Conn, dbVersion int, messengerSvc connection.Messenger) *PlanCmd {
 	return &PlanCmd{
 		command:   cmd,
 		message:   msg,
 		userConn:  db,
+		dbVersion: dbVersion,
 		messenger: messengerSvc,
+		query:     strings.Trim(cmd.Query, "; \n"),
 	}
 }
 
 // Execute runs the plan command.
 func (cmd PlanCmd) Execute(ctx context.Context) error {
-	if cmd.command.Query == "" {
+	params, query, _, err := ParseParamsOption(cmd.command.Query)
+	if err != nil {
+		return err
+	}
+
+	cmd.query, cmd.params = strings.Trim(query, "; \n"), params
+
+	if cmd.query == "" {
 		return errors.New(MsgPlanOptionReq)
 	}
 
@@ -59,7 +89,7 @@ func (cmd PlanCmd) Execute(ctx context.Context) error {
 // explainWithoutExecution runs explain without execution.
 func (cmd *PlanCmd) explainWithoutExecution(ctx context.Context) (string, error) {
 	// Explain request and show.
-	explainResult, err := querier.DBQueryWithResponse(ctx, cmd.userConn, queryExplain+cmd.command.Query)
+	explainResult, err := cmd.explain(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -79,7 +109,7 @@ func (cmd *PlanCmd) explainWithoutExecution(ctx context.Context) (string, error)
 		}
 	}
 
-	cmd.message.AppendText(fmt.Sprintf("*Plan%s:*\n```%s```", explainPlanTitle, planPreview))
+	cmd.message.AppendText(fmt.Sprintf("*%s%s:*\n```%s```", cmd.planTitle(), explainPlanTitle, planPreview))
 
 	if err := cmd.messenger.UpdateText(cmd.message); err != nil {
 		log.Err("Show plan: ", err)
@@ -132,47 +162,149 @@ func (cmd *PlanCmd) explainWithoutExecution(ctx context.Context) (string, error)
 }
 
 func (cmd *PlanCmd) runQueryWithoutHypo(ctx context.Context) (string, error) {
-	tx, err := cmd.userConn.Begin(ctx)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to start a transaction")
+	return cmd.explain(ctx, "hypopg.enabled = false")
+}
+
+// planTitle names the kind of the plan: a query with placeholders gets a generic plan,
+// or a custom one for the values given with ParamsOption. Postgres 11 and older cannot force
+// a generic plan, so the query gets a custom plan for NULLs there.
+func (cmd *PlanCmd) planTitle() string {
+	switch {
+	case cmd.params != nil:
+		bindings := make([]string, 0, len(cmd.params))
+		for index, param := range cmd.params {
+			bindings = append(bindings, fmt.Sprintf("$%d = %s", index+1, param))
+		}
+
+		return fmt.Sprintf("Custom plan for %s", strings.Join(bindings, ", "))
+
+	case countParameters(cmd.query) > 0 && cmd.dbVersion/postgresNumDiv < pgVersion12:
+		return "Custom plan for NULL parameters"
+
+	case countParameters(cmd.query) > 0:
+		return "Generic plan"
+
+	default:
+		return "Plan"
+	}
+}
+
+// explain plans the query with the settings set locally, e.g. "hypopg.enabled = false", in a transaction
+// rolled back afterwards when needsScope tells so. A query with placeholders gets a custom plan for the values given with
+// ParamsOption; otherwise, it gets a generic plan: with EXPLAIN (GENERIC_PLAN) on Postgres 16+, or as
+// a prepared statement on older versions. Without plan_cache_mode, on Postgres 11 and older, the prepared
+// statement gets a custom plan for NULLs instead, which planTitle tells.
+func (cmd *PlanCmd) explain(ctx context.Context, settings ...string) (string, error) {
+	placeholders := countParameters(cmd.query)
+
+	if cmd.params != nil && len(cmd.params) != placeholders {
+		return "", errors.Errorf("the query has %s, but %s given", english.Plural(placeholders, "placeholder", ""),
+			english.Plural(len(cmd.params), "parameter", ""))
 	}
 
+	prepared := placeholders > 0 && (cmd.params != nil || cmd.dbVersion/postgresNumDiv < pgVersion16)
+
+	// Prepared statements outlive transactions: deallocate the statement once the scope is rolled back.
+	deallocate := false
+
 	defer func() {
-		// Rollback is safe to call even if the tx is already closed.
-		err = tx.Rollback(ctx)
+		if !deallocate {
+			return
+		}
+
+		if _, err := cmd.userConn.Exec(context.WithoutCancel(ctx), "deallocate "+planStatementName); err != nil {
+			log.Err("failed to deallocate the planned statement:", err)
+		}
 	}()
 
-	if _, err := tx.Exec(ctx, "set hypopg.enabled to false;"); err != nil {
-		return "", errors.Wrap(err, "failed to disable a hypopg setting")
+	if cmd.needsScope(prepared, settings) {
+		rollback, err := rolledBackScope(ctx, cmd.userConn)
+		if err != nil {
+			return "", err
+		}
+
+		defer rollback()
 	}
 
-	queryWithoutHypo := fmt.Sprintf(`%s %s`, queryExplain, strings.Trim(cmd.command.Query, ";"))
+	if err := cmd.timeout.setLocal(ctx, cmd.userConn); err != nil {
+		return "", err
+	}
 
-	rows, err := tx.Query(ctx, queryWithoutHypo)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to run query")
+	if prepared {
+		if err := cmd.prepare(ctx, placeholders); err != nil {
+			return "", err
+		}
+
+		deallocate = true
+	}
+
+	if prepared && cmd.dbVersion/postgresNumDiv >= pgVersion12 {
+		planCacheMode := "force_generic_plan"
+		if cmd.params != nil {
+			planCacheMode = "force_custom_plan"
+		}
+
+		settings = append(settings, "plan_cache_mode = "+planCacheMode)
+	}
+
+	for _, setting := range settings {
+		if _, err := cmd.userConn.Exec(ctx, "set local "+setting); err != nil {
+			return "", errors.Wrapf(err, "failed to set %s", setting)
+		}
 	}
-	defer rows.Close()
 
-	explainResultWithoutHypo := strings.Builder{}
+	switch {
+	case prepared:
+		return querier.DBQueryWithResponse(ctx, cmd.userConn, queryExplain+executeStatement(cmd.params, placeholders))
 
-	for rows.Next() {
-		var s string
-		if err := rows.Scan(&s); err != nil {
-			return "", errors.Wrap(err, "failed to scan result")
+	case placeholders > 0:
+		return querier.DBQueryWithResponse(ctx, cmd.userConn, queryExplainGeneric+cmd.query)
+
+	default:
+		return querier.DBQueryWithResponse(ctx, cmd.userConn, queryExplain+cmd.query)
+	}
+}
+
+// needsScope tells whether planning needs a transaction, or a savepoint in the transaction of the user, rolled back
+// afterwards: to set the settings or the statement timeout locally, or to prepare the statement. A plain query is
+// explained as is, leaving the connection as it was.
+func (cmd *PlanCmd) needsScope(prepared bool, settings []string) bool {
+	return prepared || len(settings) > 0 || cmd.timeout.Timeout > 0
+}
+
+// prepare prepares the query, letting Postgres infer the types of the parameters from their use.
+// When it cannot, e.g. in "select $1", the parameters are declared as text.
+func (cmd *PlanCmd) prepare(ctx context.Context, placeholders int) error {
+	// The first attempt runs in a savepoint, so that the transaction survives its failure.
+	if _, err := cmd.userConn.Exec(ctx, "savepoint "+planStatementName); err != nil {
+		return errors.Wrap(err, "failed to create a savepoint")
+	}
+
+	_, err := cmd.userConn.Exec(ctx, fmt.Sprintf("prepare %s as %s", planStatementName, cmd.query))
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == querier.IndeterminateDatatypePQErrorCode {
+		if _, err := cmd.userConn.Exec(ctx, "rollback to savepoint "+planStatementName); err != nil {
+			return errors.Wrap(err, "failed to roll back to savepoint")
 		}
 
-		explainResultWithoutHypo.WriteString(s)
-		explainResultWithoutHypo.WriteString("\n")
+		types := strings.TrimSuffix(strings.Repeat("text, ", placeholders), ", ")
+		_, err = cmd.userConn.Exec(ctx, fmt.Sprintf("prepare %s (%s) as %s", planStatementName, types, cmd.query))
 	}
 
-	if err := rows.Err(); err != nil {
-		return "", errors.Wrap(err, "failed to complete query")
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare the query")
 	}
 
-	if _, err := tx.Exec(ctx, "reset hypopg.enabled"); err != nil {
-		return "", errors.Wrap(err, "failed to reset a hypopg setting ")
+	return nil
+}
+
+// executeStatement returns the statement executing the prepared query with the values, or with NULLs
+// for a generic plan, which does not depend on them.
+func executeStatement(params []string, placeholders int) string {
+	if params == nil {
+		params = slices.Repeat([]string{"NULL"}, placeholders)
 	}
 
-	return explainResultWithoutHypo.String(), tx.Commit(ctx)
+	return fmt.Sprintf("EXECUTE %s(%s)", planStatementName, strings.Join(params, ", "))
 }
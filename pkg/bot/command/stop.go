@@ -0,0 +1,102 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/util"
+
+	"gitlab.com/postgres-ai/joe/pkg/bot/querier"
+	"gitlab.com/postgres-ai/joe/pkg/connection"
+	"gitlab.com/postgres-ai/joe/pkg/models"
+	"gitlab.com/postgres-ai/joe/pkg/services/platform"
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
+)
+
+// msgNothingToStop is shown when the user has no running commands.
+const msgNothingToStop = "There are no running commands to stop."
+
+// StopCmd defines the stop command that cancels the running commands of the user.
+type StopCmd struct {
+	command   *platform.Command
+	message   *models.Message
+	running   *usermanager.RunningCommands
+	messenger connection.Messenger
+}
+
+// NewStopCmd returns a new stop command. It runs alongside the commands of the session, so it only
+// takes the running commands, which are created with the session and tell the pools to cancel with.
+func NewStopCmd(cmd *platform.Command, msg *models.Message, running *usermanager.RunningCommands,
+	messengerSvc connection.Messenger) *StopCmd {
+	return &StopCmd{
+		command:   cmd,
+		message:   msg,
+		running:   running,
+		messenger: messengerSvc,
+	}
+}
+
+// Execute cancels the running commands and publishes the outcome. It does not need a session
+// of its own: the commands to cancel run in the session, if any.
+func (c *StopCmd) Execute(ctx context.Context) error {
+	cancelled := c.running.Cancel()
+
+	result, err := c.cancelQueries(ctx, cancelled)
+	if err != nil {
+		return err
+	}
+
+	c.command.Response = result
+	c.message.AppendText(result)
+
+	if err := c.messenger.Publish(c.message); err != nil {
+		return errors.Wrap(err, "failed to publish message")
+	}
+
+	return nil
+}
+
+// cancelQueries cancels the queries of the commands marked as cancelled and describes the outcome.
+func (c *StopCmd) cancelQueries(ctx context.Context, cancelled []usermanager.CancelledCommand) (string, error) {
+	if len(cancelled) == 0 {
+		return msgNothingToStop, nil
+	}
+
+	sb := strings.Builder{}
+
+	for _, command := range cancelled {
+		fmt.Fprintf(&sb, "Cancelled `%s` started %s ago", command.Command,
+			util.DurationToString(time.Since(command.StartedAt).Truncate(time.Second)))
+
+		switch {
+		case command.BackendPID == 0:
+			sb.WriteString(" before its query started.\n")
+
+		case command.Pool == nil:
+			return "", errors.New("no connection to the database to cancel the query")
+
+		default:
+			signalled, err := querier.CancelBackend(ctx, command.Pool, command.BackendPID)
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to cancel the query of backend %d", command.BackendPID)
+			}
+
+			if !signalled {
+				fmt.Fprintf(&sb, " (PID %d), the query has already finished.\n", command.BackendPID)
+				continue
+			}
+
+			fmt.Fprintf(&sb, " (PID %d).\n", command.BackendPID)
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,194 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/log"
+
+	"gitlab.com/postgres-ai/joe/pkg/bot/querier"
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
+)
+
+// TimeoutOption is the option of a command that sets the statement timeout of its queries, e.g. `explain --timeout=5m`.
+const TimeoutOption = "--timeout="
+
+// statementTimeoutMessage is the message of the error Postgres raises on a statement timeout.
+const statementTimeoutMessage = "canceling statement due to statement timeout"
+
+// StatementTimeout limits the duration of the queries of a command.
+type StatementTimeout struct {
+	Timeout time.Duration // 0 for none.
+	Max     time.Duration // The most a user may request with TimeoutOption, 0 for no limit.
+}
+
+// ParseTimeoutOption extracts TimeoutOption from the beginning of the query of a command.
+// It reports whether the option is given and returns the query without it.
+func ParseTimeoutOption(query string) (time.Duration, string, bool, error) {
+	if !strings.HasPrefix(query, TimeoutOption) {
+		return 0, query, false, nil
+	}
+
+	option, rest, _ := strings.Cut(query, " ")
+	value := strings.TrimPrefix(option, TimeoutOption)
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, query, false, errors.Errorf("invalid timeout %q, use a duration like `%s30s` or `%s5m`",
+			value, TimeoutOption, TimeoutOption)
+	}
+
+	return timeout, strings.TrimSpace(rest), true, nil
+}
+
+// Request returns the statement timeout requested by a user, which must not exceed the maximum.
+func (t StatementTimeout) Request(timeout time.Duration) (StatementTimeout, error) {
+	if t.Max > 0 && (timeout == 0 || timeout > t.Max) {
+		return t, errors.Errorf("the timeout must not exceed %s in this channel", formatTimeout(t.Max))
+	}
+
+	t.Timeout = timeout
+
+	return t, nil
+}
+
+// setLocal sets the statement timeout for the rest of the transaction.
+func (t StatementTimeout) setLocal(ctx context.Context, tx sessionQuerier) error {
+	if t.Timeout == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("set local statement_timeout = %d", t.Timeout.Milliseconds())); err != nil {
+		return errors.Wrap(err, "failed to set statement timeout")
+	}
+
+	return nil
+}
+
+// set sets the statement timeout of the clone connection of the session and returns a function restoring the previous
+// one. Inside a transaction, the timeout is set locally, so that it ends with the transaction even when the statements
+// of the command end it. Otherwise, it is set for the session; when the statements open a transaction, a restore in it
+// would be undone by its rollback, so the previous timeout is only set locally and RestoreStatementTimeout restores it
+// for the session once the transaction ends.
+func (t StatementTimeout) set(ctx context.Context, session *usermanager.UserSession) (func(), error) {
+	if t.Timeout == 0 {
+		return func() {}, nil
+	}
+
+	conn := session.CloneConnection
+	local := conn.PgConn().TxStatus() != usermanager.TxStatusIdle
+
+	var previous string
+
+	if err := conn.QueryRow(ctx, "select current_setting('statement_timeout')").Scan(&previous); err != nil {
+		return nil, errors.Wrap(err, "failed to get statement timeout")
+	}
+
+	if err := setStatementTimeout(ctx, conn, fmt.Sprint(t.Timeout.Milliseconds()), local); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ctx := context.WithoutCancel(ctx)
+
+		switch status := conn.PgConn().TxStatus(); {
+		case status == usermanager.TxStatusFailed:
+			// The timeout set in the failed transaction ends with it.
+			if !local {
+				session.RestoreTimeout = previous
+			}
+
+			return
+
+		case status == usermanager.TxStatusInTransaction && !local:
+			session.RestoreTimeout = previous
+
+			if err := setStatementTimeout(ctx, conn, previous, true); err != nil {
+				log.Err("failed to restore statement timeout in the transaction:", err)
+			}
+
+			return
+
+		case status == usermanager.TxStatusIdle && local:
+			// The transaction has ended, and the local timeout with it.
+			return
+		}
+
+		if err := setStatementTimeout(ctx, conn, previous, local); err != nil {
+			log.Err("failed to restore statement timeout:", err)
+		}
+	}, nil
+}
+
+// RestoreStatementTimeout restores the statement timeout of the session left by a command whose statements opened
+// a transaction, once the transaction has ended.
+func RestoreStatementTimeout(ctx context.Context, session *usermanager.UserSession) {
+	if session.RestoreTimeout == "" || session.TxStatus() != usermanager.TxStatusIdle {
+		return
+	}
+
+	// A new connection has the default timeout.
+	if session.CloneConnection != nil && !session.CloneConnection.IsClosed() {
+		if err := setStatementTimeout(ctx, session.CloneConnection, session.RestoreTimeout, false); err != nil {
+			log.Err("failed to restore statement timeout:", err)
+			return
+		}
+	}
+
+	session.RestoreTimeout = ""
+}
+
+// setStatementTimeout sets the statement timeout of the connection, locally for the transaction or for the session.
+func setStatementTimeout(ctx context.Context, conn *pgx.Conn, timeout string, local bool) error {
+	if _, err := conn.Exec(ctx, "select set_config('statement_timeout', $1, $2)", timeout, local); err != nil {
+		return errors.Wrap(err, "failed to set statement timeout")
+	}
+
+	return nil
+}
+
+// wrapError replaces the error of a query canceled on the statement timeout with an explanation.
+func (t StatementTimeout) wrapError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != querier.QueryCanceledPQErrorCode || pgErr.Message != statementTimeoutMessage {
+		return err
+	}
+
+	text := fmt.Sprintf("The query has been canceled after the statement timeout of %s.", formatTimeout(t.Timeout))
+
+	if t.Max == 0 || t.Max > t.Timeout {
+		limit := ""
+		if t.Max > 0 {
+			limit = ", up to " + formatTimeout(t.Max)
+		}
+
+		text += fmt.Sprintf(" Use `%sDURATION` to allow more time%s.", TimeoutOption, limit)
+	}
+
+	return errors.New(text)
+}
+
+// formatTimeout formats a timeout without zero units, e.g. "5m" instead of "5m0s".
+func formatTimeout(timeout time.Duration) string {
+	text := timeout.String()
+
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+
+	return text
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeoutOption(t *testing.T) {
+	timeout, query, ok, err := ParseTimeoutOption("--timeout=5m select 1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Minute, timeout)
+	assert.Equal(t, "select 1", query)
+
+	_, query, ok, err = ParseTimeoutOption("select '--timeout=5m'")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "select '--timeout=5m'", query)
+
+	_, _, _, err = ParseTimeoutOption("--timeout=5 select 1")
+	assert.EqualError(t, err, "invalid timeout \"5\", use a duration like `--timeout=30s` or `--timeout=5m`")
+}
+
+func TestStatementTimeoutRequest(t *testing.T) {
+	limit := StatementTimeout{Timeout: 10 * time.Minute, Max: time.Hour}
+
+	requested, err := limit.Request(30 * time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, StatementTimeout{Timeout: 30 * time.Minute, Max: time.Hour}, requested)
+
+	_, err = limit.Request(2 * time.Hour)
+	assert.EqualError(t, err, "the timeout must not exceed 1h in this channel")
+
+	_, err = limit.Request(0)
+	assert.Error(t, err, "disabling the timeout must not escape the maximum")
+
+	requested, err = StatementTimeout{}.Request(0)
+	require.NoError(t, err)
+	assert.Zero(t, requested.Timeout)
+}
+
+func TestStatementTimeoutWrapError(t *testing.T) {
+	timeoutErr := errors.WithStack(&pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"})
+
+	err := StatementTimeout{Timeout: 10 * time.Minute, Max: 90 * time.Minute}.wrapError(timeoutErr)
+	assert.EqualError(t, err, "The query has been canceled after the statement timeout of 10m. "+
+		"Use `--timeout=DURATION` to allow more time, up to 1h30m.")
+
+	err = StatementTimeout{Timeout: time.Hour, Max: time.Hour}.wrapError(timeoutErr)
+	assert.EqualError(t, err, "The query has been canceled after the statement timeout of 1h.")
+
+	// Queries canceled by `stop` keep their error.
+	cancelErr := &pgconn.PgError{Code: "57014", Message: "canceling statement due to user request"}
+	assert.Equal(t, error(cancelErr), StatementTimeout{Timeout: time.Minute}.wrapError(cancelErr))
+}
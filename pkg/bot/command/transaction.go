@@ -0,0 +1,285 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/log"
+
+	"gitlab.com/postgres-ai/joe/pkg/bot/querier"
+	"gitlab.com/postgres-ai/joe/pkg/connection"
+	"gitlab.com/postgres-ai/joe/pkg/models"
+	"gitlab.com/postgres-ai/joe/pkg/services/platform"
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
+)
+
+// Transaction commands.
+const (
+	TransactionBegin     = "begin"
+	TransactionSavepoint = "savepoint"
+	TransactionRollback  = "rollback"
+	TransactionCommit    = "commit"
+)
+
+// scopeSavepoint is the savepoint rolling back the queries of a command run inside the transaction of the user.
+const scopeSavepoint = reservedSavepointPrefix + "scope"
+
+// reservedSavepointPrefix starts the names of the savepoints of the bot, which users cannot create or roll back to:
+// a user savepoint of the same name would make the bot roll back the work of the user.
+const reservedSavepointPrefix = "joe_"
+
+// savepointName matches the savepoint names accepted by the savepoint command.
+var savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var (
+	errNoTransaction = errors.New("there is no open transaction, use `begin` to open one")
+
+	errTransactionFailed = errors.New("the transaction has failed, use `rollback` or `rollback to <savepoint>` first")
+)
+
+// sessionQuerier runs queries and statements on a connection or in a transaction.
+type sessionQuerier interface {
+	querier.Querier
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// TransactionCmd defines the commands controlling the transaction of the user on the clone connection:
+// `begin`, `savepoint <name>`, `rollback [to <name>]` and `commit`.
+type TransactionCmd struct {
+	command   *platform.Command
+	message   *models.Message
+	session   *usermanager.UserSession
+	messenger connection.Messenger
+}
+
+// NewTransactionCmd returns a new transaction command.
+func NewTransactionCmd(cmd *platform.Command, msg *models.Message, session *usermanager.UserSession,
+	messengerSvc connection.Messenger) *TransactionCmd {
+	return &TransactionCmd{
+		command:   cmd,
+		message:   msg,
+		session:   session,
+		messenger: messengerSvc,
+	}
+}
+
+// Execute runs the transaction command.
+func (c *TransactionCmd) Execute(ctx context.Context) error {
+	if c.session.CloneConnection == nil {
+		return errors.New("no connection to the clone")
+	}
+
+	var (
+		result string
+		err    error
+	)
+
+	switch c.command.Command {
+	case TransactionBegin:
+		result, err = c.begin(ctx)
+
+	case TransactionSavepoint:
+		result, err = c.savepoint(ctx, c.command.Query)
+
+	case TransactionRollback:
+		result, err = c.rollback(ctx, c.command.Query)
+
+	case TransactionCommit:
+		result, err = c.commit(ctx)
+
+	default:
+		err = errors.Errorf("unknown transaction command %q", c.command.Command)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.command.Response = result
+	c.message.AppendText(result)
+
+	if err := c.messenger.UpdateText(c.message); err != nil {
+		return errors.Wrap(err, "failed to publish message")
+	}
+
+	return nil
+}
+
+func (c *TransactionCmd) begin(ctx context.Context) (string, error) {
+	if c.session.TxStatus() != usermanager.TxStatusIdle {
+		return "", errors.New("a transaction is already open, use `commit` or `rollback` first")
+	}
+
+	if _, err := c.session.CloneConnection.Exec(ctx, "begin"); err != nil {
+		return "", errors.Wrap(err, "failed to begin a transaction")
+	}
+
+	c.session.Transaction = &usermanager.Transaction{StartedAt: time.Now()}
+
+	return "Transaction started. `exec` and `explain` run inside it until `commit` or `rollback`; " +
+		"`explain` rolls back its own changes.", nil
+}
+
+func (c *TransactionCmd) savepoint(ctx context.Context, name string) (string, error) {
+	switch c.session.TxStatus() {
+	case usermanager.TxStatusIdle:
+		return "", errNoTransaction
+
+	case usermanager.TxStatusFailed:
+		return "", errTransactionFailed
+	}
+
+	name, err := parseSavepointName(name)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := c.session.CloneConnection.Exec(ctx, "savepoint "+name); err != nil {
+		return "", errors.Wrap(err, "failed to create a savepoint")
+	}
+
+	c.transaction().Savepoints = append(c.transaction().Savepoints, name)
+
+	return fmt.Sprintf("Savepoint `%s` created.", name), nil
+}
+
+// rollback rolls back the transaction or, with `to <name>`, its changes after the savepoint.
+func (c *TransactionCmd) rollback(ctx context.Context, args string) (string, error) {
+	if c.session.TxStatus() == usermanager.TxStatusIdle {
+		return "", errNoTransaction
+	}
+
+	if args == "" {
+		if _, err := c.session.CloneConnection.Exec(ctx, "rollback"); err != nil {
+			return "", errors.Wrap(err, "failed to roll back the transaction")
+		}
+
+		c.session.Transaction = nil
+
+		return "Transaction rolled back.", nil
+	}
+
+	target, found := strings.CutPrefix(strings.ToLower(args), "to ")
+	if !found {
+		return "", errors.New("use `rollback` or `rollback to <savepoint>`")
+	}
+
+	name, err := parseSavepointName(strings.TrimPrefix(strings.TrimSpace(target), "savepoint "))
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := c.session.CloneConnection.Exec(ctx, "rollback to savepoint "+name); err != nil {
+		return "", errors.Wrapf(err, "failed to roll back to savepoint %s", name)
+	}
+
+	c.transaction().RollbackTo(name)
+
+	return fmt.Sprintf("Rolled back to savepoint `%s`.", name), nil
+}
+
+func (c *TransactionCmd) commit(ctx context.Context) (string, error) {
+	if c.session.TxStatus() == usermanager.TxStatusIdle {
+		return "", errNoTransaction
+	}
+
+	tag, err := c.session.CloneConnection.Exec(ctx, "commit")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to commit the transaction")
+	}
+
+	c.session.Transaction = nil
+
+	// Postgres rolls back a failed transaction on commit.
+	if tag.String() == "ROLLBACK" {
+		return "The transaction had failed and has been rolled back.", nil
+	}
+
+	return "Transaction committed.", nil
+}
+
+// transaction returns the transaction of the session, tracking one opened with `exec begin` from now on.
+func (c *TransactionCmd) transaction() *usermanager.Transaction {
+	if c.session.Transaction == nil {
+		c.session.Transaction = &usermanager.Transaction{StartedAt: time.Now()}
+	}
+
+	return c.session.Transaction
+}
+
+// parseSavepointName returns the name of a savepoint folded to lower case, as Postgres does.
+func parseSavepointName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if !savepointName.MatchString(name) {
+		return "", errors.Errorf("invalid savepoint name %q, use letters, digits and underscores, e.g. `savepoint step1`", name)
+	}
+
+	name = strings.ToLower(name)
+
+	if strings.HasPrefix(name, reservedSavepointPrefix) {
+		return "", errors.Errorf("savepoint names starting with %q are reserved, use another name, e.g. `savepoint step1`",
+			reservedSavepointPrefix)
+	}
+
+	return name, nil
+}
+
+// failTransaction puts the transaction of the user into the failed state when the changes of a command could not be
+// rolled back: the user has to roll it back, or back to a savepoint of theirs, which discards the changes as well.
+func failTransaction(ctx context.Context, conn *pgx.Conn) {
+	_, err := conn.Exec(ctx, "do $$ begin raise exception 'the changes of the command could not be rolled back'; end $$")
+	if err == nil || conn.PgConn().TxStatus() != usermanager.TxStatusFailed {
+		log.Err("failed to mark the transaction as failed:", err)
+	}
+}
+
+// rolledBackScope starts a scope on the clone connection that the returned function rolls back: a transaction
+// or, when the user has opened one, a savepoint in it, so that the queries see the changes made in the transaction.
+func rolledBackScope(ctx context.Context, conn *pgx.Conn) (func(), error) {
+	switch conn.PgConn().TxStatus() {
+	case usermanager.TxStatusFailed:
+		return nil, errTransactionFailed
+
+	case usermanager.TxStatusInTransaction:
+		if _, err := conn.Exec(ctx, "savepoint "+scopeSavepoint); err != nil {
+			return nil, errors.Wrap(err, "failed to create a savepoint")
+		}
+
+		return func() {
+			ctx := context.WithoutCancel(ctx)
+
+			if _, err := conn.Exec(ctx, "rollback to savepoint "+scopeSavepoint); err != nil {
+				log.Err("failed to roll back to savepoint:", err)
+				failTransaction(ctx, conn)
+
+				return
+			}
+
+			if _, err := conn.Exec(ctx, "release savepoint "+scopeSavepoint); err != nil {
+				log.Err("failed to release savepoint:", err)
+			}
+		}, nil
+	}
+
+	if _, err := conn.Exec(ctx, "begin"); err != nil {
+		return nil, errors.Wrap(err, "failed to start a transaction")
+	}
+
+	return func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "rollback"); err != nil {
+			log.Err("failed to roll back transaction:", err)
+		}
+	}, nil
+}
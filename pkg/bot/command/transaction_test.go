@@ -0,0 +1,23 @@
+/*
+2026 © Postgres.ai
+*/
+
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSavepointName(t *testing.T) {
+	name, err := parseSavepointName(" Step_1 ")
+	require.NoError(t, err)
+	assert.Equal(t, "step_1", name)
+
+	for _, invalid := range []string{"", "1step", "step 1", "step;drop table t", `"step"`, "joe_scope", "JOE_plan"} {
+		_, err := parseSavepointName(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -23,6 +23,21 @@ const (
 
 	// SystemPQErrorCodeUndefinedFile defines external errors to PostgreSQL itself.
 	SystemPQErrorCodeUndefinedFile = "58P01"
+
+	// QueryCanceledPQErrorCode defines the error code of a canceled query, e.g. on a statement timeout.
+	QueryCanceledPQErrorCode = "57014"
+
+	// IndeterminateDatatypePQErrorCode defines the error code of a parameter whose type cannot be inferred.
+	IndeterminateDatatypePQErrorCode = "42P18"
+
+	// UndefinedFunctionPQErrorCode defines the error code of a call to a missing function, e.g. of an extension not installed.
+	UndefinedFunctionPQErrorCode = "42883"
+
+	// InsufficientPrivilegePQErrorCode defines the error code of a statement the user is not allowed to run.
+	InsufficientPrivilegePQErrorCode = "42501"
+
+	// FeatureNotSupportedPQErrorCode defines the error code of an unsupported feature.
+	FeatureNotSupportedPQErrorCode = "0A000"
 )
 
 // Querier is the minimal pgx interface used by the querier package.
@@ -86,6 +101,83 @@ func ObserveLocks(ctx context.Context, db Querier, pid int) ([][]string, error)
 	return runTableQuery(ctx, db, observeQuery, pid)
 }
 
+// StatementResult is the result of a statement: the rows it returns, if any, and its command tag.
+type StatementResult struct {
+	Columns    []string // Empty for a statement that returns no rows.
+	Rows       [][]string
+	CommandTag pgconn.CommandTag
+
+	// Truncated tells that the statement returned more rows than Rows keeps.
+	Truncated bool
+}
+
+// ExecStatements runs the statements of the query with the simple protocol, which returns
+// the values as text, and returns the results of the statements. NULL values are empty.
+// Each result keeps its first rows, up to maxRows and up to maxBytes of values; the rest
+// are read and dropped, so that a large result does not have to fit in memory.
+func ExecStatements(ctx context.Context, conn *pgconn.PgConn, query string, maxRows, maxBytes int) ([]StatementResult, error) {
+	log.Dbg("DB exec:", query)
+
+	multiReader := conn.Exec(ctx, query)
+
+	var statementResults []StatementResult
+
+	for multiReader.NextResult() {
+		statementResults = append(statementResults, readStatementResult(multiReader.ResultReader(), maxRows, maxBytes))
+	}
+
+	if err := multiReader.Close(); err != nil {
+		log.Err("DB exec:", err)
+		return nil, clarifyQueryError([]byte(query), err)
+	}
+
+	return statementResults, nil
+}
+
+// readStatementResult reads the result of a statement, keeping the first rows within the limits.
+// An error of the statement is returned by the multi-result reader.
+func readStatementResult(reader *pgconn.ResultReader, maxRows, maxBytes int) StatementResult {
+	var (
+		result StatementResult
+		size   int
+	)
+
+	for _, field := range reader.FieldDescriptions() {
+		result.Columns = append(result.Columns, field.Name)
+	}
+
+	for reader.NextRow() {
+		if result.Truncated {
+			continue
+		}
+
+		row := reader.Values()
+
+		rowSize := 0
+		for _, value := range row {
+			rowSize += len(value)
+		}
+
+		if len(result.Rows) >= maxRows || size+rowSize > maxBytes {
+			result.Truncated = true
+			continue
+		}
+
+		// The values are only valid until the next row is read.
+		values := make([]string, 0, len(row))
+		for _, value := range row {
+			values = append(values, string(value))
+		}
+
+		result.Rows = append(result.Rows, values)
+		size += rowSize
+	}
+
+	result.CommandTag, _ = reader.Close()
+
+	return result
+}
+
 func runQuery(ctx context.Context, db Querier, query string) (string, error) {
 	log.Dbg("DB query:", query)
 
@@ -225,3 +317,14 @@ func GetBackendPID(ctx context.Context, conn Querier) (int, error) {
 
 	return backendPID, nil
 }
+
+// CancelBackend cancels the current query of a backend and reports whether the signal has been sent.
+func CancelBackend(ctx context.Context, conn Querier, pid int) (bool, error) {
+	var cancelled bool
+
+	if err := conn.QueryRow(ctx, `select pg_cancel_backend($1)`, pid).Scan(&cancelled); err != nil {
+		return false, err
+	}
+
+	return cancelled, nil
+}
@@ -89,6 +89,114 @@ type Channel struct {
 	ChannelID   string      `yaml:"channelID" json:"channel_id"`
 	DBLabID     string      `yaml:"dblabServer" json:"-"`
 	DBLabParams DBLabParams `yaml:"dblabParams" json:"-"`
+
+	PlanArtifacts PlanArtifacts `yaml:"planArtifacts" json:"-"`
+	ExecResults   ExecResults   `yaml:"execResults" json:"-"`
+	Limits        Limits        `yaml:"limits" json:"-"`
+}
+
+// Limits defines the limits of the commands run in a channel.
+type Limits struct {
+	// StatementTimeout is the statement timeout of the queries, 0 for none.
+	StatementTimeout time.Duration `yaml:"statementTimeout" json:"-"`
+
+	// MaxStatementTimeout is the most users may request with --timeout,
+	// StatementTimeout when not set; 0 for both means no limit.
+	MaxStatementTimeout time.Duration `yaml:"maxStatementTimeout" json:"-"`
+
+	// Commands overrides the limits per command, e.g. for "exec".
+	Commands map[string]CommandLimits `yaml:"commands" json:"-"`
+
+	// MaxRunningCommands caps the commands running in the channel at once, 0 for no cap;
+	// the commands of a user always run one by one.
+	MaxRunningCommands int `yaml:"maxRunningCommands" json:"-"`
+}
+
+// CommandLimits defines the statement timeouts of the queries of a command.
+type CommandLimits struct {
+	StatementTimeout    time.Duration `yaml:"statementTimeout" json:"-"`
+	MaxStatementTimeout time.Duration `yaml:"maxStatementTimeout" json:"-"`
+}
+
+// CommandTimeout returns the statement timeout of the queries of the command
+// and the most users may request, 0 for none.
+func (l Limits) CommandTimeout(command string) (timeout, maxTimeout time.Duration) {
+	timeout, maxTimeout = l.StatementTimeout, l.MaxStatementTimeout
+
+	if limits, ok := l.Commands[command]; ok {
+		if limits.StatementTimeout != 0 {
+			timeout = limits.StatementTimeout
+		}
+
+		if limits.MaxStatementTimeout != 0 {
+			maxTimeout = limits.MaxStatementTimeout
+		}
+	}
+
+	if maxTimeout == 0 {
+		maxTimeout = timeout
+	}
+
+	return timeout, max(timeout, maxTimeout)
+}
+
+// PlanArtifacts defines options of the plan files attached to the explain results.
+type PlanArtifacts struct {
+	// Anonymize adds a copy of the plan with names replaced by placeholders and
+	// literals stripped, fit for sharing, and the mapping to restore the names.
+	Anonymize bool `yaml:"anonymize" json:"-"`
+}
+
+// Default limits of the results of exec.
+const (
+	// DefaultExecMaxRows is the number of the result rows of exec shown in the message by default.
+	DefaultExecMaxRows = 20
+
+	// DefaultExecMaxFileRows is the number of the result rows of exec read and attached as a CSV file by default.
+	DefaultExecMaxFileRows = 10000
+
+	// DefaultExecMaxFileBytes is the size of the values of the result rows of exec read by default.
+	DefaultExecMaxFileBytes = 10 << 20
+)
+
+// ExecResults defines options of the results of the exec command.
+type ExecResults struct {
+	// MaxRows caps the result rows shown in the message, DefaultExecMaxRows when not set;
+	// the result is attached as a CSV file.
+	MaxRows int `yaml:"maxRows" json:"-"`
+
+	// MaxFileRows and MaxFileBytes cap the result rows read from Postgres and attached as a CSV file,
+	// by number and by the size of their values, DefaultExecMaxFileRows and DefaultExecMaxFileBytes when not set.
+	MaxFileRows  int `yaml:"maxFileRows" json:"-"`
+	MaxFileBytes int `yaml:"maxFileBytes" json:"-"`
+}
+
+// RowLimit returns the number of the result rows shown in the message.
+func (r ExecResults) RowLimit() int {
+	if r.MaxRows <= 0 {
+		return DefaultExecMaxRows
+	}
+
+	return r.MaxRows
+}
+
+// FileRowLimit returns the number of the result rows read and attached as a CSV file,
+// never fewer than the ones shown in the message.
+func (r ExecResults) FileRowLimit() int {
+	if r.MaxFileRows <= 0 {
+		return max(DefaultExecMaxFileRows, r.RowLimit())
+	}
+
+	return max(r.MaxFileRows, r.RowLimit())
+}
+
+// FileByteLimit returns the size of the values of the result rows read and attached as a CSV file.
+func (r ExecResults) FileByteLimit() int {
+	if r.MaxFileBytes <= 0 {
+		return DefaultExecMaxFileBytes
+	}
+
+	return r.MaxFileBytes
 }
 
 // DBLabParams defines database params for clone creation.
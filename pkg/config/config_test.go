@@ -0,0 +1,73 @@
+/*
+2026 © Postgres.ai
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsCommandTimeout(t *testing.T) {
+	var channel Channel
+
+	require.NoError(t, ParseYAML([]byte(`
+limits:
+  statementTimeout: 10m
+  maxStatementTimeout: 1h
+  commands:
+    exec:
+      statementTimeout: 30m
+    explain:
+      maxStatementTimeout: 2h
+`), &channel))
+
+	tests := []struct {
+		command     string
+		timeout     time.Duration
+		maxTimeout  time.Duration
+		description string
+	}{
+		{"plan", 10 * time.Minute, time.Hour, "channel limits"},
+		{"exec", 30 * time.Minute, time.Hour, "command timeout"},
+		{"explain", 10 * time.Minute, 2 * time.Hour, "command maximum"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			timeout, maxTimeout := channel.Limits.CommandTimeout(tt.command)
+			assert.Equal(t, tt.timeout, timeout)
+			assert.Equal(t, tt.maxTimeout, maxTimeout)
+		})
+	}
+
+	// The maximum defaults to the timeout and never is below it.
+	timeout, maxTimeout := Limits{StatementTimeout: time.Minute}.CommandTimeout("exec")
+	assert.Equal(t, time.Minute, timeout)
+	assert.Equal(t, time.Minute, maxTimeout)
+
+	_, maxTimeout = Limits{StatementTimeout: time.Hour, MaxStatementTimeout: time.Minute}.CommandTimeout("exec")
+	assert.Equal(t, time.Hour, maxTimeout)
+
+	timeout, maxTimeout = Limits{}.CommandTimeout("exec")
+	assert.Zero(t, timeout)
+	assert.Zero(t, maxTimeout)
+}
+
+func TestExecResultsRowLimit(t *testing.T) {
+	assert.Equal(t, DefaultExecMaxRows, ExecResults{}.RowLimit())
+	assert.Equal(t, 5, ExecResults{MaxRows: 5}.RowLimit())
+}
+
+func TestExecResultsFileLimits(t *testing.T) {
+	assert.Equal(t, DefaultExecMaxFileRows, ExecResults{}.FileRowLimit())
+	assert.Equal(t, 500, ExecResults{MaxFileRows: 500}.FileRowLimit())
+	assert.Equal(t, 50, ExecResults{MaxRows: 50, MaxFileRows: 10}.FileRowLimit())
+
+	assert.Equal(t, DefaultExecMaxFileBytes, ExecResults{}.FileByteLimit())
+	assert.Equal(t, 1024, ExecResults{MaxFileBytes: 1024}.FileByteLimit())
+}
@@ -7,6 +7,7 @@ package connection
 import (
 	"context"
 
+	"gitlab.com/postgres-ai/joe/pkg/config"
 	"gitlab.com/postgres-ai/joe/pkg/models"
 	"gitlab.com/postgres-ai/joe/pkg/services/dblab"
 	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
@@ -29,8 +30,8 @@ type Assistant interface {
 	// CheckIdleSessions defines the method for checking user idle sessions and notification about them.
 	CheckIdleSessions(context.Context)
 
-	// AddChannel adds a new Database Lab instance to communication via the assistant.
-	AddChannel(channelID string, dbLabInstance *dblab.Instance)
+	// AddChannel adds a new channel and its Database Lab instance to communication via the assistant.
+	AddChannel(channel config.Channel, dbLabInstance *dblab.Instance)
 
 	// DumpSessions iterates over channels and collects user's sessions to storage
 	DumpSessions()
@@ -114,22 +114,23 @@ func (a *Assistant) Deregister(_ context.Context) error {
 }
 
 // AddChannel sets a message processor for a specific channel.
-func (a *Assistant) AddChannel(channelID string, dbLabInstance *dblab.Instance) {
-	messageProcessor := a.buildMessageProcessor(channelID, dbLabInstance)
+func (a *Assistant) AddChannel(channel config.Channel, dbLabInstance *dblab.Instance) {
+	messageProcessor := a.buildMessageProcessor(channel, dbLabInstance)
 
-	a.addProcessingService(channelID, messageProcessor)
+	a.addProcessingService(channel.ChannelID, messageProcessor)
 }
 
-func (a *Assistant) buildMessageProcessor(channelID string, dbLabInstance *dblab.Instance) *msgproc.ProcessingService {
+func (a *Assistant) buildMessageProcessor(channel config.Channel, dbLabInstance *dblab.Instance) *msgproc.ProcessingService {
 	processingCfg := msgproc.ProcessingConfig{
 		App:      a.appCfg.App,
 		Platform: a.appCfg.Platform,
 		DBLab:    dbLabInstance.Config(),
 		EntOpts:  a.appCfg.Enterprise,
 		Project:  a.appCfg.Platform.Project,
+		Channel:  channel,
 	}
 
-	users := a.sessionStorage.GetUsers(CommunicationType, channelID)
+	users := a.sessionStorage.GetUsers(CommunicationType, channel.ChannelID)
 	um := usermanager.NewUserManager(a.userInformer, a.appCfg.Enterprise.Quota, users)
 
 	return msgproc.NewProcessingService(a.messenger, MessageValidator{}, dbLabInstance.Client(), um, a.platformClient,
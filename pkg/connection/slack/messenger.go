@@ -23,16 +23,20 @@ const errorNotPublished = "Message not published yet"
 
 // Bot reactions.
 const (
-	ReactionRunning = "hourglass_flowing_sand"
-	ReactionError   = "x"
-	ReactionOK      = "white_check_mark"
+	ReactionRunning   = "hourglass_flowing_sand"
+	ReactionError     = "x"
+	ReactionOK        = "white_check_mark"
+	ReactionCancelled = "no_entry_sign"
+	ReactionQueued    = "hourglass"
 )
 
 // statusMapping defines a status-reaction map.
 var statusMapping = map[models.MessageStatus]string{
-	models.StatusRunning: ReactionRunning,
-	models.StatusError:   ReactionError,
-	models.StatusOK:      ReactionOK,
+	models.StatusRunning:   ReactionRunning,
+	models.StatusError:     ReactionError,
+	models.StatusOK:        ReactionOK,
+	models.StatusCancelled: ReactionCancelled,
+	models.StatusQueued:    ReactionQueued,
 }
 
 // Subtypes of incoming messages.
@@ -132,22 +132,23 @@ func (a *Assistant) Deregister(_ context.Context) error {
 }
 
 // AddChannel sets a message processor for a specific channel.
-func (a *Assistant) AddChannel(channelID string, dbLabInstance *dblab.Instance) {
-	messageProcessor := a.buildMessageProcessor(channelID, dbLabInstance)
+func (a *Assistant) AddChannel(channel config.Channel, dbLabInstance *dblab.Instance) {
+	messageProcessor := a.buildMessageProcessor(channel, dbLabInstance)
 
-	a.addProcessingService(channelID, messageProcessor)
+	a.addProcessingService(channel.ChannelID, messageProcessor)
 }
 
-func (a *Assistant) buildMessageProcessor(channelID string, dbLabInstance *dblab.Instance) *msgproc.ProcessingService {
+func (a *Assistant) buildMessageProcessor(channel config.Channel, dbLabInstance *dblab.Instance) *msgproc.ProcessingService {
 	processingCfg := msgproc.ProcessingConfig{
 		App:      a.appCfg.App,
 		Platform: a.appCfg.Platform,
 		DBLab:    dbLabInstance.Config(),
 		EntOpts:  a.appCfg.Enterprise,
 		Project:  a.appCfg.Platform.Project,
+		Channel:  channel,
 	}
 
-	userList := a.sessionStorage.GetUsers(CommunicationType, channelID)
+	userList := a.sessionStorage.GetUsers(CommunicationType, channel.ChannelID)
 	userManager := usermanager.NewUserManager(a.userInformer, a.appCfg.Enterprise.Quota, userList)
 
 	return msgproc.NewProcessingService(
@@ -21,9 +21,11 @@ const (
 
 // Message status.
 const (
-	StatusRunning = "running"
-	StatusError   = "error"
-	StatusOK      = "ok"
+	StatusRunning   = "running"
+	StatusError     = "error"
+	StatusOK        = "ok"
+	StatusCancelled = "cancelled"
+	StatusQueued    = "queued"
 )
 
 // IncomingMessage defines a standard representation of incoming events.
@@ -0,0 +1,68 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+// Analysis is the processed plan with everything joe computes from it, for
+// tools rather than people, e.g. CI jobs gating merges on plan properties.
+// Encoded as JSON, the plan tree keeps the EXPLAIN keys and adds the computed
+// fields of every node (exclusive time and buffers, outlier flags, estimate
+// factors); the totals and the reports sit next to it.
+type Analysis struct {
+	*Explain
+
+	Fingerprint  string
+	Tips         []Tip
+	Misestimates []AnalysisMisestimate
+	Parallelism  []AnalysisParallelRegion
+}
+
+// AnalysisMisestimate is a Misestimate with the node named by its caption.
+type AnalysisMisestimate struct {
+	Node string // E.g. "Seq Scan on public.orders o".
+
+	Misestimate
+}
+
+// AnalysisParallelRegion is a ParallelRegion with the node named by its
+// caption and the numbers derived from the region.
+type AnalysisParallelRegion struct {
+	Node string // E.g. "Gather Merge".
+
+	ParallelRegion
+
+	Shortfall uint
+	Speedup   float64
+	Imbalance float64
+}
+
+// Analysis collects the computed reports of the processed plan.
+func (ex *Explain) Analysis() *Analysis {
+	analysis := &Analysis{
+		Explain:      ex,
+		Fingerprint:  ex.Fingerprint(),
+		Tips:         ex.Tips(),
+		Misestimates: []AnalysisMisestimate{},
+		Parallelism:  []AnalysisParallelRegion{},
+	}
+
+	for _, misestimate := range ex.Misestimates() {
+		analysis.Misestimates = append(analysis.Misestimates, AnalysisMisestimate{
+			Node:        nodeCaption(misestimate.Plan),
+			Misestimate: misestimate,
+		})
+	}
+
+	for _, region := range ex.Parallelism() {
+		analysis.Parallelism = append(analysis.Parallelism, AnalysisParallelRegion{
+			Node:           nodeCaption(region.Plan),
+			ParallelRegion: region,
+			Shortfall:      region.Shortfall(),
+			Speedup:        region.Speedup(),
+			Imbalance:      region.Imbalance(),
+		})
+	}
+
+	return analysis
+}
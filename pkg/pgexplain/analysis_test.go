@@ -0,0 +1,77 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalysisJSON(t *testing.T) {
+	explain, err := NewExplain(inputJSONParallelShortfall)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(explain.Analysis())
+	require.NoError(t, err)
+
+	var document struct {
+		Plan struct {
+			NodeType          string `json:"Node Type"`
+			InclusiveDuration float64
+			Plans             []struct {
+				RelationName   string `json:"Relation Name"`
+				ActualDuration float64
+				Slowest        bool
+			}
+		}
+		TotalTime    float64
+		Fingerprint  string
+		Tips         []Tip
+		Misestimates []AnalysisMisestimate
+		Parallelism  []struct {
+			Node      string
+			Shortfall uint
+			Speedup   float64
+			Processes []ParallelProcess
+		}
+	}
+
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	require.Equal(t, "Gather", document.Plan.NodeType)
+	require.Equal(t, 10.0, document.Plan.InclusiveDuration)
+	require.Equal(t, "orders", document.Plan.Plans[0].RelationName)
+	require.Equal(t, 5.0, document.Plan.Plans[0].ActualDuration)
+	require.True(t, document.Plan.Plans[0].Slowest)
+	require.Equal(t, 10.5, document.TotalTime)
+	require.Equal(t, explain.Fingerprint(), document.Fingerprint)
+	require.Empty(t, document.Tips)
+	require.Empty(t, document.Misestimates)
+
+	require.Len(t, document.Parallelism, 1)
+	require.Equal(t, "Gather", document.Parallelism[0].Node)
+	require.Equal(t, uint(2), document.Parallelism[0].Shortfall)
+	require.InDelta(t, 1.5, document.Parallelism[0].Speedup, 1e-9)
+	require.Len(t, document.Parallelism[0].Processes, 3)
+}
+
+func TestAnalysisMisestimates(t *testing.T) {
+	explain, err := NewExplain(inputJSONMisestimate)
+	require.NoError(t, err)
+
+	analysis := explain.Analysis()
+
+	require.Len(t, analysis.Misestimates, 2)
+	require.Equal(t, "Hash Join", analysis.Misestimates[0].Node)
+	require.Equal(t, "Seq Scan on shop.orders o", analysis.Misestimates[1].Node)
+	require.Equal(t, []AnalysisParallelRegion{}, analysis.Parallelism)
+
+	data, err := json.Marshal(analysis.Misestimates[1])
+	require.NoError(t, err)
+	require.NotContains(t, string(data), `"Plan"`, "the node is named, not embedded")
+	require.Contains(t, string(data), `"Factor":1250`)
+}
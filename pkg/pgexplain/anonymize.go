@@ -0,0 +1,588 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Anonymization maps the placeholders of an anonymized plan back to the names
+// they replace, per kind of name, e.g. Relations["table_1"] = "orders". Saved
+// next to a shared plan, it lets its owner read answers about the plan in
+// terms of the real schema (see Restore). Literal values are dropped by the
+// anonymizer and cannot be restored.
+type Anonymization struct {
+	Schemas     map[string]string `json:"schemas,omitempty"`
+	Relations   map[string]string `json:"relations,omitempty"`
+	Indexes     map[string]string `json:"indexes,omitempty"`
+	Columns     map[string]string `json:"columns,omitempty"`
+	Aliases     map[string]string `json:"aliases,omitempty"`
+	CTEs        map[string]string `json:"ctes,omitempty"`
+	Triggers    map[string]string `json:"triggers,omitempty"`
+	Constraints map[string]string `json:"constraints,omitempty"`
+}
+
+// anonymizedLiteral replaces string and numeric constants in expressions.
+const anonymizedLiteral = "?"
+
+// placeholderName matches the names produced by Anonymizer.
+var placeholderName = regexp.MustCompile(`\b(?:schema|table|index|column|alias|cte|trigger|constraint)_\d+\b`)
+
+// expressionKeywords are the words of expressions as PostgreSQL prints them
+// that are not names and stay as they are.
+var expressionKeywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IS": true, "NULL": true, "TRUE": true, "FALSE": true,
+	"ANY": true, "ALL": true, "SOME": true, "ARRAY": true, "ROW": true, "EXISTS": true,
+	"CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+	"IN": true, "LIKE": true, "ILIKE": true, "SIMILAR": true, "TO": true, "BETWEEN": true, "DISTINCT": true, "FROM": true,
+	"ASC": true, "DESC": true, "NULLS": true, "FIRST": true, "LAST": true, "COLLATE": true, "USING": true,
+	"AT": true, "TIME": true, "ZONE": true, "UNKNOWN": true,
+	"OVER": true, "PARTITION": true, "BY": true, "ORDER": true, "FILTER": true, "WITHIN": true, "GROUP": true,
+	"ROWS": true, "RANGE": true, "GROUPS": true, "PRECEDING": true, "FOLLOWING": true, "UNBOUNDED": true, "CURRENT": true,
+	"CURRENT_DATE": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true, "CURRENT_USER": true,
+	"LOCALTIME": true, "LOCALTIMESTAMP": true, "SESSION_USER": true, "CURRENT_ROLE": true,
+	"SubPlan": true, "InitPlan": true, "CTE": true, "hashed": true, "returns": true,
+}
+
+// systemSchemas hold the built-in objects; their names reveal nothing and are kept.
+var systemSchemas = map[string]bool{"pg_catalog": true, "information_schema": true}
+
+// typeWords continue a multi-word type name after a cast, e.g.
+// "::timestamp without time zone".
+var typeWords = map[string]bool{
+	"without": true, "with": true, "time": true, "zone": true, "varying": true, "precision": true,
+}
+
+// nameSet assigns placeholders of one kind of name: prefix_1, prefix_2, ...
+// in the order the names are met.
+type nameSet struct {
+	prefix       string
+	placeholders map[string]string // original name -> placeholder
+	originals    map[string]string // placeholder -> original name
+}
+
+func newNameSet(prefix string) *nameSet {
+	return &nameSet{prefix: prefix, placeholders: make(map[string]string), originals: make(map[string]string)}
+}
+
+// placeholder returns the placeholder of name, assigning the next one to a
+// name met for the first time.
+func (s *nameSet) placeholder(name string) string {
+	if placeholder, ok := s.placeholders[name]; ok {
+		return placeholder
+	}
+
+	placeholder := fmt.Sprintf("%s_%d", s.prefix, len(s.placeholders)+1)
+	s.placeholders[name] = placeholder
+	s.originals[placeholder] = name
+
+	return placeholder
+}
+
+// mapping returns a copy of the placeholder -> original name map, nil when empty.
+func (s *nameSet) mapping() map[string]string {
+	if len(s.originals) == 0 {
+		return nil
+	}
+
+	mapping := make(map[string]string, len(s.originals))
+	for placeholder, name := range s.originals {
+		mapping[placeholder] = name
+	}
+
+	return mapping
+}
+
+// Anonymizer replaces the names of plans with placeholders and strips the
+// literal values from their expressions, so that a plan can be shared without
+// revealing the schema or the data. The tree, the node types, costs, timings,
+// row counts and buffers stay intact.
+//
+// The same name gets the same placeholder in every plan passed to one
+// Anonymizer, so several plans of a session can be compared after
+// anonymization. Function and type names, system schemas and settings other
+// than search_path are left as they are.
+type Anonymizer struct {
+	schemas     *nameSet
+	relations   *nameSet
+	indexes     *nameSet
+	columns     *nameSet
+	aliases     *nameSet
+	ctes        *nameSet
+	triggers    *nameSet
+	constraints *nameSet
+
+	// qualifiers maps the names that qualify column references in expressions
+	// (aliases, relations and CTEs) to their placeholders.
+	qualifiers map[string]string
+}
+
+// NewAnonymizer creates a new Anonymizer.
+func NewAnonymizer() *Anonymizer {
+	return &Anonymizer{
+		schemas:     newNameSet("schema"),
+		relations:   newNameSet("table"),
+		indexes:     newNameSet("index"),
+		columns:     newNameSet("column"),
+		aliases:     newNameSet("alias"),
+		ctes:        newNameSet("cte"),
+		triggers:    newNameSet("trigger"),
+		constraints: newNameSet("constraint"),
+		qualifiers:  make(map[string]string),
+	}
+}
+
+// Anonymize anonymizes a single plan and returns the mapping to restore it.
+func (ex *Explain) Anonymize() *Anonymization {
+	anonymizer := NewAnonymizer()
+	anonymizer.Anonymize(ex)
+
+	return anonymizer.Mapping()
+}
+
+// Anonymize replaces the names and literals of the plan in place.
+func (a *Anonymizer) Anonymize(ex *Explain) {
+	// Names are collected first, so that expressions referring to a node
+	// further down the tree already know its alias.
+	ex.Plan.walk(a.collectNames)
+	ex.Plan.walk(a.anonymizeNode)
+
+	if searchPath, ok := ex.Settings["search_path"]; ok {
+		ex.Settings["search_path"] = a.searchPath(searchPath)
+	}
+
+	for index := range ex.Triggers {
+		trigger := &ex.Triggers[index]
+
+		if trigger.Name != "" {
+			trigger.Name = a.triggers.placeholder(trigger.Name)
+		}
+
+		if trigger.ConstraintName != "" {
+			trigger.ConstraintName = a.constraints.placeholder(trigger.ConstraintName)
+		}
+
+		if trigger.Relation != "" {
+			trigger.Relation = a.relations.placeholder(trigger.Relation)
+		}
+	}
+}
+
+// Mapping returns the placeholders assigned so far with the names they replace.
+func (a *Anonymizer) Mapping() *Anonymization {
+	return &Anonymization{
+		Schemas:     a.schemas.mapping(),
+		Relations:   a.relations.mapping(),
+		Indexes:     a.indexes.mapping(),
+		Columns:     a.columns.mapping(),
+		Aliases:     a.aliases.mapping(),
+		CTEs:        a.ctes.mapping(),
+		Triggers:    a.triggers.mapping(),
+		Constraints: a.constraints.mapping(),
+	}
+}
+
+// collectNames assigns placeholders to the names a node reads and registers
+// its alias as a qualifier. An alias equal to the relation or CTE name gets
+// the same placeholder, so that the rendered plan keeps omitting it.
+func (a *Anonymizer) collectNames(plan *Plan) {
+	if plan.Schema != "" {
+		a.schema(plan.Schema)
+	}
+
+	if plan.RelationName != "" {
+		a.registerQualifier(plan.RelationName, a.relations.placeholder(plan.RelationName))
+	}
+
+	if name, ok := cteSubplanName(plan); ok {
+		a.registerQualifier(name, a.ctes.placeholder(name))
+	}
+
+	if plan.CteName != "" {
+		a.registerQualifier(plan.CteName, a.ctes.placeholder(plan.CteName))
+	}
+
+	if plan.IndexName != "" {
+		a.indexes.placeholder(plan.IndexName)
+	}
+
+	if plan.Alias == "" || isGeneratedName(plan.Alias) {
+		return
+	}
+
+	switch plan.Alias {
+	case plan.FunctionName:
+		// Function names are kept, and so are aliases repeating them.
+		a.qualifiers[plan.Alias] = plan.Alias
+
+	case plan.RelationName:
+		a.qualifiers[plan.Alias] = a.relations.placeholder(plan.RelationName)
+
+	case plan.CteName:
+		a.qualifiers[plan.Alias] = a.ctes.placeholder(plan.CteName)
+
+	default:
+		a.qualifiers[plan.Alias] = a.aliases.placeholder(plan.Alias)
+	}
+}
+
+// registerQualifier lets expressions refer to a relation or CTE by its name
+// unless the name is already in use as an alias.
+func (a *Anonymizer) registerQualifier(name, placeholder string) {
+	if _, ok := a.qualifiers[name]; !ok {
+		a.qualifiers[name] = placeholder
+	}
+}
+
+// anonymizeNode replaces the names and expressions of a node.
+func (a *Anonymizer) anonymizeNode(plan *Plan) {
+	if plan.Schema != "" {
+		plan.Schema = a.schema(plan.Schema)
+	}
+
+	if name, ok := cteSubplanName(plan); ok {
+		plan.SubplanName = "CTE " + a.ctes.placeholder(name)
+	}
+
+	if plan.Alias != "" {
+		plan.Alias = a.qualifier(plan.Alias)
+	}
+
+	if plan.RelationName != "" {
+		plan.RelationName = a.relations.placeholder(plan.RelationName)
+	}
+
+	if plan.CteName != "" {
+		plan.CteName = a.ctes.placeholder(plan.CteName)
+	}
+
+	if plan.IndexName != "" {
+		plan.IndexName = a.indexes.placeholder(plan.IndexName)
+	}
+
+	for _, expression := range []*string{
+		&plan.Filter, &plan.IndexCondition, &plan.RecheckCond, &plan.HashCondition, &plan.MergeCondition,
+		&plan.JoinFilter, &plan.CacheKey, &plan.RunCondition,
+	} {
+		*expression = a.expression(*expression)
+	}
+
+	for _, expressions := range [][]string{plan.Output, plan.SortKey, plan.GroupKey, plan.PresortedKey} {
+		for index := range expressions {
+			expressions[index] = a.expression(expressions[index])
+		}
+	}
+}
+
+// schema returns the placeholder of a schema name, or the name of a system schema.
+func (a *Anonymizer) schema(name string) string {
+	if systemSchemas[name] {
+		return name
+	}
+
+	return a.schemas.placeholder(name)
+}
+
+// searchPath anonymizes the schemas of a search_path setting as EXPLAIN
+// (SETTINGS) prints it, e.g. "'$user', public".
+func (a *Anonymizer) searchPath(searchPath string) string {
+	schemas := strings.Split(searchPath, ",")
+
+	for index, schema := range schemas {
+		name := strings.Trim(strings.TrimSpace(schema), `'"`)
+		if name == "" || name == "$user" {
+			continue
+		}
+
+		schemas[index] = strings.Replace(schema, name, a.schema(name), 1)
+	}
+
+	return strings.Join(schemas, ",")
+}
+
+// qualifier returns the placeholder of a name qualifying a column reference;
+// an unknown one is taken for an alias.
+func (a *Anonymizer) qualifier(name string) string {
+	if isGeneratedName(name) {
+		return name
+	}
+
+	if placeholder, ok := a.qualifiers[name]; ok {
+		return placeholder
+	}
+
+	placeholder := a.aliases.placeholder(name)
+	a.qualifiers[name] = placeholder
+
+	return placeholder
+}
+
+// isGeneratedName reports whether a name was made up by PostgreSQL, such as
+// "*VALUES*" or "*SELECT* 1", and reveals nothing.
+func isGeneratedName(name string) bool {
+	return strings.HasPrefix(name, "*")
+}
+
+// expression anonymizes an expression as printed in a plan: qualified column
+// references become qualifier and column placeholders, other names columns,
+// and literals anonymizedLiteral. Keywords, function and type names, and
+// parameters ($1) are kept.
+func (a *Anonymizer) expression(expression string) string {
+	if expression == "" {
+		return ""
+	}
+
+	tokens := tokenizeExpression(expression)
+
+	var sb strings.Builder
+
+	for index := 0; index < len(tokens); index++ {
+		token := tokens[index]
+
+		switch token.kind {
+		case tokenString:
+			sb.WriteString(anonymizedLiteral)
+
+		case tokenNumber:
+			// Subplan numbers are part of the plan structure, not values.
+			if previous := previousToken(tokens, index); previous != nil && (previous.text == "SubPlan" || previous.text == "InitPlan") {
+				sb.WriteString(token.text)
+				continue
+			}
+
+			sb.WriteString(anonymizedLiteral)
+
+		case tokenName:
+			chain := referenceChain(tokens, index)
+			sb.WriteString(a.reference(tokens, index, chain))
+			index += 2*len(chain) - 2
+
+		default:
+			sb.WriteString(token.text)
+		}
+	}
+
+	return sb.String()
+}
+
+// reference anonymizes a name, or a dotted reference of len(chain) names
+// starting at tokens[index].
+func (a *Anonymizer) reference(tokens []expressionToken, index int, chain []string) string {
+	previous := previousToken(tokens, index)
+
+	switch {
+	case previous != nil && previous.text == "::",
+		previous != nil && previous.cast && typeWords[chain[0]] && len(chain) == 1:
+		// A type name, possibly schema-qualified or of several words.
+		tokens[index].cast = true
+
+		var sb strings.Builder
+		for _, token := range tokens[index : index+2*len(chain)-1] {
+			sb.WriteString(token.text)
+		}
+
+		return sb.String()
+	}
+
+	switch len(chain) {
+	case 1:
+		name := chain[0]
+
+		if tokens[index].quoted {
+			return a.columnOrQualifier(name)
+		}
+
+		if next := index + 1; next < len(tokens) && tokens[next].text == "(" || expressionKeywords[name] {
+			// A function name or a keyword.
+			return tokens[index].text
+		}
+
+		return a.columnOrQualifier(name)
+
+	case 2:
+		return a.qualifier(chain[0]) + "." + a.column(chain[1])
+
+	default:
+		// schema.relation.column
+		parts := []string{a.schema(chain[0]), a.qualifier(chain[1]), a.column(chain[2])}
+		for _, name := range chain[3:] {
+			parts = append(parts, a.column(name))
+		}
+
+		return strings.Join(parts, ".")
+	}
+}
+
+// columnOrQualifier anonymizes an unqualified name: a whole-row reference to
+// a known alias, or a column.
+func (a *Anonymizer) columnOrQualifier(name string) string {
+	if placeholder, ok := a.qualifiers[name]; ok {
+		return placeholder
+	}
+
+	return a.column(name)
+}
+
+func (a *Anonymizer) column(name string) string {
+	if name == "*" {
+		return name
+	}
+
+	return a.columns.placeholder(name)
+}
+
+// Restore replaces the placeholders in text, e.g. a plan or advice about an
+// anonymized plan, with the names they stand for.
+func (m *Anonymization) Restore(text string) string {
+	return placeholderName.ReplaceAllStringFunc(text, func(placeholder string) string {
+		for _, names := range []map[string]string{
+			m.Schemas, m.Relations, m.Indexes, m.Columns, m.Aliases, m.CTEs, m.Triggers, m.Constraints,
+		} {
+			if name, ok := names[placeholder]; ok {
+				return name
+			}
+		}
+
+		return placeholder
+	})
+}
+
+type expressionTokenKind int
+
+const (
+	tokenOther expressionTokenKind = iota
+	tokenName
+	tokenString
+	tokenNumber
+)
+
+// expressionToken is a name, literal or punctuation of an expression.
+type expressionToken struct {
+	kind   expressionTokenKind
+	text   string // As printed.
+	name   string // Name without quotes.
+	quoted bool
+	cast   bool // Part of a type name after "::".
+}
+
+// tokenizeExpression splits an expression into names (quoted or not), string
+// and numeric literals, parameters, "::" and single characters.
+func tokenizeExpression(expression string) []expressionToken {
+	runes := []rune(expression)
+	tokens := make([]expressionToken, 0, len(runes)/2)
+
+	for position := 0; position < len(runes); {
+		start := position
+		r := runes[position]
+
+		switch {
+		case r == '\'' || r == '"':
+			position++
+
+			for position < len(runes) {
+				if runes[position] == r {
+					// A doubled quote is an escaped one.
+					if position+1 < len(runes) && runes[position+1] == r {
+						position += 2
+						continue
+					}
+
+					break
+				}
+
+				position++
+			}
+
+			position = min(position+1, len(runes))
+			text := string(runes[start:position])
+
+			if r == '\'' {
+				tokens = append(tokens, expressionToken{kind: tokenString, text: text})
+				continue
+			}
+
+			name := strings.ReplaceAll(strings.Trim(text, `"`), `""`, `"`)
+			tokens = append(tokens, expressionToken{kind: tokenName, text: text, name: name, quoted: true})
+
+		case r == '_' || unicode.IsLetter(r):
+			for position < len(runes) && isNameRune(runes[position]) {
+				position++
+			}
+
+			text := string(runes[start:position])
+			tokens = append(tokens, expressionToken{kind: tokenName, text: text, name: text})
+
+		case r == '$':
+			// Parameters ($1) are kept.
+			position++
+
+			for position < len(runes) && unicode.IsDigit(runes[position]) {
+				position++
+			}
+
+			tokens = append(tokens, expressionToken{kind: tokenOther, text: string(runes[start:position])})
+
+		case unicode.IsDigit(r):
+			for position < len(runes) && (unicode.IsDigit(runes[position]) || runes[position] == '.' ||
+				runes[position] == 'e' || runes[position] == 'E' ||
+				(runes[position] == '-' || runes[position] == '+') && (runes[position-1] == 'e' || runes[position-1] == 'E')) {
+				position++
+			}
+
+			tokens = append(tokens, expressionToken{kind: tokenNumber, text: string(runes[start:position])})
+
+		case r == ':' && position+1 < len(runes) && runes[position+1] == ':':
+			position += 2
+			tokens = append(tokens, expressionToken{kind: tokenOther, text: "::"})
+
+		default:
+			position++
+			tokens = append(tokens, expressionToken{kind: tokenOther, text: string(r)})
+		}
+	}
+
+	return tokens
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// referenceChain returns the names of the dotted reference starting at
+// tokens[index], e.g. ["o", "id"] for "o.id" or ["o", "*"] for "o.*".
+func referenceChain(tokens []expressionToken, index int) []string {
+	chain := []string{tokens[index].name}
+
+	for next := index + 1; next+1 < len(tokens) && tokens[next].text == "."; next += 2 {
+		part := tokens[next+1]
+
+		switch {
+		case part.kind == tokenName:
+			chain = append(chain, part.name)
+
+		case part.text == "*":
+			return append(chain, part.text)
+
+		default:
+			return chain
+		}
+	}
+
+	return chain
+}
+
+// previousToken returns the token before tokens[index], skipping spaces.
+func previousToken(tokens []expressionToken, index int) *expressionToken {
+	for previous := index - 1; previous >= 0; previous-- {
+		if strings.TrimSpace(tokens[previous].text) != "" {
+			return &tokens[previous]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inputJSONAnonymize joins two relations of a custom schema with literals in
+// the conditions, an index scan and a sort.
+const inputJSONAnonymize = `[{
+	"Plan": {
+		"Node Type": "Sort", "Startup Cost": 25.5, "Total Cost": 25.6, "Plan Rows": 10, "Plan Width": 40,
+		"Actual Startup Time": 0.5, "Actual Total Time": 0.6, "Actual Rows": 3, "Actual Loops": 1,
+		"Output": ["o.id", "c.email"],
+		"Sort Key": ["o.created_at DESC"],
+		"Plans": [{
+			"Node Type": "Nested Loop", "Parent Relationship": "Outer", "Join Type": "Inner",
+			"Startup Cost": 0.29, "Total Cost": 25.0, "Plan Rows": 10, "Plan Width": 40,
+			"Actual Startup Time": 0.1, "Actual Total Time": 0.4, "Actual Rows": 3, "Actual Loops": 1,
+			"Output": ["o.id", "c.email", "o.created_at"],
+			"Plans": [
+				{
+					"Node Type": "Seq Scan", "Parent Relationship": "Outer",
+					"Relation Name": "orders", "Schema": "shop", "Alias": "o",
+					"Startup Cost": 0.0, "Total Cost": 20.0, "Plan Rows": 10, "Plan Width": 24,
+					"Actual Startup Time": 0.05, "Actual Total Time": 0.2, "Actual Rows": 3, "Actual Loops": 1,
+					"Output": ["o.id", "o.customer_id", "o.created_at"],
+					"Filter": "(((o.status)::text = 'paid'::text) AND (o.total > 100.50) AND (o.created_at > '2024-01-01 00:00:00'::timestamp without time zone))",
+					"Rows Removed by Filter": 997,
+					"Shared Hit Blocks": 10
+				},
+				{
+					"Node Type": "Index Scan", "Parent Relationship": "Inner", "Scan Direction": "Forward",
+					"Index Name": "customers_pkey", "Relation Name": "customers", "Schema": "shop", "Alias": "c",
+					"Startup Cost": 0.29, "Total Cost": 0.5, "Plan Rows": 1, "Plan Width": 24,
+					"Actual Startup Time": 0.01, "Actual Total Time": 0.01, "Actual Rows": 1, "Actual Loops": 3,
+					"Output": ["c.id", "c.email"],
+					"Index Cond": "(c.id = o.customer_id)",
+					"Filter": "(c.email <> ALL ('{a@example.com,b@example.com}'::text[]))",
+					"Shared Hit Blocks": 9
+				}
+			]
+		}]
+	},
+	"Settings": {"search_path": "shop, public", "work_mem": "64MB"},
+	"Planning Time": 0.2,
+	"Execution Time": 0.7
+}]`
+
+func TestAnonymize(t *testing.T) {
+	explain, err := NewExplain(inputJSONAnonymize)
+	require.NoError(t, err)
+
+	stats := explain.RenderStats()
+	mapping := explain.Anonymize()
+
+	require.Equal(t, ` Sort  (cost=25.50..25.60 rows=10 width=40) (actual time=0.500..0.600 rows=3 loops=1)
+   Sort Key: alias_1.column_3 DESC
+   ->  Nested Loop  (cost=0.29..25.00 rows=10 width=40) (actual time=0.100..0.400 rows=3 loops=1)
+         ->  Seq Scan on schema_1.table_1 alias_1  (cost=0.00..20.00 rows=10 width=24) (actual time=0.050..0.200 rows=3 loops=1)
+               Filter: (((alias_1.column_4)::text = ?::text) AND (alias_1.column_5 > ?) AND (alias_1.column_3 > ?::timestamp without time zone))
+               Rows Removed by Filter: 997
+               Buffers: shared hit=10
+         ->  Index Scan using index_1 on schema_1.table_2 alias_2  (cost=0.29..0.50 rows=1 width=24) (actual time=0.010..0.010 rows=1 loops=3)
+               Index Cond: (alias_2.column_1 = alias_1.column_6)
+               Filter: (alias_2.column_2 <> ALL (?::text[]))
+               Rows Removed by Filter: 0
+               Buffers: shared hit=9
+Settings: search_path = 'schema_1, schema_2', work_mem = '64MB'
+`, explain.RenderPlanText())
+
+	require.Equal(t, &Anonymization{
+		Schemas:   map[string]string{"schema_1": "shop", "schema_2": "public"},
+		Relations: map[string]string{"table_1": "orders", "table_2": "customers"},
+		Indexes:   map[string]string{"index_1": "customers_pkey"},
+		Columns: map[string]string{
+			"column_1": "id", "column_2": "email", "column_3": "created_at", "column_4": "status",
+			"column_5": "total", "column_6": "customer_id",
+		},
+		Aliases: map[string]string{"alias_1": "o", "alias_2": "c"},
+	}, mapping)
+
+	// The numbers are kept, so is everything computed from them.
+	require.Equal(t, strings.NewReplacer("orders", "table_1", "customers", "table_2").Replace(stats), explain.RenderStats())
+}
+
+func TestAnonymizationRestore(t *testing.T) {
+	explain, err := NewExplain(inputJSONAnonymize)
+	require.NoError(t, err)
+
+	mapping := explain.Anonymize()
+
+	require.Equal(t, "Index Scan using customers_pkey on shop.customers c, Filter: (c.email <> ALL (?::text[]))",
+		mapping.Restore("Index Scan using index_1 on schema_1.table_2 alias_2, Filter: (alias_2.column_2 <> ALL (?::text[]))"))
+	require.Equal(t, "table_9 is unknown", mapping.Restore("table_9 is unknown"))
+}
+
+func TestAnonymizerConsistentAcrossPlans(t *testing.T) {
+	anonymizer := NewAnonymizer()
+
+	first, err := NewExplain(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "orders", "Alias": "orders",
+		"Filter": "(orders.status = 'new'::text)"}}]`)
+	require.NoError(t, err)
+
+	second, err := NewExplain(`[{"Plan": {"Node Type": "Index Scan", "Relation Name": "payments", "Alias": "p",
+		"Index Name": "payments_order_id_idx", "Index Cond": "(p.order_id = 42)",
+		"Plans": [{"Node Type": "Seq Scan", "Parent Relationship": "SubPlan", "Subplan Name": "SubPlan 1",
+			"Relation Name": "orders", "Alias": "orders", "Filter": "(orders.status = 'paid'::text)"}]}}]`)
+	require.NoError(t, err)
+
+	anonymizer.Anonymize(first)
+	anonymizer.Anonymize(second)
+
+	require.Equal(t, "table_1", first.Plan.RelationName)
+	require.Equal(t, "table_1", first.Plan.Alias, "an alias repeating the relation name stays omitted")
+	require.Equal(t, "(table_1.column_1 = ?::text)", first.Plan.Filter)
+
+	require.Equal(t, "table_2", second.Plan.RelationName)
+	require.Equal(t, "(alias_1.column_2 = ?)", second.Plan.IndexCondition)
+	require.Equal(t, "(table_1.column_1 = ?::text)", second.Plan.Plans[0].Filter)
+	require.Equal(t, "SubPlan 1", second.Plan.Plans[0].SubplanName)
+
+	mapping := anonymizer.Mapping()
+	require.Equal(t, map[string]string{"table_1": "orders", "table_2": "payments"}, mapping.Relations)
+	require.Equal(t, map[string]string{"index_1": "payments_order_id_idx"}, mapping.Indexes)
+}
+
+func TestAnonymizeCTE(t *testing.T) {
+	explain, err := NewExplain(inputJSONExclusiveCTE)
+	require.NoError(t, err)
+
+	mapping := explain.Anonymize()
+
+	require.Equal(t, "CTE cte_1", explain.Plan.Plans[0].SubplanName)
+	require.Equal(t, "cte_1", explain.Plan.Plans[2].CteName)
+	require.Equal(t, "alias_1", explain.Plan.Plans[2].Alias)
+	require.Equal(t, map[string]string{"cte_1": "c"}, mapping.CTEs)
+	require.Equal(t, map[string]string{"alias_1": "c1", "alias_2": "c2"}, mapping.Aliases)
+}
+
+func TestAnonymizerExpression(t *testing.T) {
+	testCases := []struct {
+		expression string
+		expected   string
+	}{
+		{
+			expression: `(lower((u.email)::text) = 'x@example.com'::text)`,
+			expected:   `(lower((alias_1.column_1)::text) = ?::text)`,
+		},
+		{
+			expression: `("Order Items".qty >= 10)`,
+			expected:   `(alias_2.column_2 >= ?)`,
+		},
+		{
+			expression: `(u.id = $1)`,
+			expected:   `(alias_1.column_3 = $1)`,
+		},
+		{
+			expression: `((hashed SubPlan 2) OR (u.created_at < (now() - '1 day'::interval)))`,
+			expected:   `((hashed SubPlan 2) OR (alias_1.column_4 < (now() - ?::interval)))`,
+		},
+		{
+			expression: `(u.score > 1.5e3)`,
+			expected:   `(alias_1.column_5 > ?)`,
+		},
+		{
+			expression: `(app.users.id IS NOT NULL)`,
+			expected:   `(schema_1.alias_3.column_3 IS NOT NULL)`,
+		},
+		{
+			expression: `ROW(u.*)`,
+			expected:   `ROW(alias_1.*)`,
+		},
+		{
+			expression: `(u.kind = ANY ('{1,2,3}'::integer[]))`,
+			expected:   `(alias_1.column_6 = ANY (?::integer[]))`,
+		},
+		{
+			expression: `((u.doc)::pg_catalog.jsonb ? 'key'::text)`,
+			expected:   `((alias_1.column_7)::pg_catalog.jsonb ? ?::text)`,
+		},
+	}
+
+	anonymizer := NewAnonymizer()
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, anonymizer.expression(tc.expression), tc.expression)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitlab.com/postgres-ai/joe/pkg/util"
+)
+
+// Highlight colors of the hot nodes in diagrams: the slowest node is filled,
+// the costliest one gets a thick orange border and the largest one a dashed
+// border, so a node that is several at once shows all of them.
+const (
+	diagramSlowestFill    = "#ffc9c9"
+	diagramCostliestColor = "#e8590c"
+	diagramDefaultFill    = "#ffffff"
+)
+
+// diagramNode is a plan node laid out for a diagram.
+type diagramNode struct {
+	id     string
+	plan   *Plan
+	parent string
+	edge   string // Label of the edge from the parent.
+}
+
+// RenderMermaid renders the plan tree as a Mermaid flowchart, e.g. for design
+// docs and merge requests. Nodes are labelled with their caption, actual and
+// planned rows and time; the slowest, costliest and largest nodes are highlighted.
+func (ex *Explain) RenderMermaid() string {
+	buf := new(bytes.Buffer)
+	ex.writeMermaid(buf)
+
+	return buf.String()
+}
+
+// RenderDOT renders the plan tree as a Graphviz DOT digraph with the same
+// labels and highlights as RenderMermaid, e.g. for `dot -Tsvg`.
+func (ex *Explain) RenderDOT() string {
+	buf := new(bytes.Buffer)
+	ex.writeDOT(buf)
+
+	return buf.String()
+}
+
+func (ex *Explain) writeMermaid(writer io.Writer) {
+	nodes := ex.diagramNodes()
+	analyzed := ex.Plan.ActualLoops > 0
+
+	_, _ = fmt.Fprintln(writer, "flowchart TD")
+
+	for _, node := range nodes {
+		label := strings.Join(diagramLabel(node.plan, analyzed), "<br/>")
+		_, _ = fmt.Fprintf(writer, "    %s[\"%s\"]\n", node.id, mermaidEscape(label))
+	}
+
+	for _, node := range nodes[1:] {
+		if node.edge == "" {
+			_, _ = fmt.Fprintf(writer, "    %s --> %s\n", node.parent, node.id)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(writer, "    %s -->|\"%s\"| %s\n", node.parent, mermaidEscape(node.edge), node.id)
+	}
+
+	classes := []struct {
+		name  string
+		style string
+		flag  func(plan *Plan) bool
+	}{
+		{"slowest", "fill:" + diagramSlowestFill, func(plan *Plan) bool { return plan.Slowest }},
+		{"costliest", "stroke:" + diagramCostliestColor + ",stroke-width:3px", func(plan *Plan) bool { return plan.Costliest }},
+		{"largest", "stroke-dasharray:5 3,stroke-width:2px", func(plan *Plan) bool { return plan.Largest }},
+	}
+
+	for _, class := range classes {
+		var ids []string
+
+		for _, node := range nodes {
+			if class.flag(node.plan) {
+				ids = append(ids, node.id)
+			}
+		}
+
+		if len(ids) == 0 {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(writer, "    classDef %s %s\n", class.name, class.style)
+		_, _ = fmt.Fprintf(writer, "    class %s %s\n", strings.Join(ids, ","), class.name)
+	}
+}
+
+func (ex *Explain) writeDOT(writer io.Writer) {
+	nodes := ex.diagramNodes()
+	analyzed := ex.Plan.ActualLoops > 0
+
+	_, _ = fmt.Fprintln(writer, "digraph plan {")
+	_, _ = fmt.Fprintln(writer, "    rankdir=TB;")
+	_, _ = fmt.Fprintln(writer, `    node [shape=box, style="rounded,filled", fillcolor="`+diagramDefaultFill+`", fontname="Helvetica"];`)
+	_, _ = fmt.Fprintln(writer, `    edge [fontname="Helvetica", fontsize=10];`)
+
+	for _, node := range nodes {
+		attributes := []string{fmt.Sprintf("label=\"%s\"", dotEscape(strings.Join(diagramLabel(node.plan, analyzed), "\n")))}
+
+		if node.plan.Slowest {
+			attributes = append(attributes, `fillcolor="`+diagramSlowestFill+`"`)
+		}
+
+		if node.plan.Costliest {
+			attributes = append(attributes, `color="`+diagramCostliestColor+`"`, "penwidth=3")
+		}
+
+		if node.plan.Largest {
+			attributes = append(attributes, `style="rounded,filled,dashed"`)
+		}
+
+		_, _ = fmt.Fprintf(writer, "    %s [%s];\n", node.id, strings.Join(attributes, ", "))
+	}
+
+	for _, node := range nodes[1:] {
+		if node.edge == "" {
+			_, _ = fmt.Fprintf(writer, "    %s -> %s;\n", node.parent, node.id)
+			continue
+		}
+
+		_, _ = fmt.Fprintf(writer, "    %s -> %s [label=\"%s\"];\n", node.parent, node.id, dotEscape(node.edge))
+	}
+
+	_, _ = fmt.Fprintln(writer, "}")
+}
+
+// diagramNodes lists the nodes of the plan in depth-first order with ids n1,
+// n2, ... and the edges to their parents.
+func (ex *Explain) diagramNodes() []diagramNode {
+	var nodes []diagramNode
+
+	var add func(plan *Plan, parent string)
+
+	add = func(plan *Plan, parent string) {
+		node := diagramNode{id: fmt.Sprintf("n%d", len(nodes)+1), plan: plan, parent: parent, edge: plan.ParentRelationship}
+		if plan.SubplanName != "" {
+			node.edge = plan.SubplanName
+		}
+
+		nodes = append(nodes, node)
+
+		for index := range plan.Plans {
+			add(&plan.Plans[index], node.id)
+		}
+	}
+
+	add(&ex.Plan, "")
+
+	return nodes
+}
+
+// diagramLabel returns the lines of a node label, e.g.
+//
+//	Seq Scan on public.orders o
+//	rows: 10 actual / 10 planned
+//	time: 35.000 ms (self 35.000 ms)
+//	slowest, largest
+func diagramLabel(plan *Plan, analyzed bool) []string {
+	lines := []string{nodeCaption(plan)}
+
+	switch {
+	case !analyzed:
+		lines = append(lines, fmt.Sprintf("rows: %d planned", plan.PlanRows))
+
+	case plan.ActualLoops == 0:
+		lines = append(lines, fmt.Sprintf("rows: %d planned, never executed", plan.PlanRows))
+
+	default:
+		lines = append(lines,
+			fmt.Sprintf("rows: %s actual / %d planned", formatActualRows(plan.ActualRows), plan.PlanRows),
+			fmt.Sprintf("time: %s (self %s)", util.MillisecondsToString(plan.InclusiveDuration),
+				util.MillisecondsToString(plan.ActualDuration)))
+	}
+
+	var flags []string
+
+	if plan.Slowest {
+		flags = append(flags, "slowest")
+	}
+
+	if plan.Costliest {
+		flags = append(flags, "costliest")
+	}
+
+	if plan.Largest {
+		flags = append(flags, "largest")
+	}
+
+	if len(flags) > 0 {
+		lines = append(lines, strings.Join(flags, ", "))
+	}
+
+	return lines
+}
+
+// mermaidEscape makes text safe inside a quoted Mermaid label, where quotes
+// and HTML-significant characters are written as entity codes.
+var mermaidEscape = strings.NewReplacer(`#`, "#35;", `"`, "#quot;", `<br/>`, "<br/>", `<`, "#lt;", `>`, "#gt;").Replace
+
+// dotEscape makes text safe inside a quoted DOT string.
+var dotEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
@@ -0,0 +1,72 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMermaid(t *testing.T) {
+	explain, err := NewExplain(inputJSONDiffBefore)
+	require.NoError(t, err)
+
+	require.Equal(t, `flowchart TD
+    n1["Hash Join<br/>rows: 10 actual / 10 planned<br/>time: 40.000 ms (self 4.000 ms)"]
+    n2["Seq Scan on public.orders o<br/>rows: 10 actual / 10 planned<br/>time: 35.000 ms (self 35.000 ms)<br/>slowest, costliest"]
+    n3["Hash<br/>rows: 100 actual / 100 planned<br/>time: 1.000 ms (self 0.500 ms)<br/>largest"]
+    n4["Seq Scan on public.customers c<br/>rows: 100 actual / 100 planned<br/>time: 0.500 ms (self 0.500 ms)<br/>largest"]
+    n1 -->|"Outer"| n2
+    n1 -->|"Inner"| n3
+    n3 -->|"Outer"| n4
+    classDef slowest fill:#ffc9c9
+    class n2 slowest
+    classDef costliest stroke:#e8590c,stroke-width:3px
+    class n2 costliest
+    classDef largest stroke-dasharray:5 3,stroke-width:2px
+    class n3,n4 largest
+`, explain.RenderMermaid())
+}
+
+func TestRenderDOT(t *testing.T) {
+	explain, err := NewExplain(inputJSONDiffBefore)
+	require.NoError(t, err)
+
+	require.Equal(t, `digraph plan {
+    rankdir=TB;
+    node [shape=box, style="rounded,filled", fillcolor="#ffffff", fontname="Helvetica"];
+    edge [fontname="Helvetica", fontsize=10];
+    n1 [label="Hash Join\nrows: 10 actual / 10 planned\ntime: 40.000 ms (self 4.000 ms)"];
+    n2 [label="Seq Scan on public.orders o\nrows: 10 actual / 10 planned\ntime: 35.000 ms (self 35.000 ms)\nslowest, costliest", fillcolor="#ffc9c9", color="#e8590c", penwidth=3];
+    n3 [label="Hash\nrows: 100 actual / 100 planned\ntime: 1.000 ms (self 0.500 ms)\nlargest", style="rounded,filled,dashed"];
+    n4 [label="Seq Scan on public.customers c\nrows: 100 actual / 100 planned\ntime: 0.500 ms (self 0.500 ms)\nlargest", style="rounded,filled,dashed"];
+    n1 -> n2 [label="Outer"];
+    n1 -> n3 [label="Inner"];
+    n3 -> n4 [label="Outer"];
+}
+`, explain.RenderDOT())
+}
+
+func TestRenderDiagramEscaping(t *testing.T) {
+	explain, err := NewExplain(`[{"Plan": {"Node Type": "Values Scan", "Alias": "*VALUES*",
+		"Startup Cost": 0.0, "Total Cost": 0.04, "Plan Rows": 3, "Plan Width": 4}}]`)
+	require.NoError(t, err)
+
+	// Without ANALYZE only the planned rows are known.
+	require.Contains(t, explain.RenderMermaid(), `n1["Values Scan on #quot;*VALUES*#quot;<br/>rows: 3 planned`)
+	require.Contains(t, explain.RenderDOT(), `n1 [label="Values Scan on \"*VALUES*\"\nrows: 3 planned`)
+}
+
+func TestRenderDiagramSubplanEdges(t *testing.T) {
+	explain, err := NewExplain(inputJSONExclusiveCTE)
+	require.NoError(t, err)
+
+	out := explain.RenderMermaid()
+
+	require.Contains(t, out, `n1 -->|"CTE c"| n2`)
+	require.Contains(t, out, `n1 -->|"InitPlan 2"| n3`)
+	require.Contains(t, out, `n1 -->|"Member"| n4`)
+}
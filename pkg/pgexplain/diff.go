@@ -0,0 +1,409 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gitlab.com/postgres-ai/joe/pkg/util"
+)
+
+// DiffStatus describes how an aligned plan node changed between two plans.
+type DiffStatus string
+
+// Diff statuses, used as the line marker of a rendered node.
+const (
+	DiffUnchanged DiffStatus = "="
+	DiffChanged   DiffStatus = "~"
+	DiffAdded     DiffStatus = "+"
+	DiffRemoved   DiffStatus = "-"
+)
+
+// NodeDiff is a pair of aligned plan nodes. Before is nil for an added node and
+// After is nil for a removed one.
+type NodeDiff struct {
+	Status   DiffStatus
+	Before   *Plan
+	After    *Plan
+	Changes  []string // Shape changes, e.g. "node type: Seq Scan -> Index Scan".
+	Children []NodeDiff
+}
+
+// PlanDiff is the structural difference between two processed explains.
+type PlanDiff struct {
+	Before *Explain
+	After  *Explain
+	Root   NodeDiff
+}
+
+// DiffExplains aligns the plan trees of two processed explains node by node.
+//
+// Children are aligned by the set of relations their subtrees read, so a scan
+// that switched from Seq Scan to Index Scan, or join inputs that swapped sides,
+// still pair up. A node inserted or removed between two aligned levels (e.g. a
+// new Sort or Memoize) is reported on its own instead of shifting the pairing.
+func DiffExplains(before, after *Explain) *PlanDiff {
+	return &PlanDiff{
+		Before: before,
+		After:  after,
+		Root:   diffNodes(&before.Plan, &after.Plan),
+	}
+}
+
+// HasShapeChanges reports whether any node was added, removed or changed.
+func (d *PlanDiff) HasShapeChanges() bool {
+	return d.Root.hasShapeChanges()
+}
+
+func (nd *NodeDiff) hasShapeChanges() bool {
+	if nd.Status != DiffUnchanged {
+		return true
+	}
+
+	for index := range nd.Children {
+		if nd.Children[index].hasShapeChanges() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Render renders the totals comparison followed by the annotated node tree.
+func (d *PlanDiff) Render() string {
+	buf := new(bytes.Buffer)
+	d.writeDiffText(buf)
+
+	return buf.String()
+}
+
+func diffNodes(before, after *Plan) NodeDiff {
+	// A node wrapped around (or unwrapped from) the counterpart subtree.
+	if nodeTypeCaption(before) != nodeTypeCaption(after) {
+		if isWrapperOf(after, before) {
+			return NodeDiff{Status: DiffAdded, After: after, Children: []NodeDiff{diffNodes(before, &after.Plans[0])}}
+		}
+
+		if isWrapperOf(before, after) {
+			return NodeDiff{Status: DiffRemoved, Before: before, Children: []NodeDiff{diffNodes(&before.Plans[0], after)}}
+		}
+	}
+
+	nd := NodeDiff{
+		Status:  DiffUnchanged,
+		Before:  before,
+		After:   after,
+		Changes: shapeChanges(before, after),
+	}
+
+	if len(nd.Changes) > 0 {
+		nd.Status = DiffChanged
+	}
+
+	nd.Children = diffChildren(before.Plans, after.Plans)
+
+	return nd
+}
+
+// diffChildren pairs child nodes by the longest common subsequence of their
+// relation signatures, then pairs the leftovers in order; whatever remains is
+// reported as added or removed.
+func diffChildren(before, after []Plan) []NodeDiff {
+	pairs := alignBySignature(before, after)
+
+	beforePaired := make([]bool, len(before))
+	afterPaired := make([]bool, len(after))
+
+	for _, pair := range pairs {
+		beforePaired[pair[0]] = true
+		afterPaired[pair[1]] = true
+	}
+
+	var leftBefore, leftAfter []int
+
+	for index := range before {
+		if !beforePaired[index] {
+			leftBefore = append(leftBefore, index)
+		}
+	}
+
+	for index := range after {
+		if !afterPaired[index] {
+			leftAfter = append(leftAfter, index)
+		}
+	}
+
+	for len(leftBefore) > 0 && len(leftAfter) > 0 {
+		pairs = append(pairs, [2]int{leftBefore[0], leftAfter[0]})
+		leftBefore, leftAfter = leftBefore[1:], leftAfter[1:]
+	}
+
+	// Follow the child order of the new plan; unpaired old children go last.
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][1] < pairs[j][1] })
+
+	children := make([]NodeDiff, 0, len(pairs)+len(leftBefore)+len(leftAfter))
+
+	for _, pair := range pairs {
+		children = append(children, diffNodes(&before[pair[0]], &after[pair[1]]))
+	}
+
+	for _, index := range leftAfter {
+		children = append(children, addedNode(&after[index]))
+	}
+
+	for _, index := range leftBefore {
+		children = append(children, removedNode(&before[index]))
+	}
+
+	return children
+}
+
+// alignBySignature returns index pairs of the LCS of two child lists, where
+// two children match when their subtrees read the same relations.
+func alignBySignature(before, after []Plan) [][2]int {
+	beforeSigs := make([]string, len(before))
+	for index := range before {
+		beforeSigs[index] = relationSignature(&before[index])
+	}
+
+	afterSigs := make([]string, len(after))
+	for index := range after {
+		afterSigs[index] = relationSignature(&after[index])
+	}
+
+	lcs := make([][]int, len(before)+1)
+	for index := range lcs {
+		lcs[index] = make([]int, len(after)+1)
+	}
+
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			switch {
+			case beforeSigs[i] != "" && beforeSigs[i] == afterSigs[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var pairs [][2]int
+
+	for i, j := 0, 0; i < len(before) && j < len(after); {
+		switch {
+		case beforeSigs[i] != "" && beforeSigs[i] == afterSigs[j]:
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return pairs
+}
+
+// relationSignature lists, sorted, the relations read in the node's subtree.
+func relationSignature(plan *Plan) string {
+	relations := []string{}
+
+	plan.walk(func(node *Plan) {
+		if name := relationName(node); name != "" {
+			relations = append(relations, name)
+		}
+	})
+
+	sort.Strings(relations)
+
+	return strings.Join(relations, ",")
+}
+
+// relationName returns the schema-qualified relation, CTE or function the node reads.
+func relationName(plan *Plan) string {
+	name := plan.RelationName
+	if name == "" {
+		name = plan.CteName
+	}
+
+	if name == "" {
+		name = plan.FunctionName
+	}
+
+	if name != "" && plan.Schema != "" {
+		name = plan.Schema + "." + name
+	}
+
+	return name
+}
+
+// isWrapperOf reports whether wrapper is a relation-less node with a single
+// child that is the same operation as node, or reads the same relation.
+func isWrapperOf(wrapper, node *Plan) bool {
+	if len(wrapper.Plans) != 1 || relationName(wrapper) != "" {
+		return false
+	}
+
+	child := &wrapper.Plans[0]
+	if nodeCaption(child) == nodeCaption(node) {
+		return true
+	}
+
+	return relationName(node) != "" && relationName(node) == relationName(child)
+}
+
+func shapeChanges(before, after *Plan) []string {
+	var changes []string
+
+	if beforeType, afterType := nodeTypeCaption(before), nodeTypeCaption(after); beforeType != afterType {
+		changes = append(changes, fmt.Sprintf("node type: %s -> %s", beforeType, afterType))
+	}
+
+	if before.IndexName != after.IndexName {
+		changes = append(changes, fmt.Sprintf("index: %s -> %s", orNone(before.IndexName), orNone(after.IndexName)))
+	}
+
+	if beforeRel, afterRel := relationName(before), relationName(after); beforeRel != afterRel {
+		changes = append(changes, fmt.Sprintf("relation: %s -> %s", orNone(beforeRel), orNone(afterRel)))
+	}
+
+	return changes
+}
+
+// nodeTypeCaption returns the node type as the text plan names it, e.g.
+// "Parallel Seq Scan" or "Hash Left Join".
+func nodeTypeCaption(plan *Plan) string {
+	node := *plan
+	node.IndexName = ""
+	node.RelationName = ""
+	node.CteName = ""
+	node.FunctionName = ""
+	node.Alias = ""
+	node.Schema = ""
+
+	if node.NodeType == SubqueryScan || node.NodeType == ValuesScan {
+		return string(node.NodeType)
+	}
+
+	return nodeCaption(&node)
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+
+	return value
+}
+
+func addedNode(plan *Plan) NodeDiff {
+	nd := NodeDiff{Status: DiffAdded, After: plan}
+
+	for index := range plan.Plans {
+		nd.Children = append(nd.Children, addedNode(&plan.Plans[index]))
+	}
+
+	return nd
+}
+
+func removedNode(plan *Plan) NodeDiff {
+	nd := NodeDiff{Status: DiffRemoved, Before: plan}
+
+	for index := range plan.Plans {
+		nd.Children = append(nd.Children, removedNode(&plan.Plans[index]))
+	}
+
+	return nd
+}
+
+func (d *PlanDiff) writeDiffText(writer io.Writer) {
+	_, _ = fmt.Fprintf(writer, "Execution time: %s\n", formatTimeDelta(d.Before.ExecutionTime, d.After.ExecutionTime))
+	_, _ = fmt.Fprintf(writer, "Planning time: %s\n", formatTimeDelta(d.Before.PlanningTime, d.After.PlanningTime))
+	_, _ = fmt.Fprintf(writer, "Total cost: %s\n", formatCostDelta(d.Before.Plan.TotalCost, d.After.Plan.TotalCost))
+	_, _ = fmt.Fprintf(writer, "Shared buffers (hit+read): %s\n",
+		formatCountDelta(float64(sharedBuffers(&d.Before.Plan)), float64(sharedBuffers(&d.After.Plan))))
+	_, _ = fmt.Fprintln(writer)
+
+	writeNodeDiff(writer, &d.Root, "")
+}
+
+func writeNodeDiff(writer io.Writer, nd *NodeDiff, indent string) {
+	switch nd.Status {
+	case DiffAdded:
+		_, _ = fmt.Fprintf(writer, "%s%s %s\n", indent, nd.Status, nodeCaption(nd.After))
+		_, _ = fmt.Fprintf(writer, "%s    %s\n", indent, nodeMetrics(nd.After))
+
+	case DiffRemoved:
+		_, _ = fmt.Fprintf(writer, "%s%s %s\n", indent, nd.Status, nodeCaption(nd.Before))
+		_, _ = fmt.Fprintf(writer, "%s    %s\n", indent, nodeMetrics(nd.Before))
+
+	default:
+		caption := nodeCaption(nd.After)
+		if beforeCaption := nodeCaption(nd.Before); beforeCaption != caption {
+			caption = beforeCaption + " -> " + caption
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s%s %s\n", indent, nd.Status, caption)
+
+		for _, change := range nd.Changes {
+			_, _ = fmt.Fprintf(writer, "%s    %s\n", indent, change)
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s    time: %s  rows: %s  cost: %s  buffers: %s\n", indent,
+			formatTimeDelta(nodeTime(nd.Before), nodeTime(nd.After)),
+			formatCountDelta(nd.Before.totalRows(), nd.After.totalRows()),
+			formatCostDelta(nd.Before.TotalCost, nd.After.TotalCost),
+			formatCountDelta(float64(sharedBuffers(nd.Before)), float64(sharedBuffers(nd.After))))
+	}
+
+	for index := range nd.Children {
+		writeNodeDiff(writer, &nd.Children[index], indent+"  ")
+	}
+}
+
+// nodeMetrics renders one side's metrics for an added or removed node.
+func nodeMetrics(plan *Plan) string {
+	return fmt.Sprintf("time: %s  rows: %s  cost: %.2f  buffers: %d",
+		util.MillisecondsToString(nodeTime(plan)), formatActualRows(plan.totalRows()), plan.TotalCost, sharedBuffers(plan))
+}
+
+// nodeTime returns the node's inclusive time across all loops, in ms.
+func nodeTime(plan *Plan) float64 {
+	return plan.ActualTotalTime * float64(plan.ActualLoops)
+}
+
+// sharedBuffers returns the shared buffers the node's subtree hit or read.
+func sharedBuffers(plan *Plan) uint64 {
+	return plan.SharedHitBlocks + plan.SharedReadBlocks
+}
+
+func formatTimeDelta(before, after float64) string {
+	return fmt.Sprintf("%s -> %s%s", util.MillisecondsToString(before), util.MillisecondsToString(after), percentDelta(before, after))
+}
+
+func formatCostDelta(before, after float64) string {
+	return fmt.Sprintf("%.2f -> %.2f%s", before, after, percentDelta(before, after))
+}
+
+func formatCountDelta(before, after float64) string {
+	return fmt.Sprintf("%s -> %s%s", formatActualRows(before), formatActualRows(after), percentDelta(before, after))
+}
+
+// percentDelta renders the relative change, e.g. " (-42.0%)"; it is empty when
+// nothing changed or there is no base to compare with.
+func percentDelta(before, after float64) string {
+	if before == after || before == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (%+.1f%%)", (after-before)/before*100)
+}
@@ -0,0 +1,153 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inputJSONDiffBefore joins orders to customers with two Seq Scans.
+const inputJSONDiffBefore = `[
+  {
+    "Plan": {
+      "Node Type": "Hash Join", "Join Type": "Inner", "Parallel Aware": false,
+      "Startup Cost": 10.0, "Total Cost": 1000.0, "Plan Rows": 10, "Plan Width": 8,
+      "Actual Startup Time": 1.0, "Actual Total Time": 40.0, "Actual Rows": 10, "Actual Loops": 1,
+      "Hash Cond": "(o.customer_id = c.id)", "Shared Hit Blocks": 100, "Shared Read Blocks": 900,
+      "Plans": [
+        {
+          "Node Type": "Seq Scan", "Parent Relationship": "Outer", "Parallel Aware": false,
+          "Relation Name": "orders", "Schema": "public", "Alias": "o",
+          "Startup Cost": 0.0, "Total Cost": 900.0, "Plan Rows": 10, "Plan Width": 8,
+          "Actual Startup Time": 0.1, "Actual Total Time": 35.0, "Actual Rows": 10, "Actual Loops": 1,
+          "Filter": "(o.status = 'new'::text)", "Rows Removed by Filter": 99990,
+          "Shared Hit Blocks": 90, "Shared Read Blocks": 900
+        },
+        {
+          "Node Type": "Hash", "Parent Relationship": "Inner", "Parallel Aware": false,
+          "Startup Cost": 2.0, "Total Cost": 2.0, "Plan Rows": 100, "Plan Width": 4,
+          "Actual Startup Time": 1.0, "Actual Total Time": 1.0, "Actual Rows": 100, "Actual Loops": 1,
+          "Hash Buckets": 1024, "Hash Batches": 1, "Peak Memory Usage": 12, "Shared Hit Blocks": 10,
+          "Plans": [
+            {
+              "Node Type": "Seq Scan", "Parent Relationship": "Outer", "Parallel Aware": false,
+              "Relation Name": "customers", "Schema": "public", "Alias": "c",
+              "Startup Cost": 0.0, "Total Cost": 2.0, "Plan Rows": 100, "Plan Width": 4,
+              "Actual Startup Time": 0.01, "Actual Total Time": 0.5, "Actual Rows": 100, "Actual Loops": 1,
+              "Shared Hit Blocks": 10
+            }
+          ]
+        }
+      ]
+    },
+    "Planning Time": 0.2, "Triggers": [], "Execution Time": 40.0
+  }
+]`
+
+// inputJSONDiffAfter is the same query after an index on orders.status: the
+// orders scan becomes an Index Scan, the join inputs swap sides and a Sort is
+// added on top.
+const inputJSONDiffAfter = `[
+  {
+    "Plan": {
+      "Node Type": "Sort", "Parallel Aware": false,
+      "Startup Cost": 20.0, "Total Cost": 20.5, "Plan Rows": 10, "Plan Width": 8,
+      "Actual Startup Time": 0.5, "Actual Total Time": 0.5, "Actual Rows": 10, "Actual Loops": 1,
+      "Sort Key": ["o.id"], "Sort Method": "quicksort", "Sort Space Type": "Memory", "Sort Space Used": 25,
+      "Shared Hit Blocks": 14,
+      "Plans": [
+        {
+          "Node Type": "Hash Join", "Join Type": "Inner", "Parent Relationship": "Outer", "Parallel Aware": false,
+          "Startup Cost": 10.0, "Total Cost": 20.0, "Plan Rows": 10, "Plan Width": 8,
+          "Actual Startup Time": 0.2, "Actual Total Time": 0.4, "Actual Rows": 10, "Actual Loops": 1,
+          "Hash Cond": "(c.id = o.customer_id)", "Shared Hit Blocks": 14,
+          "Plans": [
+            {
+              "Node Type": "Seq Scan", "Parent Relationship": "Outer", "Parallel Aware": false,
+              "Relation Name": "customers", "Schema": "public", "Alias": "c",
+              "Startup Cost": 0.0, "Total Cost": 2.0, "Plan Rows": 100, "Plan Width": 4,
+              "Actual Startup Time": 0.01, "Actual Total Time": 0.1, "Actual Rows": 100, "Actual Loops": 1,
+              "Shared Hit Blocks": 10
+            },
+            {
+              "Node Type": "Hash", "Parent Relationship": "Inner", "Parallel Aware": false,
+              "Startup Cost": 8.0, "Total Cost": 8.0, "Plan Rows": 10, "Plan Width": 8,
+              "Actual Startup Time": 0.1, "Actual Total Time": 0.1, "Actual Rows": 10, "Actual Loops": 1,
+              "Hash Buckets": 1024, "Hash Batches": 1, "Peak Memory Usage": 9, "Shared Hit Blocks": 4,
+              "Plans": [
+                {
+                  "Node Type": "Index Scan", "Parent Relationship": "Outer", "Parallel Aware": false,
+                  "Scan Direction": "Forward", "Index Name": "orders_status_idx",
+                  "Relation Name": "orders", "Schema": "public", "Alias": "o",
+                  "Startup Cost": 0.3, "Total Cost": 8.0, "Plan Rows": 10, "Plan Width": 8,
+                  "Actual Startup Time": 0.02, "Actual Total Time": 0.05, "Actual Rows": 10, "Actual Loops": 1,
+                  "Index Cond": "(o.status = 'new'::text)", "Shared Hit Blocks": 4
+                }
+              ]
+            }
+          ]
+        }
+      ]
+    },
+    "Planning Time": 0.3, "Triggers": [], "Execution Time": 0.6
+  }
+]`
+
+func TestDiffExplains(t *testing.T) {
+	before, err := NewExplain(inputJSONDiffBefore)
+	require.NoError(t, err)
+
+	after, err := NewExplain(inputJSONDiffAfter)
+	require.NoError(t, err)
+
+	d := DiffExplains(before, after)
+	require.True(t, d.HasShapeChanges())
+
+	// The Sort is a new wrapper around the unchanged Hash Join.
+	require.Equal(t, DiffAdded, d.Root.Status)
+	require.Len(t, d.Root.Children, 1)
+
+	join := d.Root.Children[0]
+	require.Equal(t, DiffUnchanged, join.Status)
+	require.Len(t, join.Children, 2)
+
+	// The join inputs swapped sides: the customers scan lost its Hash and moved
+	// to the outer side, while the orders scan gained one and changed type.
+	customers := join.Children[0]
+	require.Equal(t, DiffRemoved, customers.Status, "the Hash over customers is gone")
+	require.Len(t, customers.Children, 1)
+	require.Equal(t, DiffUnchanged, customers.Children[0].Status)
+	require.Equal(t, "customers", customers.Children[0].After.RelationName)
+
+	orders := join.Children[1]
+	require.Equal(t, DiffAdded, orders.Status, "the Hash over orders is new")
+	require.Len(t, orders.Children, 1)
+	require.Equal(t, DiffChanged, orders.Children[0].Status)
+	require.Equal(t, "orders_status_idx", orders.Children[0].After.IndexName)
+
+	out := d.Render()
+	require.Contains(t, out, "Execution time: 40.000 ms -> 0.600 ms (-98.5%)")
+	require.Contains(t, out, "Shared buffers (hit+read): 1000 -> 14 (-98.6%)")
+	require.Contains(t, out, "+ Sort\n")
+	require.Contains(t, out, "  = Hash Join\n")
+	require.Contains(t, out, "node type: Seq Scan -> Index Scan")
+	require.Contains(t, out, "index: (none) -> orders_status_idx")
+	require.Contains(t, out, "time: 35.000 ms -> 0.050 ms (-99.9%)")
+}
+
+func TestDiffExplainsIdentical(t *testing.T) {
+	before, err := NewExplain(inputJSONDiffBefore)
+	require.NoError(t, err)
+
+	after, err := NewExplain(inputJSONDiffBefore)
+	require.NoError(t, err)
+
+	d := DiffExplains(before, after)
+	require.False(t, d.HasShapeChanges())
+	require.NotContains(t, d.Render(), "(+")
+	require.NotContains(t, d.Render(), "(-")
+}
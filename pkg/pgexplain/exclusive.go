@@ -0,0 +1,262 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gitlab.com/postgres-ai/joe/pkg/util"
+)
+
+// nodeTypeBreakdownLimit caps the rows of the time-by-node-type table.
+const nodeTypeBreakdownLimit = 10
+
+// calculateExclusives sets the inclusive and exclusive time and the exclusive
+// buffers of every node.
+//
+// EXPLAIN reports inclusive numbers: a node's time and buffers contain those of
+// the nodes below it. The time is a per-loop average, so it is multiplied by
+// the loops; inside a parallel region the loops of all processes add up, so the
+// product is divided by the number of processes to get the time the region
+// actually took. Buffers and I/O time are totals over all loops and processes.
+//
+// InitPlans and SubPlans run within their parent and are subtracted like any
+// other child. A CTE subplan is different: it runs while CTE Scans read it, so
+// its numbers are already inside those scans and not in the node it hangs off.
+// They are subtracted from the CTE Scans instead, split evenly when several
+// scans read the same CTE.
+func (ex *Explain) calculateExclusives() {
+	ex.Plan.calculateInclusive(1)
+
+	ctes := make(map[string]*Plan)
+	cteScans := make(map[string]uint64)
+
+	ex.Plan.walk(func(plan *Plan) {
+		if name, ok := cteSubplanName(plan); ok {
+			ctes[name] = plan
+		}
+
+		if plan.NodeType == CTEScan {
+			cteScans[plan.CteName]++
+		}
+	})
+
+	ex.Plan.walk(func(plan *Plan) {
+		plan.ActualDuration = plan.InclusiveDuration
+		plan.ExclusiveBufferUsage = plan.BufferUsage
+
+		for index := range plan.Plans {
+			child := &plan.Plans[index]
+			if _, ok := cteSubplanName(child); ok {
+				continue
+			}
+
+			plan.ActualDuration -= child.InclusiveDuration
+			plan.ExclusiveBufferUsage.subtract(&child.BufferUsage, 1)
+		}
+
+		if cte, ok := ctes[plan.CteName]; ok && plan.NodeType == CTEScan {
+			plan.ActualDuration -= cte.InclusiveDuration / float64(cteScans[plan.CteName])
+			plan.ExclusiveBufferUsage.subtract(&cte.BufferUsage, cteScans[plan.CteName])
+		}
+
+		if plan.ActualDuration < 0 {
+			plan.ActualDuration = 0
+		}
+	})
+}
+
+// calculateInclusive sets the inclusive time of the node and its descendants;
+// processes is the number of processes running the node.
+func (plan *Plan) calculateInclusive(processes float64) {
+	plan.InclusiveDuration = plan.ActualTotalTime * float64(plan.ActualLoops) / processes
+
+	if plan.NodeType == Gather || plan.NodeType == GatherMerge {
+		processes = plan.parallelProcesses()
+	}
+
+	for index := range plan.Plans {
+		plan.Plans[index].calculateInclusive(processes)
+	}
+}
+
+// parallelProcesses returns the number of processes below a Gather or Gather
+// Merge node: the launched workers plus the leader, unless the leader ran no
+// loop of the parallel part (parallel_leader_participation = off).
+func (plan *Plan) parallelProcesses() float64 {
+	processes := float64(plan.WorkersLaunched) + 1
+
+	for _, child := range plan.Plans {
+		if child.SubplanName == "" && child.ActualLoops > 0 && child.ActualLoops == uint64(plan.WorkersLaunched) {
+			processes--
+		}
+	}
+
+	if processes < 1 {
+		return 1
+	}
+
+	return processes
+}
+
+// cteSubplanName returns the name of the CTE a "CTE name" subplan computes.
+func cteSubplanName(plan *Plan) (string, bool) {
+	return strings.CutPrefix(plan.SubplanName, "CTE ")
+}
+
+// subtract removes the 1/share part of the usage of another node; counters
+// never drop below zero. I/O times are kept only when the node tracked them.
+func (u *BufferUsage) subtract(other *BufferUsage, share uint64) {
+	subtractBlocks := func(blocks *uint64, other uint64) {
+		other /= share
+		if other > *blocks {
+			*blocks = 0
+			return
+		}
+
+		*blocks -= other
+	}
+
+	subtractBlocks(&u.SharedHitBlocks, other.SharedHitBlocks)
+	subtractBlocks(&u.SharedReadBlocks, other.SharedReadBlocks)
+	subtractBlocks(&u.SharedDirtiedBlocks, other.SharedDirtiedBlocks)
+	subtractBlocks(&u.SharedWrittenBlocks, other.SharedWrittenBlocks)
+	subtractBlocks(&u.LocalHitBlocks, other.LocalHitBlocks)
+	subtractBlocks(&u.LocalReadBlocks, other.LocalReadBlocks)
+	subtractBlocks(&u.LocalDirtiedBlocks, other.LocalDirtiedBlocks)
+	subtractBlocks(&u.LocalWrittenBlocks, other.LocalWrittenBlocks)
+	subtractBlocks(&u.TempReadBlocks, other.TempReadBlocks)
+	subtractBlocks(&u.TempWrittenBlocks, other.TempWrittenBlocks)
+
+	subtractTime := func(time **float64, other *float64) {
+		if *time == nil || other == nil {
+			return
+		}
+
+		// Copy, so that the node's own inclusive timing stays untouched.
+		exclusive := **time - *other/float64(share)
+		if exclusive < 0 {
+			exclusive = 0
+		}
+
+		*time = &exclusive
+	}
+
+	subtractTime(&u.IOReadTime, other.IOReadTime)
+	subtractTime(&u.IOWriteTime, other.IOWriteTime)
+}
+
+// nodeTypeShare is a row of the time-by-node-type table.
+type nodeTypeShare struct {
+	label    string
+	duration float64
+	buffers  uint64
+	ioTime   float64
+}
+
+// nodeTypeLabel names the group a node's exclusive numbers are added to, e.g.
+// "Parallel Seq Scan on orders" or "Hash Join".
+func nodeTypeLabel(plan *Plan) string {
+	nodeType := string(plan.NodeType)
+	if plan.ParallelAware {
+		nodeType = "Parallel " + nodeType
+	}
+
+	switch {
+	case plan.RelationName != "":
+		return fmt.Sprintf("%s on %s", nodeType, plan.RelationName)
+
+	case plan.NodeType == BitmapIndexScan && plan.IndexName != "":
+		return fmt.Sprintf("%s on %s", nodeType, plan.IndexName)
+
+	case plan.NodeType == CTEScan && plan.CteName != "":
+		return fmt.Sprintf("%s on %s", nodeType, plan.CteName)
+	}
+
+	return nodeType
+}
+
+// nodeTypeBreakdown sums the exclusive numbers of the nodes by nodeTypeLabel,
+// slowest first.
+func (ex *Explain) nodeTypeBreakdown() (shares []nodeTypeShare, total float64) {
+	indexes := make(map[string]int)
+
+	ex.Plan.walk(func(plan *Plan) {
+		label := nodeTypeLabel(plan)
+
+		index, ok := indexes[label]
+		if !ok {
+			index = len(shares)
+			indexes[label] = index
+			shares = append(shares, nodeTypeShare{label: label})
+		}
+
+		share := &shares[index]
+		share.duration += plan.ActualDuration
+		share.buffers += plan.ExclusiveBufferUsage.SharedHitBlocks + plan.ExclusiveBufferUsage.SharedReadBlocks
+
+		if plan.ExclusiveBufferUsage.IOReadTime != nil {
+			share.ioTime += *plan.ExclusiveBufferUsage.IOReadTime
+		}
+
+		if plan.ExclusiveBufferUsage.IOWriteTime != nil {
+			share.ioTime += *plan.ExclusiveBufferUsage.IOWriteTime
+		}
+
+		total += plan.ActualDuration
+	})
+
+	// Nodes that neither took time nor touched buffers are not worth a row.
+	filtered := shares[:0]
+
+	for _, share := range shares {
+		if share.duration > 0 || share.buffers > 0 {
+			filtered = append(filtered, share)
+		}
+	}
+
+	shares = filtered
+
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].duration > shares[j].duration })
+
+	return shares, total
+}
+
+// writeNodeTypeBreakdown renders where the execution time went, e.g.
+//
+//	Time by node type:
+//	  - 45% in Seq Scan on orders: 5.512 ms, buffers: 990 (~7.73 MiB), I/O: 1.250 ms
+//
+// Nothing is written for plans without timing (EXPLAIN without ANALYZE).
+func (ex *Explain) writeNodeTypeBreakdown(writer io.Writer) {
+	shares, total := ex.nodeTypeBreakdown()
+	if total <= 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(writer, "\nTime by node type:\n")
+
+	for index, share := range shares {
+		if index == nodeTypeBreakdownLimit {
+			_, _ = fmt.Fprintf(writer, "  - ... %d more\n", len(shares)-index)
+			break
+		}
+
+		line := fmt.Sprintf("  - %.0f%% in %s: %s", share.duration/total*100, share.label, util.MillisecondsToString(share.duration))
+
+		if share.buffers > 0 {
+			line += fmt.Sprintf(", buffers: %d (~%s)", share.buffers, blocksToBytes(share.buffers))
+		}
+
+		if share.ioTime > 0 {
+			line += fmt.Sprintf(", I/O: %s", util.MillisecondsToString(share.ioTime))
+		}
+
+		_, _ = fmt.Fprintln(writer, line)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inputJSONExclusiveParallel is a parallel nested loop: the nodes below Gather
+// ran in the leader and two workers, and the inner index scan 100 times per process.
+const inputJSONExclusiveParallel = `[{
+	"Plan": {
+		"Node Type": "Gather", "Workers Planned": 2, "Workers Launched": 2,
+		"Actual Total Time": 10.0, "Actual Rows": 300, "Actual Loops": 1,
+		"Shared Hit Blocks": 700, "Shared Read Blocks": 100, "I/O Read Time": 4.0,
+		"Plans": [{
+			"Node Type": "Nested Loop", "Parent Relationship": "Outer", "Join Type": "Inner",
+			"Actual Total Time": 8.0, "Actual Rows": 100, "Actual Loops": 3,
+			"Shared Hit Blocks": 700, "Shared Read Blocks": 100, "I/O Read Time": 4.0,
+			"Plans": [
+				{
+					"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Parallel Aware": true,
+					"Relation Name": "orders", "Alias": "o",
+					"Actual Total Time": 2.0, "Actual Rows": 100, "Actual Loops": 3,
+					"Shared Hit Blocks": 100, "Shared Read Blocks": 100, "I/O Read Time": 3.0
+				},
+				{
+					"Node Type": "Index Scan", "Parent Relationship": "Inner",
+					"Relation Name": "customers", "Alias": "c", "Index Name": "customers_pkey",
+					"Actual Total Time": 0.01, "Actual Rows": 1, "Actual Loops": 300,
+					"Shared Hit Blocks": 600, "I/O Read Time": 0.0
+				}
+			]
+		}]
+	},
+	"Execution Time": 10.5
+}]`
+
+// inputJSONExclusiveCTE reads a CTE twice and evaluates an InitPlan.
+const inputJSONExclusiveCTE = `[{
+	"Plan": {
+		"Node Type": "Append",
+		"Actual Total Time": 10.0, "Actual Rows": 30, "Actual Loops": 1,
+		"Shared Hit Blocks": 100,
+		"Plans": [
+			{
+				"Node Type": "Seq Scan", "Parent Relationship": "InitPlan", "Subplan Name": "CTE c",
+				"Relation Name": "a", "Alias": "a",
+				"Actual Total Time": 4.0, "Actual Rows": 20, "Actual Loops": 1,
+				"Shared Hit Blocks": 40
+			},
+			{
+				"Node Type": "Result", "Parent Relationship": "InitPlan", "Subplan Name": "InitPlan 2",
+				"Actual Total Time": 0.5, "Actual Rows": 1, "Actual Loops": 1
+			},
+			{
+				"Node Type": "CTE Scan", "Parent Relationship": "Member", "CTE Name": "c", "Alias": "c1",
+				"Actual Total Time": 5.0, "Actual Rows": 20, "Actual Loops": 1,
+				"Shared Hit Blocks": 40
+			},
+			{
+				"Node Type": "CTE Scan", "Parent Relationship": "Member", "CTE Name": "c", "Alias": "c2",
+				"Actual Total Time": 3.0, "Actual Rows": 10, "Actual Loops": 1,
+				"Shared Hit Blocks": 20
+			}
+		]
+	},
+	"Execution Time": 10.1
+}]`
+
+func TestCalculateExclusivesParallel(t *testing.T) {
+	explain, err := NewExplain(inputJSONExclusiveParallel)
+	require.NoError(t, err)
+
+	gather := explain.Plan
+	loop := gather.Plans[0]
+	scan, index := loop.Plans[0], loop.Plans[1]
+
+	// Per-process averages: 0.01 ms × 300 loops over 3 processes is 1 ms.
+	require.InDelta(t, 8.0, loop.InclusiveDuration, 1e-9)
+	require.InDelta(t, 1.0, index.InclusiveDuration, 1e-9)
+
+	require.InDelta(t, 2.0, gather.ActualDuration, 1e-9)
+	require.InDelta(t, 5.0, loop.ActualDuration, 1e-9)
+	require.InDelta(t, 2.0, scan.ActualDuration, 1e-9)
+	require.InDelta(t, 1.0, index.ActualDuration, 1e-9)
+
+	require.Equal(t, uint64(0), loop.ExclusiveBufferUsage.SharedHitBlocks)
+	require.Equal(t, uint64(600), index.ExclusiveBufferUsage.SharedHitBlocks)
+	require.InDelta(t, 1.0, *loop.ExclusiveBufferUsage.IOReadTime, 1e-9)
+	require.Equal(t, 4.0, *loop.IOReadTime, "the reported timing stays inclusive")
+
+	require.True(t, loop.Slowest)
+}
+
+func TestCalculateExclusivesLeaderNotParticipating(t *testing.T) {
+	explain, err := NewExplain(`[{"Plan": {
+		"Node Type": "Gather", "Workers Launched": 2, "Actual Total Time": 5.0, "Actual Loops": 1,
+		"Plans": [{"Node Type": "Seq Scan", "Parallel Aware": true, "Relation Name": "t",
+			"Actual Total Time": 4.0, "Actual Loops": 2}]
+	}}]`)
+	require.NoError(t, err)
+
+	require.InDelta(t, 4.0, explain.Plan.Plans[0].InclusiveDuration, 1e-9)
+	require.InDelta(t, 1.0, explain.Plan.ActualDuration, 1e-9)
+}
+
+func TestCalculateExclusivesCTE(t *testing.T) {
+	explain, err := NewExplain(inputJSONExclusiveCTE)
+	require.NoError(t, err)
+
+	appendNode := explain.Plan
+	cte, initPlan, first, second := appendNode.Plans[0], appendNode.Plans[1], appendNode.Plans[2], appendNode.Plans[3]
+
+	// The CTE runs inside its scans, not in the Append it hangs off.
+	require.InDelta(t, 1.5, appendNode.ActualDuration, 1e-9)
+	require.InDelta(t, 4.0, cte.ActualDuration, 1e-9)
+	require.InDelta(t, 0.5, initPlan.ActualDuration, 1e-9)
+	require.InDelta(t, 3.0, first.ActualDuration, 1e-9)
+	require.InDelta(t, 1.0, second.ActualDuration, 1e-9)
+
+	require.Equal(t, uint64(40), appendNode.ExclusiveBufferUsage.SharedHitBlocks)
+	require.Equal(t, uint64(20), first.ExclusiveBufferUsage.SharedHitBlocks)
+	require.Equal(t, uint64(0), second.ExclusiveBufferUsage.SharedHitBlocks)
+}
+
+func TestRenderStatsNodeTypeBreakdown(t *testing.T) {
+	explain, err := NewExplain(inputJSONExclusiveParallel)
+	require.NoError(t, err)
+
+	require.Contains(t, explain.RenderStats(), `
+Time by node type:
+  - 50% in Nested Loop: 5.000 ms, I/O: 1.000 ms
+  - 20% in Gather: 2.000 ms
+  - 20% in Parallel Seq Scan on orders: 2.000 ms, buffers: 200 (~1.60 MiB), I/O: 3.000 ms
+  - 10% in Index Scan on customers: 1.000 ms, buffers: 600 (~4.70 MiB)
+`)
+
+	withoutAnalyze, err := NewExplain(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "t", "Total Cost": 1.0}}]`)
+	require.NoError(t, err)
+	require.NotContains(t, withoutAnalyze.RenderStats(), "Time by node type")
+}
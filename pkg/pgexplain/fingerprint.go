@@ -0,0 +1,155 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// fingerprintLength is the number of hex characters of a plan fingerprint.
+const fingerprintLength = 16
+
+var (
+	// conditionStringLiteral matches quoted literals, including escaped quotes
+	// and array literals such as '{1,2,3}'.
+	conditionStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+	// conditionNumber matches numeric literals that are not part of an identifier.
+	conditionNumber = regexp.MustCompile(`\b\d+(?:\.\d+)?(?:[eE][-+]?\d+)?\b`)
+
+	// conditionList matches a list of placeholders, e.g. "?, ?, ?" of an IN list
+	// or an ARRAY constructor, so that its length does not matter.
+	conditionList = regexp.MustCompile(`\?(?:\s*,\s*\?)+`)
+
+	// subplanNumber matches the number of a subplan reference in a condition,
+	// which depends on the order the planner happened to create subplans in.
+	subplanNumber = regexp.MustCompile(`\b(InitPlan|SubPlan) \d+\b`)
+)
+
+// NormalizeCondition strips the literal values from a condition or expression
+// as printed in a plan, so that the same condition with other values compares
+// equal:
+//
+//	((status = 'new'::text) AND (id = ANY ('{1,2,3}'::integer[])))
+//
+// becomes
+//
+//	((status = ?::text) AND (id = ANY (?::integer[])))
+func NormalizeCondition(condition string) string {
+	normalized := subplanNumber.ReplaceAllString(condition, "$1")
+	normalized = conditionStringLiteral.ReplaceAllString(normalized, "?")
+	normalized = conditionNumber.ReplaceAllString(normalized, "?")
+	normalized = conditionList.ReplaceAllString(normalized, "?")
+
+	return normalized
+}
+
+// Shape renders the shape of the plan: one line per node with its type, join
+// type, strategy, relation, index and normalized conditions, indented by
+// depth. Costs, timings, row counts and literal values are left out, so two
+// runs of a query with the same plan produce the same shape.
+func (ex *Explain) Shape() string {
+	var sb strings.Builder
+
+	writePlanShape(&sb, &ex.Plan, 0)
+
+	return sb.String()
+}
+
+// Fingerprint returns a stable hash of the plan shape (see Shape). Plans with
+// equal fingerprints are the same plan, e.g. a query whose fingerprint changes
+// between two runs has flipped to another plan.
+func (ex *Explain) Fingerprint() string {
+	sum := sha256.Sum256([]byte(ex.Shape()))
+
+	return hex.EncodeToString(sum[:])[:fingerprintLength]
+}
+
+func writePlanShape(sb *strings.Builder, plan *Plan, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	sb.WriteString(nodeShape(plan))
+	sb.WriteString("\n")
+
+	for index := range plan.Plans {
+		writePlanShape(sb, &plan.Plans[index], depth+1)
+	}
+}
+
+// nodeShape renders the shape line of a single node.
+func nodeShape(plan *Plan) string {
+	parts := make([]string, 0, 8)
+
+	if name, ok := cteSubplanName(plan); ok {
+		parts = append(parts, "CTE "+name+":")
+	} else if plan.ParentRelationship != "" {
+		parts = append(parts, plan.ParentRelationship+":")
+	}
+
+	if plan.ParallelAware {
+		parts = append(parts, "Parallel")
+	}
+
+	parts = append(parts, string(plan.NodeType))
+
+	for _, detail := range []string{plan.Strategy, plan.PartialMode, plan.Operation, plan.JoinType, plan.ScanDirection} {
+		if detail != "" && detail != "Simple" {
+			parts = append(parts, detail)
+		}
+	}
+
+	if plan.IndexName != "" {
+		parts = append(parts, "using", plan.IndexName)
+	}
+
+	if target := shapeTarget(plan); target != "" {
+		parts = append(parts, "on", target)
+	}
+
+	conditions := []struct {
+		name  string
+		value string
+	}{
+		{"Index Cond", plan.IndexCondition},
+		{"Recheck Cond", plan.RecheckCond},
+		{"Hash Cond", plan.HashCondition},
+		{"Merge Cond", plan.MergeCondition},
+		{"Join Filter", plan.JoinFilter},
+		{"Filter", plan.Filter},
+		{"Sort Key", strings.Join(plan.SortKey, ", ")},
+		{"Group Key", strings.Join(plan.GroupKey, ", ")},
+	}
+
+	for _, condition := range conditions {
+		if condition.value != "" {
+			parts = append(parts, fmt.Sprintf("[%s: %s]", condition.name, NormalizeCondition(condition.value)))
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// shapeTarget names what the node reads: a schema-qualified relation, a CTE
+// or a function.
+func shapeTarget(plan *Plan) string {
+	switch {
+	case plan.RelationName != "" && plan.Schema != "":
+		return plan.Schema + "." + plan.RelationName
+
+	case plan.RelationName != "":
+		return plan.RelationName
+
+	case plan.CteName != "":
+		return plan.CteName
+
+	case plan.FunctionName != "":
+		return plan.FunctionName
+	}
+
+	return ""
+}
@@ -0,0 +1,89 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCondition(t *testing.T) {
+	testCases := []struct {
+		condition string
+		expected  string
+	}{
+		{
+			condition: "((status = 'new'::text) AND (id = ANY ('{1,2,3}'::integer[])))",
+			expected:  "((status = ?::text) AND (id = ANY (?::integer[])))",
+		},
+		{
+			condition: "((t1.val > 5) AND (t1.price < 10.25) AND (t1.note <> 'it''s'::text))",
+			expected:  "((t1.val > ?) AND (t1.price < ?) AND (t1.note <> ?::text))",
+		},
+		{
+			condition: "(id = ANY (ARRAY[1, 2, 3, 4]))",
+			expected:  "(id = ANY (ARRAY[?]))",
+		},
+		{
+			condition: "(o.id > $0) AND (hashed SubPlan 2)",
+			expected:  "(o.id > $?) AND (hashed SubPlan)",
+		},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, NormalizeCondition(tc.condition))
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	const (
+		planA = `[{"Plan": {"Node Type": "Hash Join", "Join Type": "Inner", "Total Cost": 100.5,
+			"Actual Total Time": 12.5, "Actual Loops": 1, "Hash Cond": "(o.customer_id = c.id)",
+			"Plans": [
+				{"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Relation Name": "orders", "Schema": "public",
+					"Alias": "o", "Filter": "(o.status = 'new'::text)", "Total Cost": 80.0, "Actual Total Time": 9.0},
+				{"Node Type": "Hash", "Parent Relationship": "Inner", "Total Cost": 10.0, "Plans": [
+					{"Node Type": "Index Scan", "Parent Relationship": "Outer", "Relation Name": "customers",
+						"Schema": "public", "Alias": "c", "Index Name": "customers_pkey", "Scan Direction": "Forward",
+						"Index Cond": "(c.id < 1000)"}
+				]}
+			]}}]`
+		// The same plan with other literals, costs and timings.
+		planB = `[{"Plan": {"Node Type": "Hash Join", "Join Type": "Inner", "Total Cost": 98.0,
+			"Actual Total Time": 3.1, "Actual Loops": 1, "Hash Cond": "(o.customer_id = c.id)",
+			"Plans": [
+				{"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Relation Name": "orders", "Schema": "public",
+					"Alias": "o", "Filter": "(o.status = 'shipped'::text)", "Total Cost": 81.0, "Actual Total Time": 2.0},
+				{"Node Type": "Hash", "Parent Relationship": "Inner", "Total Cost": 11.0, "Plans": [
+					{"Node Type": "Index Scan", "Parent Relationship": "Outer", "Relation Name": "customers",
+						"Schema": "public", "Alias": "c", "Index Name": "customers_pkey", "Scan Direction": "Forward",
+						"Index Cond": "(c.id < 50)"}
+				]}
+			]}}]`
+	)
+
+	explainA, err := NewExplain(planA)
+	require.NoError(t, err)
+
+	explainB, err := NewExplain(planB)
+	require.NoError(t, err)
+
+	require.Equal(t, `Hash Join Inner [Hash Cond: (o.customer_id = c.id)]
+  Outer: Seq Scan on public.orders [Filter: (o.status = ?::text)]
+  Inner: Hash
+    Outer: Index Scan Forward using customers_pkey on public.customers [Index Cond: (c.id < ?)]
+`, explainA.Shape())
+
+	require.Len(t, explainA.Fingerprint(), fingerprintLength)
+	require.Equal(t, explainA.Fingerprint(), explainB.Fingerprint())
+
+	// A plan flip: the orders scan turns into an index scan.
+	flipped, err := NewExplain(strings.Replace(planB, `"Node Type": "Seq Scan"`,
+		`"Node Type": "Index Scan", "Index Name": "orders_status_idx"`, 1))
+	require.NoError(t, err)
+	require.NotEqual(t, explainA.Fingerprint(), flipped.Fingerprint())
+}
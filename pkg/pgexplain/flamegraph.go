@@ -0,0 +1,268 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+
+	"gitlab.com/postgres-ai/joe/pkg/util"
+)
+
+// Flame graph geometry, in SVG user units.
+const (
+	flameWidth      = 1200
+	flameRowHeight  = 22
+	flameCharWidth  = 7  // Approximate width of a label character at the chosen font size.
+	flameMinLabelPx = 30 // Narrower frames are drawn without a label.
+)
+
+// flameFrame is one rectangle of an icicle graph.
+type flameFrame struct {
+	X, Y, Width, Height float64
+	Fill                string
+	Class               string
+	Label               string
+	Title               string
+}
+
+// flameGraph is one icicle graph of the page, sized by a single metric.
+type flameGraph struct {
+	Title  string
+	Note   string
+	Height float64
+	Frames []flameFrame
+}
+
+// flameMetric gives a node's exclusive (self) weight in one dimension.
+type flameMetric struct {
+	title  string
+	weight func(plan *Plan) float64
+	format func(value float64) string
+}
+
+var flameMetrics = []flameMetric{
+	{
+		title:  "Exclusive time",
+		weight: func(plan *Plan) float64 { return math.Max(plan.ActualDuration, 0) },
+		format: util.MillisecondsToString,
+	},
+	{
+		title:  "Exclusive shared buffers (hit+read)",
+		weight: func(plan *Plan) float64 { return float64(exclusiveSharedBuffers(plan)) },
+		format: func(value float64) string { return fmt.Sprintf("%.0f (~%s)", value, blocksToBytes(uint64(value))) },
+	},
+}
+
+// RenderFlameGraphHTML renders a self-contained HTML page with icicle graphs of
+// the exclusive time and exclusive shared buffers of every plan node. A frame's
+// width is the node's share of the metric including its descendants, so the
+// widest frames at the bottom are where the work happens. Nodes flagged as the
+// slowest, costliest or largest are outlined.
+func (ex *Explain) RenderFlameGraphHTML() string {
+	buf := new(bytes.Buffer)
+	ex.writeFlameGraphHTML(buf)
+
+	return buf.String()
+}
+
+func (ex *Explain) writeFlameGraphHTML(writer io.Writer) {
+	graphs := make([]flameGraph, 0, len(flameMetrics))
+
+	for _, metric := range flameMetrics {
+		graphs = append(graphs, buildFlameGraph(&ex.Plan, metric))
+	}
+
+	data := struct {
+		Width  int
+		Time   string
+		Graphs []flameGraph
+	}{
+		Width:  flameWidth,
+		Time:   util.MillisecondsToString(ex.TotalTime),
+		Graphs: graphs,
+	}
+
+	if err := flameGraphTemplate.Execute(writer, data); err != nil {
+		_, _ = fmt.Fprintf(writer, "<!-- failed to render flame graph: %s -->\n", template.HTMLEscapeString(err.Error()))
+	}
+}
+
+func buildFlameGraph(root *Plan, metric flameMetric) flameGraph {
+	graph := flameGraph{Title: metric.title}
+
+	total := subtreeWeight(root, metric.weight)
+	if total == 0 {
+		graph.Note = "No data: the plan carries no values for this metric (EXPLAIN without ANALYZE or BUFFERS?)."
+		return graph
+	}
+
+	depth := addFlameFrames(&graph, root, metric, total, 0, 0, flameWidth)
+	graph.Height = float64(depth) * flameRowHeight
+
+	return graph
+}
+
+// addFlameFrames lays out the node and its descendants starting at x and
+// returns the depth of the deepest row it drew.
+func addFlameFrames(graph *flameGraph, plan *Plan, metric flameMetric, total float64, depth int, x, width float64) int {
+	self := metric.weight(plan)
+	caption := nodeCaption(plan)
+
+	graph.Frames = append(graph.Frames, flameFrame{
+		X:      x,
+		Y:      float64(depth) * flameRowHeight,
+		Width:  width,
+		Height: flameRowHeight - 1,
+		Fill:   flameColor(self / total),
+		Class:  flameClass(plan),
+		Label:  flameLabel(caption, width),
+		Title: fmt.Sprintf("%s\nself: %s (%.1f%%)\nwith children: %s (%.1f%%)%s", caption,
+			metric.format(self), self/total*100,
+			metric.format(subtreeWeight(plan, metric.weight)), subtreeWeight(plan, metric.weight)/total*100,
+			flameFlags(plan)),
+	})
+
+	maxDepth := depth + 1
+	childX := x
+
+	for index := range plan.Plans {
+		child := &plan.Plans[index]
+
+		childWidth := subtreeWeight(child, metric.weight) / total * flameWidth
+		if childWidth <= 0 {
+			continue
+		}
+
+		if childDepth := addFlameFrames(graph, child, metric, total, depth+1, childX, childWidth); childDepth > maxDepth {
+			maxDepth = childDepth
+		}
+
+		childX += childWidth
+	}
+
+	return maxDepth
+}
+
+// subtreeWeight sums the metric over the node and all its descendants.
+func subtreeWeight(plan *Plan, weight func(plan *Plan) float64) float64 {
+	total := 0.0
+
+	plan.walk(func(node *Plan) {
+		total += weight(node)
+	})
+
+	return total
+}
+
+// exclusiveSharedBuffers returns the shared buffers the node hit or read itself;
+// EXPLAIN reports buffers including those of the node's children.
+func exclusiveSharedBuffers(plan *Plan) uint64 {
+	return plan.ExclusiveBufferUsage.SharedHitBlocks + plan.ExclusiveBufferUsage.SharedReadBlocks
+}
+
+// flameColor maps a node's share of the total to a yellow-to-red heat color.
+func flameColor(share float64) string {
+	const (
+		coldHue = 55
+		hotHue  = 0
+	)
+
+	share = math.Min(math.Max(share, 0), 1)
+	hue := coldHue - (coldHue-hotHue)*math.Sqrt(share)
+
+	return fmt.Sprintf("hsl(%.0f, 90%%, %.0f%%)", hue, 75-25*share)
+}
+
+func flameClass(plan *Plan) string {
+	var classes []string
+
+	if plan.Slowest {
+		classes = append(classes, "slowest")
+	}
+
+	if plan.Costliest {
+		classes = append(classes, "costliest")
+	}
+
+	if plan.Largest {
+		classes = append(classes, "largest")
+	}
+
+	return strings.Join(classes, " ")
+}
+
+func flameFlags(plan *Plan) string {
+	flags := ""
+
+	if plan.Slowest {
+		flags += "\nslowest node"
+	}
+
+	if plan.Costliest {
+		flags += "\ncostliest node"
+	}
+
+	if plan.Largest {
+		flags += "\nlargest node (rows)"
+	}
+
+	return flags
+}
+
+// flameLabel fits the caption into a frame of the given width.
+func flameLabel(caption string, width float64) string {
+	if width < flameMinLabelPx {
+		return ""
+	}
+
+	maxChars := int(width/flameCharWidth) - 1
+	if len(caption) <= maxChars {
+		return caption
+	}
+
+	if maxChars < 3 {
+		return ""
+	}
+
+	return caption[:maxChars-2] + ".."
+}
+
+var flameGraphTemplate = template.Must(template.New("flamegraph").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Query plan flame graph</title>
+<style>
+  body { font-family: sans-serif; margin: 16px; }
+  h2 { font-size: 16px; margin: 24px 0 8px; }
+  svg text { font-family: monospace; font-size: 11px; pointer-events: none; }
+  rect { stroke: #fff; stroke-width: 0.5; }
+  rect.slowest, rect.costliest, rect.largest { stroke: #000; stroke-width: 1.5; }
+  rect.slowest { stroke-width: 2.5; }
+  .legend { font-size: 12px; color: #555; }
+</style>
+</head>
+<body>
+<div class="legend">Total time: {{.Time}}. Frame width is the share of the metric including children; color is the node's own (exclusive) share. Outlined frames are the slowest, costliest or largest nodes. Hover for details.</div>
+{{- range .Graphs}}
+<h2>{{.Title}}</h2>
+{{- if .Note}}
+<p class="legend">{{.Note}}</p>
+{{- else}}
+<svg xmlns="http://www.w3.org/2000/svg" width="{{$.Width}}" height="{{.Height}}" viewBox="0 0 {{$.Width}} {{.Height}}">
+{{- range .Frames}}
+<g><title>{{.Title}}</title><rect x="{{printf "%.2f" .X}}" y="{{.Y}}" width="{{printf "%.2f" .Width}}" height="{{.Height}}" fill="{{.Fill}}"{{if .Class}} class="{{.Class}}"{{end}}/>{{if .Label}}<text x="{{printf "%.2f" .X}}" dx="3" y="{{.Y}}" dy="15">{{.Label}}</text>{{end}}</g>
+{{- end}}
+</svg>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
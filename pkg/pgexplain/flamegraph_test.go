@@ -0,0 +1,51 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderFlameGraphHTML(t *testing.T) {
+	explain, err := NewExplain(inputJSONDiffBefore)
+	require.NoError(t, err)
+
+	out := explain.RenderFlameGraphHTML()
+
+	require.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	require.Equal(t, 2, strings.Count(out, "<svg"), "one graph for time and one for buffers")
+	require.NotContains(t, out, "<script", "the page must stay self-contained and static")
+	require.Contains(t, out, "Seq Scan on public.orders o")
+	require.Contains(t, out, `class="slowest`)
+
+	// The orders scan spends 35 of 40 ms by itself.
+	require.Contains(t, out, "self: 35.000 ms (87.5%)")
+	// Quotes in filter literals must be escaped, not break the markup.
+	require.NotContains(t, out, "'new'")
+}
+
+func TestRenderFlameGraphHTMLWithoutAnalyze(t *testing.T) {
+	explain, err := NewExplain(`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "t", "Alias": "t",
+		"Startup Cost": 0.0, "Total Cost": 9.25, "Plan Rows": 200, "Plan Width": 8}}]`)
+	require.NoError(t, err)
+
+	out := explain.RenderFlameGraphHTML()
+
+	require.NotContains(t, out, "<svg")
+	require.Contains(t, out, "No data")
+}
+
+func TestExclusiveSharedBuffers(t *testing.T) {
+	explain, err := NewExplain(inputJSONDiffBefore)
+	require.NoError(t, err)
+
+	// Hash Join: 1000 in total, 990 in the orders scan and 10 under the Hash.
+	require.Equal(t, uint64(0), exclusiveSharedBuffers(&explain.Plan))
+	require.Equal(t, uint64(990), exclusiveSharedBuffers(&explain.Plan.Plans[0]))
+	require.Equal(t, uint64(0), exclusiveSharedBuffers(&explain.Plan.Plans[1]))
+}
@@ -0,0 +1,376 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the output format of an EXPLAIN document.
+type Format string
+
+// Supported EXPLAIN formats.
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatXML  Format = "xml"
+	FormatText Format = "text"
+)
+
+// Formats lists the supported EXPLAIN formats.
+var Formats = []Format{FormatJSON, FormatYAML, FormatXML, FormatText}
+
+// yamlNodeType matches a property only YAML plans carry; the text format
+// never prints node types as "Node Type: ...".
+var yamlNodeType = regexp.MustCompile(`(?m)^\s*(- )?Node Type: `)
+
+// DetectFormat guesses the format of an EXPLAIN document: JSON plans are arrays
+// or objects, XML plans are elements, YAML plans carry "Node Type:" properties
+// and anything else is treated as PostgreSQL's text format.
+func DetectFormat(input string) Format {
+	trimmed := strings.TrimSpace(input)
+
+	switch {
+	case strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{"):
+		return FormatJSON
+
+	case strings.HasPrefix(trimmed, "<"):
+		return FormatXML
+
+	case yamlNodeType.MatchString(trimmed):
+		return FormatYAML
+	}
+
+	return FormatText
+}
+
+// ParseExplain processes an EXPLAIN document of the given format. Documents
+// with several plans yield the first one; see ParseExplains.
+func ParseExplain(input string, format Format) (*Explain, error) {
+	explains, err := ParseExplains(input, format)
+	if err != nil {
+		return nil, err
+	}
+
+	return explains[0], nil
+}
+
+// ParseExplains processes every plan of an EXPLAIN document of the given format.
+func ParseExplains(input string, format Format) ([]*Explain, error) {
+	switch format {
+	case FormatJSON:
+		return newExplainsFromJSON(input)
+
+	case FormatYAML:
+		explainJSON, err := yamlToJSON(input)
+		if err != nil {
+			return nil, err
+		}
+
+		return newExplainsFromJSON(explainJSON)
+
+	case FormatXML:
+		explainJSON, err := xmlToJSON(input)
+		if err != nil {
+			return nil, err
+		}
+
+		return newExplainsFromJSON(explainJSON)
+
+	case FormatText:
+		return newExplainsFromText(input)
+	}
+
+	return nil, fmt.Errorf("unsupported EXPLAIN format %q", format)
+}
+
+// yamlToJSON converts EXPLAIN (FORMAT YAML) documents to a JSON list of plans.
+// PostgreSQL quotes every string property in YAML, so scalars keep their types.
+func yamlToJSON(explainYAML string) (string, error) {
+	queries := make([]interface{}, 0, 1)
+	decoder := yaml.NewDecoder(strings.NewReader(explainYAML))
+
+	for {
+		var document interface{}
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		switch document := document.(type) {
+		case []interface{}:
+			queries = append(queries, document...)
+
+		case map[string]interface{}:
+			// auto_explain logs a single mapping rather than a list of them.
+			queries = append(queries, document)
+		}
+	}
+
+	explainJSON, err := json.Marshal(queries)
+	if err != nil {
+		return "", err
+	}
+
+	return string(explainJSON), nil
+}
+
+// xmlElement is an element of an EXPLAIN (FORMAT XML) document.
+type xmlElement struct {
+	name     string
+	text     string
+	children []*xmlElement
+}
+
+// explainProperties maps every struct type joe decodes an EXPLAIN document
+// into to the properties of that type and their value types; it is collected
+// from the JSON tags of Explain and the types it refers to. XML carries no
+// types, so values are converted by these; the same property name may have
+// different types in different objects ("Inlining" is a flag among the JIT
+// options and a duration in the JIT timing).
+var explainProperties = collectExplainProperties(reflect.TypeOf(Explain{}), make(map[reflect.Type]map[string]reflect.Type))
+
+// xmlProperties maps XML element names back to the property names.
+var xmlProperties = func() map[string]string {
+	properties := make(map[string]string)
+
+	for _, fields := range explainProperties {
+		for name := range fields {
+			properties[xmlTagName(name)] = name
+		}
+	}
+
+	return properties
+}()
+
+func collectExplainProperties(t reflect.Type, properties map[reflect.Type]map[string]reflect.Type) map[reflect.Type]map[string]reflect.Type {
+	if _, ok := properties[t]; ok {
+		return properties
+	}
+
+	fields := make(map[string]reflect.Type)
+	properties[t] = fields
+
+	collectExplainFields(t, fields, properties)
+
+	return properties
+}
+
+// collectExplainFields adds the properties of t to fields, including those of
+// embedded structs, and collects the struct types they refer to.
+func collectExplainFields(t reflect.Type, fields map[string]reflect.Type, properties map[reflect.Type]map[string]reflect.Type) {
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			collectExplainFields(field.Type, fields, properties)
+			continue
+		}
+
+		if name == "" || name == "-" {
+			continue
+		}
+
+		fieldType := dereferenceType(field.Type)
+		fields[name] = fieldType
+
+		if fieldType.Kind() == reflect.Slice {
+			fieldType = dereferenceType(fieldType.Elem())
+		}
+
+		if fieldType.Kind() == reflect.Struct {
+			collectExplainProperties(fieldType, properties)
+		}
+	}
+}
+
+func dereferenceType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+
+	return t
+}
+
+// xmlTagName converts a property name the way PostgreSQL does for XML output:
+// characters not allowed in a tag name become dashes ("I/O Read Time" turns
+// into "I-O-Read-Time").
+func xmlTagName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+
+		return '-'
+	}, name)
+}
+
+func xmlPropertyName(tag string) string {
+	if name, ok := xmlProperties[tag]; ok {
+		return name
+	}
+
+	return strings.ReplaceAll(tag, "-", " ")
+}
+
+// xmlToJSON converts EXPLAIN (FORMAT XML) documents to a JSON list of plans.
+func xmlToJSON(explainXML string) (string, error) {
+	roots, err := parseXMLElements(explainXML)
+	if err != nil {
+		return "", err
+	}
+
+	queries := make([]interface{}, 0, len(roots))
+
+	for _, root := range roots {
+		for _, query := range root.children {
+			queries = append(queries, query.object(reflect.TypeOf(Explain{})))
+		}
+	}
+
+	explainJSON, err := json.Marshal(queries)
+	if err != nil {
+		return "", err
+	}
+
+	return string(explainJSON), nil
+}
+
+// parseXMLElements returns the root elements of one or more concatenated
+// XML documents.
+func parseXMLElements(explainXML string) ([]*xmlElement, error) {
+	decoder := xml.NewDecoder(strings.NewReader(explainXML))
+
+	var (
+		roots []*xmlElement
+		stack []*xmlElement
+	)
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{name: token.Name.Local}
+
+			if len(stack) == 0 {
+				roots = append(roots, element)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, element)
+			}
+
+			stack = append(stack, element)
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(token)
+			}
+		}
+	}
+
+	if len(roots) == 0 {
+		return nil, errors.New("Empty explain")
+	}
+
+	return roots, nil
+}
+
+// object converts the element to an object of type t; t is nil for objects
+// joe does not decode.
+func (e *xmlElement) object(t reflect.Type) map[string]interface{} {
+	object := make(map[string]interface{}, len(e.children))
+
+	for _, child := range e.children {
+		name := xmlPropertyName(child.name)
+		object[name] = child.value(explainProperties[t][name])
+	}
+
+	return object
+}
+
+func (e *xmlElement) value(t reflect.Type) interface{} {
+	kind := reflect.Invalid
+	if t != nil {
+		kind = t.Kind()
+	}
+
+	switch {
+	case kind == reflect.Map:
+		// Settings are elements named after the parameters.
+		settings := make(map[string]interface{}, len(e.children))
+
+		for _, child := range e.children {
+			settings[child.name] = child.text
+		}
+
+		return settings
+
+	case kind == reflect.Slice:
+		items := make([]interface{}, 0, len(e.children))
+		itemType := dereferenceType(t.Elem())
+
+		for _, child := range e.children {
+			if len(child.children) == 0 {
+				items = append(items, child.text)
+				continue
+			}
+
+			items = append(items, child.object(itemType))
+		}
+
+		return items
+
+	case len(e.children) > 0:
+		return e.object(t)
+	}
+
+	return xmlScalar(strings.TrimSpace(e.text), kind)
+}
+
+// xmlScalar converts a leaf value by the kind of its property. Numbers decoded
+// into structs (the JIT generation time before PostgreSQL 17) stay numbers.
+func xmlScalar(text string, kind reflect.Kind) interface{} {
+	switch kind {
+	case reflect.Bool:
+		return text == "true"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Struct:
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return json.Number(text)
+		}
+	}
+
+	return text
+}
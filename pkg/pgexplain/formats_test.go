@@ -0,0 +1,119 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormats(t *testing.T) {
+	require.Equal(t, FormatJSON, DetectFormat(`{"Query Text": "select 1", "Plan": {}}`))
+	require.Equal(t, FormatXML, DetectFormat(`<explain xmlns="http://www.postgresql.org/2009/explain">`))
+	require.Equal(t, FormatYAML, DetectFormat("- Plan: \n    Node Type: \"Result\"\n"))
+	require.Equal(t, FormatYAML, DetectFormat("Query Text: \"select 1\"\nPlan: \n  Node Type: \"Result\"\n"))
+	require.Equal(t, FormatText, DetectFormat("Query Text: select 1\nResult  (cost=0.00..0.01 rows=1 width=4)\n"))
+}
+
+// TestNewExplainAutoExplain covers the single-document shape auto_explain logs
+// instead of the list EXPLAIN returns.
+func TestNewExplainAutoExplain(t *testing.T) {
+	documents := map[Format]string{
+		FormatJSON: `{"Query Text": "select 1", "Plan": {"Node Type": "Result", "Total Cost": 0.01, "I/O Read Time": 1.5}}`,
+		FormatYAML: "Query Text: \"select 1\"\nPlan: \n  Node Type: \"Result\"\n  Total Cost: 0.01\n  I/O Read Time: 1.5\n",
+		FormatXML: `<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query>
+    <Query-Text>select 1</Query-Text>
+    <Plan>
+      <Node-Type>Result</Node-Type>
+      <Total-Cost>0.01</Total-Cost>
+      <I-O-Read-Time>1.5</I-O-Read-Time>
+    </Plan>
+  </Query>
+</explain>`,
+	}
+
+	for format, document := range documents {
+		explain, err := NewExplain(document)
+		require.NoError(t, err, format)
+		require.Equal(t, Result, explain.Plan.NodeType, format)
+		require.Equal(t, 0.01, explain.Plan.TotalCost, format)
+		require.NotNil(t, explain.IOReadTime, format)
+		require.Equal(t, 1.5, *explain.IOReadTime, format)
+	}
+}
+
+func TestParseExplainUnsupportedFormat(t *testing.T) {
+	_, err := ParseExplain("", Format("csv"))
+	require.EqualError(t, err, `unsupported EXPLAIN format "csv"`)
+}
+
+func TestNewExplains(t *testing.T) {
+	const (
+		first  = `{"Plan": {"Node Type": "Result", "Total Cost": 0.01}, "Execution Time": 0.1}`
+		second = `{"Plan": {"Node Type": "Seq Scan", "Relation Name": "t", "Alias": "t", "Total Cost": 9.25}, "Execution Time": 2.5}`
+	)
+
+	documents := map[string]string{
+		"json list":         "[" + first + "," + second + "]",
+		"concatenated json": "[" + first + "]\n[" + second + "]\n",
+		"auto_explain json": first + "\n" + second,
+		"yaml": "- Plan: \n    Node Type: \"Result\"\n    Total Cost: 0.01\n  Execution Time: 0.1\n" +
+			"- Plan: \n    Node Type: \"Seq Scan\"\n    Relation Name: \"t\"\n    Alias: \"t\"\n    Total Cost: 9.25\n" +
+			"  Execution Time: 2.5\n",
+		"xml": `<explain><Query><Plan><Node-Type>Result</Node-Type><Total-Cost>0.01</Total-Cost></Plan>` +
+			`<Execution-Time>0.1</Execution-Time></Query></explain>` + "\n" +
+			`<explain><Query><Plan><Node-Type>Seq Scan</Node-Type><Relation-Name>t</Relation-Name><Alias>t</Alias>` +
+			`<Total-Cost>9.25</Total-Cost></Plan><Execution-Time>2.5</Execution-Time></Query></explain>`,
+	}
+
+	for name, document := range documents {
+		explains, err := NewExplains(document)
+		require.NoError(t, err, name)
+		require.Len(t, explains, 2, name)
+		require.Equal(t, Result, explains[0].Plan.NodeType, name)
+		require.Equal(t, SequenceScan, explains[1].Plan.NodeType, name)
+		require.Equal(t, 2.5, explains[1].ExecutionTime, name)
+
+		// NewExplain keeps returning the first plan.
+		explain, err := NewExplain(document)
+		require.NoError(t, err, name)
+		require.Equal(t, Result, explain.Plan.NodeType, name)
+	}
+}
+
+func TestNewExplainsText(t *testing.T) {
+	explains, err := ParseExplains(`LOG:  duration: 0.010 ms  plan:
+	Query Text: select 1
+	Result  (cost=0.00..0.01 rows=1 width=4) (actual time=0.001..0.001 rows=1 loops=1)
+LOG:  duration: 2.500 ms  plan:
+	Query Text: select * from t
+	Seq Scan on t  (cost=0.00..9.25 rows=500 width=8) (actual time=0.005..2.000 rows=500 loops=1)
+	  Buffers: shared hit=3
+`, FormatText)
+	require.NoError(t, err)
+	require.Len(t, explains, 2)
+	require.Equal(t, Result, explains[0].Plan.NodeType)
+	require.Equal(t, uint64(0), explains[0].Plan.SharedHitBlocks)
+	require.Equal(t, "t", explains[1].Plan.RelationName)
+	require.Equal(t, uint64(3), explains[1].Plan.SharedHitBlocks)
+}
+
+func TestRenderExplains(t *testing.T) {
+	explains, err := NewExplains(`[{"Plan": {"Node Type": "Result", "Total Cost": 0.01}, "Execution Time": 0.1},
+		{"Plan": {"Node Type": "Result", "Total Cost": 0.02}, "Execution Time": 0.2}]`)
+	require.NoError(t, err)
+
+	out := RenderExplains(explains)
+	require.Contains(t, out, "Plan 1 of 2:\n Result  (cost=0.00..0.01")
+	require.Contains(t, out, "\nPlan 2 of 2:\n Result  (cost=0.00..0.02")
+	require.Contains(t, out, "execution: 0.100 ms")
+	require.Contains(t, out, "execution: 0.200 ms")
+
+	single := RenderExplains(explains[:1])
+	require.NotContains(t, single, "Plan 1 of 1")
+	require.Equal(t, explains[0].RenderPlanText()+explains[0].RenderStats(), single)
+}
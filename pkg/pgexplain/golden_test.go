@@ -158,3 +158,37 @@ func TestGoldenPG18Insert(t *testing.T) {
 
 	require.Contains(t, text, "Insert on", "expected an \"Insert on\" caption in %s", fixture)
 }
+
+// TestGoldenFormats renders the YAML and XML captures of golden fixtures and
+// checks them against the golden file of the same plan captured as JSON: the
+// input format must not change a single byte of the output.
+func TestGoldenFormats(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/pg*/*.yaml")
+	require.NoError(t, err)
+
+	xmlFixtures, err := filepath.Glob("testdata/pg*/*.xml")
+	require.NoError(t, err)
+
+	fixtures = append(fixtures, xmlFixtures...)
+	require.NotEmpty(t, fixtures, "no testdata/pg*/*.{yaml,xml} fixtures found")
+
+	for _, path := range fixtures {
+		path := path
+
+		t.Run(goldenName(path)+filepath.Ext(path), func(t *testing.T) {
+			raw, err := os.ReadFile(path)
+			require.NoError(t, err, "read fixture %s", path)
+
+			require.Equal(t, Format(strings.TrimPrefix(filepath.Ext(path), ".")), DetectFormat(string(raw)))
+
+			explain, err := NewExplain(string(raw))
+			require.NoError(t, err, "NewExplain failed for %s", path)
+
+			out := goldenCanonicalize(explain.RenderPlanText() + goldenSeparator + explain.RenderStats())
+
+			want, err := os.ReadFile(goldenPathFor(path))
+			require.NoError(t, err, "missing golden %s for %s", goldenPathFor(path), path)
+			require.Equal(t, string(want), out, "%s renders differently from its JSON capture", path)
+		})
+	}
+}
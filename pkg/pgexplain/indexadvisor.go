@@ -0,0 +1,275 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// indexCandidateMaxColumns caps the number of columns of a candidate index:
+// wider indexes seldom pay off and cost more to maintain.
+const indexCandidateMaxColumns = 3
+
+// plainIdentifier matches the identifiers that need no quotes.
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// reservedWords are the reserved keywords of Postgres, which must be quoted as identifiers.
+var reservedWords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true, "as": true, "asc": true,
+	"asymmetric": true, "both": true, "case": true, "cast": true, "check": true, "collate": true, "column": true,
+	"constraint": true, "create": true, "current_catalog": true, "current_date": true, "current_role": true,
+	"current_time": true, "current_timestamp": true, "current_user": true, "default": true, "deferrable": true,
+	"desc": true, "distinct": true, "do": true, "else": true, "end": true, "except": true, "false": true, "fetch": true,
+	"for": true, "foreign": true, "from": true, "grant": true, "group": true, "having": true, "in": true,
+	"initially": true, "intersect": true, "into": true, "lateral": true, "leading": true, "limit": true,
+	"localtime": true, "localtimestamp": true, "not": true, "null": true, "offset": true, "on": true, "only": true,
+	"or": true, "order": true, "placing": true, "primary": true, "references": true, "returning": true, "select": true,
+	"session_user": true, "some": true, "symmetric": true, "system_user": true, "table": true, "then": true, "to": true,
+	"trailing": true, "true": true, "union": true, "unique": true, "user": true, "using": true, "variadic": true,
+	"when": true, "where": true, "window": true, "with": true,
+}
+
+// IndexCandidate is an index that may speed up the query of a plan.
+type IndexCandidate struct {
+	Schema   string // Empty when the plan has no schema.
+	Relation string
+	Columns  []string
+	Sources  []string // The expressions the columns come from, e.g. "Filter" or "Sort Key".
+}
+
+// Name returns the relation of the index, schema-qualified when the plan has the schema.
+func (c IndexCandidate) Name() string {
+	if c.Schema == "" {
+		return quoteIdentifier(c.Relation)
+	}
+
+	return quoteIdentifier(c.Schema) + "." + quoteIdentifier(c.Relation)
+}
+
+// Definition returns the statement creating the index, e.g.
+// "CREATE INDEX CONCURRENTLY ON shop.orders (status, region)".
+func (c IndexCandidate) Definition(concurrently bool) string {
+	columns := make([]string, 0, len(c.Columns))
+	for _, column := range c.Columns {
+		columns = append(columns, quoteIdentifier(column))
+	}
+
+	statement := "CREATE INDEX"
+	if concurrently {
+		statement += " CONCURRENTLY"
+	}
+
+	return fmt.Sprintf("%s ON %s (%s)", statement, c.Name(), strings.Join(columns, ", "))
+}
+
+// IndexCandidates suggests B-tree indexes on the columns the plan filters,
+// joins, sorts and groups by: one per column and one per set of columns of
+// a relation used together, including the filter columns followed by the
+// sort or group keys. The candidates are guesses; try them as hypothetical
+// indexes to see which ones the planner picks.
+func (ex *Explain) IndexCandidates() []IndexCandidate {
+	scans := ex.Plan.relationScans()
+	only := onlyRelationScan(scans)
+
+	var (
+		candidates indexCandidates
+		conditions []relationColumns // The columns each relation is filtered and joined by.
+		orderings  []relationColumns // The sort and group keys on a single relation.
+	)
+
+	ex.Plan.walk(func(node *Plan) {
+		for _, expression := range []struct{ name, value string }{
+			{"Index Cond", node.IndexCondition},
+			{"Recheck Cond", node.RecheckCond},
+			{"Filter", node.Filter},
+			{"Hash Cond", node.HashCondition},
+			{"Merge Cond", node.MergeCondition},
+			{"Join Filter", node.JoinFilter},
+		} {
+			for _, group := range resolveColumns(node, scans, only, expression.value) {
+				for _, column := range group.columns {
+					candidates.add(group.scan, []string{column}, expression.name)
+				}
+
+				candidates.add(group.scan, group.columns, expression.name)
+				conditions = mergeRelationColumns(conditions, group, expression.name)
+			}
+		}
+
+		for _, key := range []struct {
+			name   string
+			values []string
+		}{
+			{"Sort Key", node.SortKey},
+			{"Group Key", node.GroupKey},
+		} {
+			// An index provides the order only when it has all the keys.
+			groups := resolveColumns(node, scans, only, strings.Join(key.values, ", "))
+			if len(groups) != 1 {
+				continue
+			}
+
+			groups[0].sources = []string{key.name}
+
+			candidates.add(groups[0].scan, groups[0].columns, key.name)
+			orderings = append(orderings, groups[0])
+		}
+	})
+
+	for _, ordering := range orderings {
+		for _, condition := range conditions {
+			if qualifiedRelationName(condition.scan) != qualifiedRelationName(ordering.scan) {
+				continue
+			}
+
+			columns := slices.Clone(condition.columns)
+			for _, column := range ordering.columns {
+				if !slices.Contains(columns, column) {
+					columns = append(columns, column)
+				}
+			}
+
+			candidates.add(ordering.scan, columns, append(slices.Clone(condition.sources), ordering.sources...)...)
+		}
+	}
+
+	return candidates
+}
+
+// IndexNames returns the indexes the plan uses, in order of appearance.
+func (ex *Explain) IndexNames() []string {
+	var names []string
+
+	ex.Plan.walk(func(node *Plan) {
+		if node.IndexName != "" && !slices.Contains(names, node.IndexName) {
+			names = append(names, node.IndexName)
+		}
+	})
+
+	return names
+}
+
+// relationColumns are the columns of a relation used in expressions.
+type relationColumns struct {
+	scan    *Plan
+	columns []string
+	sources []string
+}
+
+// indexCandidates collects the candidate indexes without duplicates.
+type indexCandidates []IndexCandidate
+
+// add adds the index on the first columns, up to indexCandidateMaxColumns,
+// or the sources to the same index found before.
+func (c *indexCandidates) add(scan *Plan, columns []string, sources ...string) {
+	columns = columns[:min(len(columns), indexCandidateMaxColumns)]
+
+	for index, candidate := range *c {
+		if candidate.Schema != scan.Schema || candidate.Relation != scan.RelationName || !slices.Equal(candidate.Columns, columns) {
+			continue
+		}
+
+		for _, source := range sources {
+			if !slices.Contains(candidate.Sources, source) {
+				(*c)[index].Sources = append((*c)[index].Sources, source)
+			}
+		}
+
+		return
+	}
+
+	*c = append(*c, IndexCandidate{
+		Schema:   scan.Schema,
+		Relation: scan.RelationName,
+		Columns:  slices.Clone(columns),
+		Sources:  slices.Compact(slices.Clone(sources)),
+	})
+}
+
+// resolveColumns groups the columns of an expression of the node by the
+// relations they belong to, in order of appearance. Unqualified columns
+// belong to the relation the node scans or, failing that, to the only
+// relation of the plan. Columns of CTEs, subqueries and functions are skipped.
+func resolveColumns(node *Plan, scans map[string]*Plan, only *Plan, expression string) []relationColumns {
+	own := only
+	if node.RelationName != "" {
+		own = node
+	}
+
+	var groups []relationColumns
+
+	for _, reference := range expressionColumns(expression) {
+		scan := own
+		if reference.qualifier != "" {
+			scan = scans[reference.qualifier]
+		}
+
+		if scan == nil {
+			continue
+		}
+
+		groups = mergeRelationColumns(groups, relationColumns{scan: scan, columns: []string{reference.column}})
+	}
+
+	return groups
+}
+
+// mergeRelationColumns adds the columns and the sources to the group of the same relation.
+func mergeRelationColumns(groups []relationColumns, group relationColumns, sources ...string) []relationColumns {
+	name := qualifiedRelationName(group.scan)
+
+	for index := range groups {
+		if qualifiedRelationName(groups[index].scan) != name {
+			continue
+		}
+
+		for _, column := range group.columns {
+			if !slices.Contains(groups[index].columns, column) {
+				groups[index].columns = append(groups[index].columns, column)
+			}
+		}
+
+		for _, source := range sources {
+			if !slices.Contains(groups[index].sources, source) {
+				groups[index].sources = append(groups[index].sources, source)
+			}
+		}
+
+		return groups
+	}
+
+	group.columns = slices.Clone(group.columns)
+	group.sources = slices.Clone(sources)
+
+	return append(groups, group)
+}
+
+// onlyRelationScan returns the scan of the only relation of the plan, or nil
+// when it reads several relations or none.
+func onlyRelationScan(scans map[string]*Plan) *Plan {
+	var only *Plan
+
+	for _, scan := range scans {
+		if only != nil && qualifiedRelationName(only) != qualifiedRelationName(scan) {
+			return nil
+		}
+
+		only = scan
+	}
+
+	return only
+}
+
+// quoteIdentifier quotes an identifier unless it is lower case and not a reserved keyword.
+func quoteIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) && !reservedWords[name] {
+		return name
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
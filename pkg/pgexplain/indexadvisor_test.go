@@ -0,0 +1,103 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inputJSONIndexAdvisor sorts the new orders of a region joined to customers.
+const inputJSONIndexAdvisor = `[{
+	"Plan": {
+		"Node Type": "Sort", "Total Cost": 2100.0, "Plan Rows": 10, "Plan Width": 16,
+		"Sort Key": ["o.created_at DESC"],
+		"Plans": [{
+			"Node Type": "Hash Join", "Parent Relationship": "Outer", "Join Type": "Inner",
+			"Total Cost": 2000.0, "Plan Rows": 10, "Plan Width": 16,
+			"Hash Cond": "(o.customer_id = c.id)",
+			"Plans": [
+				{
+					"Node Type": "Seq Scan", "Parent Relationship": "Outer",
+					"Relation Name": "orders", "Schema": "shop", "Alias": "o",
+					"Total Cost": 1800.0, "Plan Rows": 10, "Plan Width": 12,
+					"Filter": "((o.status = 'new'::text) AND (o.region = 'eu'::text))"
+				},
+				{
+					"Node Type": "Hash", "Parent Relationship": "Inner",
+					"Total Cost": 2.0, "Plan Rows": 100, "Plan Width": 4,
+					"Plans": [{
+						"Node Type": "Seq Scan", "Parent Relationship": "Outer",
+						"Relation Name": "customers", "Schema": "shop", "Alias": "c",
+						"Total Cost": 2.0, "Plan Rows": 100, "Plan Width": 4
+					}]
+				}
+			]
+		}]
+	}
+}]`
+
+func TestIndexCandidates(t *testing.T) {
+	explain, err := NewExplain(inputJSONIndexAdvisor)
+	require.NoError(t, err)
+
+	var definitions []string
+	for _, candidate := range explain.IndexCandidates() {
+		definitions = append(definitions, candidate.Definition(false))
+	}
+
+	assert.Equal(t, []string{
+		"CREATE INDEX ON shop.orders (created_at)",
+		"CREATE INDEX ON shop.orders (customer_id)",
+		"CREATE INDEX ON shop.customers (id)",
+		"CREATE INDEX ON shop.orders (status)",
+		"CREATE INDEX ON shop.orders (region)",
+		"CREATE INDEX ON shop.orders (status, region)",
+		"CREATE INDEX ON shop.orders (customer_id, status, region)",
+	}, definitions)
+
+	candidates := explain.IndexCandidates()
+	assert.Equal(t, []string{"Sort Key"}, candidates[0].Sources)
+	assert.Equal(t, []string{"Hash Cond", "Filter", "Sort Key"}, candidates[6].Sources)
+}
+
+func TestIndexCandidatesUnqualifiedColumns(t *testing.T) {
+	explain, err := NewExplain(`[{"Plan": {
+		"Node Type": "Aggregate", "Strategy": "Hashed", "Total Cost": 30.0, "Plan Rows": 5,
+		"Group Key": ["user", "kind"],
+		"Plans": [{
+			"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Relation Name": "Events",
+			"Total Cost": 25.0, "Plan Rows": 100,
+			"Filter": "(created_at > now())"
+		}]
+	}}]`)
+	require.NoError(t, err)
+
+	var definitions []string
+	for _, candidate := range explain.IndexCandidates() {
+		definitions = append(definitions, candidate.Definition(true))
+	}
+
+	assert.Equal(t, []string{
+		`CREATE INDEX CONCURRENTLY ON "Events" ("user", kind)`,
+		`CREATE INDEX CONCURRENTLY ON "Events" (created_at)`,
+		`CREATE INDEX CONCURRENTLY ON "Events" (created_at, "user", kind)`,
+	}, definitions)
+}
+
+func TestIndexNames(t *testing.T) {
+	explain, err := NewExplain(`[{"Plan": {
+		"Node Type": "Nested Loop", "Join Type": "Inner",
+		"Plans": [
+			{"Node Type": "Index Scan", "Parent Relationship": "Outer", "Relation Name": "a", "Index Name": "<13543>btree_a_id"},
+			{"Node Type": "Index Only Scan", "Parent Relationship": "Inner", "Relation Name": "b", "Index Name": "b_pkey"}
+		]
+	}}]`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"<13543>btree_a_id", "b_pkey"}, explain.IndexNames())
+}
@@ -0,0 +1,419 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// misestimateReportLimit caps the nodes listed in the misestimate report.
+	misestimateReportLimit = 5
+
+	// misestimateStatisticsTarget is the per-column statistics target suggested
+	// for misestimated columns; the default is default_statistics_target (100).
+	misestimateStatisticsTarget = 1000
+)
+
+// Misestimate is a plan node whose row count the planner got badly wrong,
+// with what the estimate was based on and how to improve it.
+type Misestimate struct {
+	Plan      *Plan `json:"-"`
+	Direction EstimateDirection
+	// Factor is how many times the planned rows were off, at least 1.
+	Factor float64
+	// Conditions are the node's conditions and keys, e.g. "Filter: (...)".
+	Conditions []string
+	// Relations are the relations involved with the columns of the conditions.
+	Relations []MisestimateRelation
+	// Remedies are the SQL statements that may improve the estimate.
+	Remedies []string
+}
+
+// MisestimateRelation is a relation of a misestimated node and the columns the
+// node's conditions use; Columns is empty when the node only reads the relation.
+type MisestimateRelation struct {
+	Name    string // Schema-qualified when the plan has the schema.
+	Columns []string
+}
+
+// Misestimates returns the nodes whose actual rows differ from the planned
+// ones at least misestimateMinFactor times, worst first. Nodes where both
+// numbers are small are left out like in the row misestimate tip, and so are:
+//   - nodes with neither conditions nor a relation of their own, such as a
+//     Sort or a Hash, which only pass on the misestimate of their input;
+//   - overestimates of nodes that stop early by design, below a Limit, a
+//     WindowAgg with a run condition, a Merge Join or the inner side of a
+//     semi or anti join, where fewer rows than planned are expected.
+func (ex *Explain) Misestimates() []Misestimate {
+	var misestimates []Misestimate
+
+	ex.Plan.walkEarlyStop(false, func(plan *Plan, earlyStop bool) {
+		if plan.ActualLoops == 0 {
+			return
+		}
+
+		if float64(plan.PlanRows) < misestimateMinRows && plan.ActualRows < misestimateMinRows {
+			return
+		}
+
+		direction, factor := misestimateFactor(plan)
+		if factor < misestimateMinFactor || direction == Over && earlyStop {
+			return
+		}
+
+		misestimate := Misestimate{
+			Plan:       plan,
+			Direction:  direction,
+			Factor:     factor,
+			Conditions: misestimateConditions(plan),
+			Relations:  misestimateRelations(plan),
+		}
+
+		if len(misestimate.Conditions) == 0 && len(misestimate.Relations) == 0 {
+			return
+		}
+
+		misestimate.Remedies = misestimateRemedies(misestimate.Relations)
+
+		misestimates = append(misestimates, misestimate)
+	})
+
+	sort.SliceStable(misestimates, func(i, j int) bool { return misestimates[i].Factor > misestimates[j].Factor })
+
+	return misestimates
+}
+
+// RenderMisestimates renders the misestimate report.
+func (ex *Explain) RenderMisestimates() string {
+	buf := new(bytes.Buffer)
+
+	if !ex.writeMisestimates(buf) {
+		buf.WriteString("No significant row count misestimates.\n")
+	}
+
+	return buf.String()
+}
+
+// writeMisestimates renders the worst misestimated nodes, e.g.
+//
+//	Row count misestimates:
+//	  - Seq Scan on public.orders o: 1250x underestimated (planned 8, actual 10000)
+//	    Filter: ((o.status = 'new'::text) AND (o.region = 'eu'::text))
+//	    Relations: public.orders (status, region)
+//	    Remedies:
+//	      ANALYZE public.orders;
+//	      ...
+//
+// and reports whether there was anything to render.
+func (ex *Explain) writeMisestimates(writer io.Writer) bool {
+	misestimates := ex.Misestimates()
+	if len(misestimates) == 0 {
+		return false
+	}
+
+	_, _ = fmt.Fprintf(writer, "Row count misestimates:\n")
+
+	for index, misestimate := range misestimates {
+		if index == misestimateReportLimit {
+			_, _ = fmt.Fprintf(writer, "  - ... %d more\n", len(misestimates)-index)
+			break
+		}
+
+		plan := misestimate.Plan
+		direction := "underestimated"
+
+		if misestimate.Direction == Over {
+			direction = "overestimated"
+		}
+
+		_, _ = fmt.Fprintf(writer, "  - %s: %.0fx %s (planned %d, actual %s)\n",
+			nodeCaption(plan), misestimate.Factor, direction, plan.PlanRows, formatActualRows(plan.ActualRows))
+
+		for _, condition := range misestimate.Conditions {
+			_, _ = fmt.Fprintf(writer, "    %s\n", condition)
+		}
+
+		relations := make([]string, 0, len(misestimate.Relations))
+
+		for _, relation := range misestimate.Relations {
+			if len(relation.Columns) == 0 {
+				relations = append(relations, relation.Name)
+				continue
+			}
+
+			relations = append(relations, fmt.Sprintf("%s (%s)", relation.Name, strings.Join(relation.Columns, ", ")))
+		}
+
+		if len(relations) > 0 {
+			_, _ = fmt.Fprintf(writer, "    Relations: %s\n", strings.Join(relations, "; "))
+		}
+
+		_, _ = fmt.Fprintf(writer, "    Remedies:\n")
+
+		for _, remedy := range misestimate.Remedies {
+			_, _ = fmt.Fprintf(writer, "      %s\n", remedy)
+		}
+	}
+
+	return true
+}
+
+// walkEarlyStop calls fn for the node and its descendants in depth-first
+// order, telling whether the node may stop before producing all its rows
+// because a node above does not need them.
+func (plan *Plan) walkEarlyStop(earlyStop bool, fn func(plan *Plan, earlyStop bool)) {
+	if plan.RunCondition != "" {
+		earlyStop = true
+	}
+
+	fn(plan, earlyStop)
+
+	for index := range plan.Plans {
+		child := &plan.Plans[index]
+		childEarlyStop := earlyStop
+
+		switch {
+		case plan.NodeType == Limit, plan.NodeType == MergeJoin:
+			childEarlyStop = true
+
+		case (plan.JoinType == "Semi" || plan.JoinType == "Anti") && child.ParentRelationship == "Inner":
+			childEarlyStop = true
+		}
+
+		child.walkEarlyStop(childEarlyStop, fn)
+	}
+}
+
+// misestimateFactor returns the direction and the factor of the row estimate
+// error; unlike PlannerRowEstimateFactor it stays meaningful when a side is
+// zero, which the planner never estimates, by counting it as one row.
+func misestimateFactor(plan *Plan) (EstimateDirection, float64) {
+	planned := max(float64(plan.PlanRows), 1)
+	actual := max(plan.ActualRows, 1)
+
+	if actual >= planned {
+		return Under, actual / planned
+	}
+
+	return Over, planned / actual
+}
+
+// misestimateConditions returns the conditions and keys the estimate of the
+// node is based on.
+func misestimateConditions(plan *Plan) []string {
+	var conditions []string
+
+	for _, condition := range misestimateExpressions(plan) {
+		if condition.value != "" {
+			conditions = append(conditions, condition.name+": "+condition.value)
+		}
+	}
+
+	return conditions
+}
+
+// misestimateExpressions lists the named expressions of a node that drive its
+// row estimate: scan and join conditions and, for aggregates, the group key
+// whose number of distinct values is estimated.
+func misestimateExpressions(plan *Plan) []struct{ name, value string } {
+	return []struct{ name, value string }{
+		{"Index Cond", plan.IndexCondition},
+		{"Recheck Cond", plan.RecheckCond},
+		{"Hash Cond", plan.HashCondition},
+		{"Merge Cond", plan.MergeCondition},
+		{"Join Filter", plan.JoinFilter},
+		{"Filter", plan.Filter},
+		{"Group Key", strings.Join(plan.GroupKey, ", ")},
+	}
+}
+
+// misestimateRelations resolves the columns of the node's conditions to the
+// relations read by the node or below it. A scan without conditions still
+// names its relation.
+func misestimateRelations(plan *Plan) []MisestimateRelation {
+	scans := plan.relationScans()
+	ownRelation := qualifiedRelationName(plan)
+
+	var relations []MisestimateRelation
+
+	add := func(name, column string) {
+		for index := range relations {
+			if relations[index].Name != name {
+				continue
+			}
+
+			if column != "" && !slices.Contains(relations[index].Columns, column) {
+				relations[index].Columns = append(relations[index].Columns, column)
+			}
+
+			return
+		}
+
+		relation := MisestimateRelation{Name: name}
+		if column != "" {
+			relation.Columns = []string{column}
+		}
+
+		relations = append(relations, relation)
+	}
+
+	if ownRelation != "" {
+		add(ownRelation, "")
+	}
+
+	for _, expression := range misestimateExpressions(plan) {
+		for _, reference := range expressionColumns(expression.value) {
+			name := ownRelation
+			if reference.qualifier != "" {
+				name = qualifiedRelationName(scans[reference.qualifier])
+			}
+
+			// Columns of CTEs, subqueries and functions have no statistics to fix.
+			if name != "" {
+				add(name, reference.column)
+			}
+		}
+	}
+
+	return relations
+}
+
+// relationScans maps the aliases and the names of the relations scanned by the
+// node or below it to the first scan of each.
+func (plan *Plan) relationScans() map[string]*Plan {
+	scans := make(map[string]*Plan)
+
+	plan.walk(func(node *Plan) {
+		if node.RelationName == "" {
+			return
+		}
+
+		for _, qualifier := range []string{node.Alias, node.RelationName} {
+			if _, ok := scans[qualifier]; qualifier != "" && !ok {
+				scans[qualifier] = node
+			}
+		}
+	})
+
+	return scans
+}
+
+// qualifiedRelationName returns the relation a node scans, schema-qualified
+// when the plan has the schema, or an empty string for other nodes.
+func qualifiedRelationName(plan *Plan) string {
+	if plan == nil || plan.RelationName == "" {
+		return ""
+	}
+
+	if plan.Schema == "" {
+		return plan.RelationName
+	}
+
+	return plan.Schema + "." + plan.RelationName
+}
+
+// misestimateRemedies suggests refreshing the statistics of the relations,
+// raising the statistics target of their columns, and extended statistics
+// when a relation has several columns in the conditions, which are often
+// correlated.
+func misestimateRemedies(relations []MisestimateRelation) []string {
+	if len(relations) == 0 {
+		return []string{"ANALYZE the tables the node reads; their statistics may be stale."}
+	}
+
+	names := make([]string, 0, len(relations))
+	for _, relation := range relations {
+		names = append(names, relation.Name)
+	}
+
+	remedies := []string{fmt.Sprintf("ANALYZE %s;", strings.Join(names, ", "))}
+
+	for _, relation := range relations {
+		if len(relation.Columns) == 0 {
+			continue
+		}
+
+		alterations := make([]string, 0, len(relation.Columns))
+		for _, column := range relation.Columns {
+			alterations = append(alterations, fmt.Sprintf("ALTER COLUMN %s SET STATISTICS %d", column, misestimateStatisticsTarget))
+		}
+
+		remedies = append(remedies, fmt.Sprintf("ALTER TABLE %s %s;", relation.Name, strings.Join(alterations, ", ")))
+	}
+
+	for _, relation := range relations {
+		if len(relation.Columns) > 1 {
+			remedies = append(remedies, fmt.Sprintf("CREATE STATISTICS ON %s FROM %s;",
+				strings.Join(relation.Columns, ", "), relation.Name))
+		}
+	}
+
+	if len(remedies) > 1 {
+		remedies = append(remedies, "ANALYZE again after changing the statistics target or creating statistics.")
+	}
+
+	return remedies
+}
+
+// columnReference is a column used in an expression, with the alias or
+// relation name qualifying it, if any.
+type columnReference struct {
+	qualifier string
+	column    string
+}
+
+// expressionColumns returns the columns an expression as printed in a plan
+// refers to, in order of appearance. Keywords, function and type names are
+// skipped; whole-row references ("o.*") are not columns.
+func expressionColumns(expression string) []columnReference {
+	var references []columnReference
+
+	tokens := tokenizeExpression(expression)
+
+	for index := 0; index < len(tokens); index++ {
+		if tokens[index].kind != tokenName {
+			continue
+		}
+
+		start := index
+		chain := referenceChain(tokens, start)
+		previous := previousToken(tokens, start)
+		next := start + 2*len(chain) - 1
+
+		index = next - 1
+
+		switch {
+		case previous != nil && previous.text == "::",
+			previous != nil && previous.cast && typeWords[chain[0]] && len(chain) == 1:
+			tokens[start].cast = true
+			continue
+
+		case len(chain) == 1 && !tokens[start].quoted && (expressionKeywords[chain[0]] || next < len(tokens) && tokens[next].text == "("):
+			continue
+		}
+
+		column := chain[len(chain)-1]
+		if column == "*" {
+			continue
+		}
+
+		reference := columnReference{column: column}
+		if len(chain) > 1 {
+			reference.qualifier = chain[len(chain)-2]
+		}
+
+		if !slices.Contains(references, reference) {
+			references = append(references, reference)
+		}
+	}
+
+	return references
+}
@@ -0,0 +1,130 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inputJSONMisestimate joins orders filtered on two correlated columns to
+// customers: the filter is underestimated, and so is the join above it.
+const inputJSONMisestimate = `[{
+	"Plan": {
+		"Node Type": "Hash Join", "Join Type": "Inner",
+		"Startup Cost": 10.0, "Total Cost": 2000.0, "Plan Rows": 5, "Plan Width": 16,
+		"Actual Startup Time": 1.0, "Actual Total Time": 50.0, "Actual Rows": 9000, "Actual Loops": 1,
+		"Hash Cond": "(o.customer_id = c.id)",
+		"Plans": [
+			{
+				"Node Type": "Seq Scan", "Parent Relationship": "Outer",
+				"Relation Name": "orders", "Schema": "shop", "Alias": "o",
+				"Startup Cost": 0.0, "Total Cost": 1800.0, "Plan Rows": 8, "Plan Width": 12,
+				"Actual Startup Time": 0.1, "Actual Total Time": 40.0, "Actual Rows": 10000, "Actual Loops": 1,
+				"Filter": "((o.status = 'new'::text) AND (o.region = 'eu'::text))",
+				"Rows Removed by Filter": 90000
+			},
+			{
+				"Node Type": "Hash", "Parent Relationship": "Inner",
+				"Startup Cost": 2.0, "Total Cost": 2.0, "Plan Rows": 100, "Plan Width": 4,
+				"Actual Startup Time": 1.0, "Actual Total Time": 1.0, "Actual Rows": 100, "Actual Loops": 1,
+				"Plans": [{
+					"Node Type": "Seq Scan", "Parent Relationship": "Outer",
+					"Relation Name": "customers", "Schema": "shop", "Alias": "c",
+					"Startup Cost": 0.0, "Total Cost": 2.0, "Plan Rows": 100, "Plan Width": 4,
+					"Actual Startup Time": 0.01, "Actual Total Time": 0.5, "Actual Rows": 100, "Actual Loops": 1
+				}]
+			}
+		]
+	},
+	"Execution Time": 51.0
+}]`
+
+func TestMisestimates(t *testing.T) {
+	explain, err := NewExplain(inputJSONMisestimate)
+	require.NoError(t, err)
+
+	misestimates := explain.Misestimates()
+	require.Len(t, misestimates, 2)
+
+	// The join is off by more than the scan below it, so it comes first.
+	join := misestimates[0]
+	require.Equal(t, &explain.Plan, join.Plan)
+	require.Equal(t, []string{"Hash Cond: (o.customer_id = c.id)"}, join.Conditions)
+	require.Equal(t, []MisestimateRelation{
+		{Name: "shop.orders", Columns: []string{"customer_id"}},
+		{Name: "shop.customers", Columns: []string{"id"}},
+	}, join.Relations)
+
+	scan := misestimates[1]
+	require.Equal(t, &explain.Plan.Plans[0], scan.Plan)
+	require.Equal(t, EstimateDirection(Under), scan.Direction)
+	require.InDelta(t, 1250, scan.Factor, 1e-9)
+	require.Equal(t, []MisestimateRelation{{Name: "shop.orders", Columns: []string{"status", "region"}}}, scan.Relations)
+	require.Equal(t, []string{
+		"ANALYZE shop.orders;",
+		"ALTER TABLE shop.orders ALTER COLUMN status SET STATISTICS 1000, ALTER COLUMN region SET STATISTICS 1000;",
+		"CREATE STATISTICS ON status, region FROM shop.orders;",
+		"ANALYZE again after changing the statistics target or creating statistics.",
+	}, scan.Remedies)
+}
+
+func TestRenderStatsMisestimates(t *testing.T) {
+	explain, err := NewExplain(inputJSONMisestimate)
+	require.NoError(t, err)
+
+	require.Contains(t, explain.RenderStats(), `
+Row count misestimates:
+  - Hash Join: 1800x underestimated (planned 5, actual 9000)
+    Hash Cond: (o.customer_id = c.id)
+    Relations: shop.orders (customer_id); shop.customers (id)
+    Remedies:
+      ANALYZE shop.orders, shop.customers;
+      ALTER TABLE shop.orders ALTER COLUMN customer_id SET STATISTICS 1000;
+      ALTER TABLE shop.customers ALTER COLUMN id SET STATISTICS 1000;
+      ANALYZE again after changing the statistics target or creating statistics.
+  - Seq Scan on shop.orders o: 1250x underestimated (planned 8, actual 10000)
+    Filter: ((o.status = 'new'::text) AND (o.region = 'eu'::text))
+    Relations: shop.orders (status, region)
+    Remedies:
+      ANALYZE shop.orders;
+      ALTER TABLE shop.orders ALTER COLUMN status SET STATISTICS 1000, ALTER COLUMN region SET STATISTICS 1000;
+      CREATE STATISTICS ON status, region FROM shop.orders;
+      ANALYZE again after changing the statistics target or creating statistics.
+`)
+	require.Equal(t, explain.RenderMisestimates(), explain.RenderStats()[strings.Index(explain.RenderStats(), "Row count"):])
+}
+
+func TestMisestimatesSkipEarlyStopAndPassThrough(t *testing.T) {
+	// The scan below the Limit stops after 10 rows, and the Sort only passes
+	// on the rows of its input: neither is worth reporting.
+	explain, err := NewExplain(`[{"Plan": {
+		"Node Type": "Limit", "Plan Rows": 10, "Actual Rows": 10, "Actual Loops": 1,
+		"Plans": [{
+			"Node Type": "Sort", "Parent Relationship": "Outer", "Sort Key": ["t.a"],
+			"Plan Rows": 100, "Actual Rows": 50000, "Actual Loops": 1,
+			"Plans": [{
+				"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Relation Name": "t", "Alias": "t",
+				"Plan Rows": 100000, "Actual Rows": 50000, "Actual Loops": 1
+			}]
+		}]
+	}}]`)
+	require.NoError(t, err)
+
+	require.Empty(t, explain.Misestimates())
+	require.Equal(t, "No significant row count misestimates.\n", explain.RenderMisestimates())
+	require.NotContains(t, explain.RenderStats(), "Row count misestimates")
+}
+
+func TestExpressionColumns(t *testing.T) {
+	require.Equal(t, []columnReference{
+		{qualifier: "o", column: "created_at"},
+		{column: "status"},
+		{qualifier: "orders", column: "total"},
+	}, expressionColumns(`((date_trunc('day'::text, o.created_at) > '2024-01-01 00:00:00'::timestamp without time zone) `+
+		`AND (status IS NOT NULL) AND (shop.orders.total > 10) AND (o.created_at IS NOT NULL) AND ROW(o.*) IS NOT NULL)`))
+}
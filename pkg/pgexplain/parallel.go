@@ -0,0 +1,325 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gitlab.com/postgres-ai/joe/pkg/util"
+)
+
+// parallelImbalanceFactor is how many times the average time of the processes
+// of a parallel region the slowest one has to take to count as imbalanced.
+const parallelImbalanceFactor = 1.5
+
+// leaderWorkerNumber is the worker number of the leader in ParallelProcess.
+const leaderWorkerNumber = -1
+
+// ParallelRegion is the parallel part of a plan below a Gather or Gather
+// Merge node and the work its processes did.
+type ParallelRegion struct {
+	Plan *Plan `json:"-"` // Gather or Gather Merge.
+
+	// WorkersPlanned and WorkersLaunched count per execution of the node. Postgres
+	// overwrites the launched count on each rescan, so it only reflects the last
+	// execution of a Gather run several times, e.g. under a Nested Loop.
+	WorkersPlanned     uint
+	WorkersLaunched    uint
+	LeaderParticipated bool
+
+	// Work is the time all processes spent in the parallel part and Elapsed
+	// the time the Gather took, both in milliseconds.
+	Work    float64
+	Elapsed float64
+
+	// Processes are the leader, when it participated, and the workers. They are
+	// only known when the plan has per-worker numbers (EXPLAIN VERBOSE).
+	Processes []ParallelProcess
+
+	// Appends are the Parallel Append nodes of the region.
+	Appends []*Plan `json:"-"`
+}
+
+// ParallelProcess is the share of a process in the work of a parallel region.
+type ParallelProcess struct {
+	Worker int // Worker number, leaderWorkerNumber for the leader.
+
+	// Time is spent in the parallel part, in milliseconds, and Rows are read
+	// by the parallel-aware node that splits the work between the processes.
+	Time float64
+	Rows float64
+}
+
+// Parallelism analyzes the parallel regions of the plan, one per Gather or
+// Gather Merge node that ran; plans without ANALYZE have none.
+func (ex *Explain) Parallelism() []ParallelRegion {
+	var regions []ParallelRegion
+
+	ex.Plan.walk(func(plan *Plan) {
+		if (plan.NodeType == Gather || plan.NodeType == GatherMerge) && plan.ActualLoops > 0 {
+			regions = append(regions, newParallelRegion(plan))
+		}
+	})
+
+	return regions
+}
+
+func newParallelRegion(gather *Plan) ParallelRegion {
+	region := ParallelRegion{
+		Plan:               gather,
+		WorkersPlanned:     gather.WorkersPlanned,
+		WorkersLaunched:    gather.WorkersLaunched,
+		LeaderParticipated: gather.parallelProcesses() > float64(gather.WorkersLaunched),
+		Elapsed:            gather.InclusiveDuration,
+	}
+
+	var top *Plan
+
+	for index := range gather.Plans {
+		if gather.Plans[index].SubplanName == "" {
+			top = &gather.Plans[index]
+			break
+		}
+	}
+
+	if top == nil {
+		return region
+	}
+
+	region.Work = top.ActualTotalTime * float64(top.ActualLoops)
+
+	// The rows show how the parallel-aware node below, e.g. a Parallel Seq
+	// Scan, split the work; the time is the one of the whole parallel part.
+	splitter := top
+
+	top.walk(func(plan *Plan) {
+		if plan.NodeType == Append && plan.ParallelAware {
+			region.Appends = append(region.Appends, plan)
+		}
+
+		if splitter == top && plan.ParallelAware && len(plan.Workers) > 0 {
+			splitter = plan
+		}
+	})
+
+	if len(top.Workers) == 0 {
+		return region
+	}
+
+	workerRows := make(map[int]float64, len(splitter.Workers))
+	for _, worker := range splitter.Workers {
+		workerRows[worker.WorkerNumber] = worker.ActualRows * float64(worker.ActualLoops)
+	}
+
+	leader := ParallelProcess{Worker: leaderWorkerNumber, Time: region.Work, Rows: splitter.totalRows()}
+
+	for _, worker := range top.Workers {
+		process := ParallelProcess{
+			Worker: worker.WorkerNumber,
+			Time:   worker.ActualTotalTime * float64(worker.ActualLoops),
+			Rows:   workerRows[worker.WorkerNumber],
+		}
+
+		leader.Time -= process.Time
+		leader.Rows -= process.Rows
+
+		region.Processes = append(region.Processes, process)
+	}
+
+	if region.LeaderParticipated {
+		leader.Time = max(leader.Time, 0)
+		leader.Rows = max(leader.Rows, 0)
+
+		region.Processes = append([]ParallelProcess{leader}, region.Processes...)
+	}
+
+	return region
+}
+
+// Shortfall returns how many of the planned workers were not launched in the
+// last execution, typically because max_parallel_workers or max_worker_processes
+// was reached.
+func (r *ParallelRegion) Shortfall() uint {
+	if r.WorkersLaunched >= r.WorkersPlanned {
+		return 0
+	}
+
+	return r.WorkersPlanned - r.WorkersLaunched
+}
+
+// Speedup returns the effective parallel speedup: the work of all processes
+// divided by the time the Gather took. It would equal the number of processes
+// if the work were split evenly and gathering the rows were free.
+func (r *ParallelRegion) Speedup() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+
+	return r.Work / r.Elapsed
+}
+
+// CriticalPath returns the index in Processes of the slowest process, which
+// the region has to wait for, or -1 when the processes are not known.
+func (r *ParallelRegion) CriticalPath() int {
+	slowest := -1
+
+	for index, process := range r.Processes {
+		if slowest == -1 || process.Time > r.Processes[slowest].Time {
+			slowest = index
+		}
+	}
+
+	return slowest
+}
+
+// Imbalance returns how many times the average time of the processes the
+// slowest one took; 1 is a perfectly even split, 0 means unknown.
+func (r *ParallelRegion) Imbalance() float64 {
+	slowest := r.CriticalPath()
+	if slowest == -1 || len(r.Processes) < 2 {
+		return 0
+	}
+
+	var total float64
+	for _, process := range r.Processes {
+		total += process.Time
+	}
+
+	if total <= 0 {
+		return 0
+	}
+
+	return r.Processes[slowest].Time / (total / float64(len(r.Processes)))
+}
+
+// RenderParallelism renders the parallelism analysis.
+func (ex *Explain) RenderParallelism() string {
+	buf := new(bytes.Buffer)
+
+	if !ex.writeParallelism(buf) {
+		buf.WriteString("No parallel workers in the plan.\n")
+	}
+
+	return buf.String()
+}
+
+// writeParallelism renders the parallel regions, e.g.
+//
+//	Parallelism:
+//	  - Gather: 1 of 2 planned workers launched (1 short: ...), leader participating
+//	    - effective speedup: 1.60x with 2 processes (16.000 ms of work in 10.000 ms)
+//	    - leader: 6.000 ms, rows: 4000 (40%)
+//	    - worker 0: 10.000 ms, rows: 6000 (60%), critical path
+//	    - imbalance: the slowest process took 1.2x the average time
+//
+// and reports whether there was anything to render.
+func (ex *Explain) writeParallelism(writer io.Writer) bool {
+	regions := ex.Parallelism()
+	if len(regions) == 0 {
+		return false
+	}
+
+	_, _ = fmt.Fprintf(writer, "Parallelism:\n")
+
+	for index := range regions {
+		region := &regions[index]
+
+		launched := fmt.Sprintf("%d of %d planned workers launched", region.WorkersLaunched, region.WorkersPlanned)
+		if region.Plan.ActualLoops > 1 {
+			launched += fmt.Sprintf(" in the last of %d executions", region.Plan.ActualLoops)
+		}
+
+		if shortfall := region.Shortfall(); shortfall > 0 {
+			launched += fmt.Sprintf(" (%d short: max_parallel_workers or max_worker_processes reached)", shortfall)
+		}
+
+		leader := "leader participating"
+		if !region.LeaderParticipated {
+			leader = "leader not participating"
+		}
+
+		_, _ = fmt.Fprintf(writer, "  - %s: %s, %s\n", nodeCaption(region.Plan), launched, leader)
+
+		processes := region.WorkersLaunched
+		if region.LeaderParticipated {
+			processes++
+		}
+
+		_, _ = fmt.Fprintf(writer, "    - effective speedup: %.2fx with %d processes (%s of work in %s)\n", region.Speedup(),
+			processes, util.MillisecondsToString(region.Work), util.MillisecondsToString(region.Elapsed))
+
+		var totalRows float64
+		for _, process := range region.Processes {
+			totalRows += process.Rows
+		}
+
+		slowest := region.CriticalPath()
+
+		for index, process := range region.Processes {
+			name := fmt.Sprintf("worker %d", process.Worker)
+			if process.Worker == leaderWorkerNumber {
+				name = "leader"
+			}
+
+			line := fmt.Sprintf("    - %s: %s, rows: %s", name, util.MillisecondsToString(process.Time), formatActualRows(process.Rows))
+
+			if totalRows > 0 {
+				line += fmt.Sprintf(" (%.0f%%)", process.Rows/totalRows*100)
+			}
+
+			if index == slowest && len(region.Processes) > 1 {
+				line += ", critical path"
+			}
+
+			_, _ = fmt.Fprintln(writer, line)
+		}
+
+		if imbalance := region.Imbalance(); imbalance >= parallelImbalanceFactor {
+			_, _ = fmt.Fprintf(writer, "    - imbalance: the slowest process took %.1fx the average time\n", imbalance)
+		}
+
+		for _, parallelAppend := range region.Appends {
+			writeParallelAppend(writer, parallelAppend)
+		}
+	}
+
+	return true
+}
+
+// writeParallelAppend renders the slowest member of a Parallel Append: the
+// processes share out the members, so one slow member, e.g. a non-partial one
+// run by a single process, holds up the whole append.
+func writeParallelAppend(writer io.Writer, plan *Plan) {
+	var (
+		slowest *Plan
+		work    float64
+		members int
+	)
+
+	for index := range plan.Plans {
+		member := &plan.Plans[index]
+		if member.SubplanName != "" {
+			continue
+		}
+
+		members++
+		work += member.ActualTotalTime * float64(member.ActualLoops)
+
+		if slowest == nil || member.ActualTotalTime*float64(member.ActualLoops) > slowest.ActualTotalTime*float64(slowest.ActualLoops) {
+			slowest = member
+		}
+	}
+
+	if slowest == nil || work <= 0 {
+		return
+	}
+
+	slowestWork := slowest.ActualTotalTime * float64(slowest.ActualLoops)
+
+	_, _ = fmt.Fprintf(writer, "    - Parallel Append: the slowest of %d members is %s, %s (%.0f%% of the work)\n",
+		members, nodeCaption(slowest), util.MillisecondsToString(slowestWork), slowestWork/work*100)
+}
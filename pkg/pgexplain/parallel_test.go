@@ -0,0 +1,135 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inputJSONParallelShortfall is a parallel scan that got two of four planned
+// workers, one of which read most of the rows.
+const inputJSONParallelShortfall = `[{
+	"Plan": {
+		"Node Type": "Gather", "Workers Planned": 4, "Workers Launched": 2,
+		"Plan Rows": 9000, "Actual Total Time": 10.0, "Actual Rows": 9000, "Actual Loops": 1,
+		"Plans": [{
+			"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Parallel Aware": true,
+			"Relation Name": "orders", "Alias": "orders",
+			"Plan Rows": 3000, "Actual Total Time": 5.0, "Actual Rows": 3000, "Actual Loops": 3,
+			"Workers": [
+				{"Worker Number": 0, "Actual Total Time": 9.0, "Actual Rows": 6000, "Actual Loops": 1},
+				{"Worker Number": 1, "Actual Total Time": 3.0, "Actual Rows": 2000, "Actual Loops": 1}
+			]
+		}]
+	},
+	"Execution Time": 10.5
+}]`
+
+func TestParallelism(t *testing.T) {
+	explain, err := NewExplain(inputJSONParallelShortfall)
+	require.NoError(t, err)
+
+	regions := explain.Parallelism()
+	require.Len(t, regions, 1)
+
+	region := regions[0]
+	require.Equal(t, &explain.Plan, region.Plan)
+	require.Equal(t, uint(2), region.Shortfall())
+	require.True(t, region.LeaderParticipated)
+	require.Equal(t, []ParallelProcess{
+		{Worker: leaderWorkerNumber, Time: 3, Rows: 1000},
+		{Worker: 0, Time: 9, Rows: 6000},
+		{Worker: 1, Time: 3, Rows: 2000},
+	}, region.Processes)
+	require.Equal(t, 1, region.CriticalPath())
+	require.InDelta(t, 1.5, region.Speedup(), 1e-9)
+	require.InDelta(t, 1.8, region.Imbalance(), 1e-9)
+
+	require.Equal(t, `Parallelism:
+  - Gather: 2 of 4 planned workers launched (2 short: max_parallel_workers or max_worker_processes reached), leader participating
+    - effective speedup: 1.50x with 3 processes (15.000 ms of work in 10.000 ms)
+    - leader: 3.000 ms, rows: 1000 (11%)
+    - worker 0: 9.000 ms, rows: 6000 (67%), critical path
+    - worker 1: 3.000 ms, rows: 2000 (22%)
+    - imbalance: the slowest process took 1.8x the average time
+`, explain.RenderParallelism())
+	require.Contains(t, explain.RenderStats(), "\nParallelism:\n  - Gather: 2 of 4 planned workers launched")
+}
+
+func TestParallelismParallelAppend(t *testing.T) {
+	// Without per-worker numbers (no VERBOSE) only the speedup is known; the
+	// leader ran no loop, so the members were shared by the two workers.
+	explain, err := NewExplain(`[{"Plan": {
+		"Node Type": "Gather", "Workers Planned": 2, "Workers Launched": 2,
+		"Actual Total Time": 6.5, "Actual Rows": 100, "Actual Loops": 1,
+		"Plans": [{
+			"Node Type": "Append", "Parent Relationship": "Outer", "Parallel Aware": true,
+			"Actual Total Time": 4.5, "Actual Rows": 50, "Actual Loops": 2,
+			"Plans": [
+				{"Node Type": "Seq Scan", "Parent Relationship": "Member", "Relation Name": "p1", "Alias": "p1",
+					"Actual Total Time": 6.0, "Actual Rows": 60, "Actual Loops": 1},
+				{"Node Type": "Seq Scan", "Parent Relationship": "Member", "Parallel Aware": true,
+					"Relation Name": "p2", "Alias": "p2",
+					"Actual Total Time": 1.5, "Actual Rows": 20, "Actual Loops": 2}
+			]
+		}]
+	}}]`)
+	require.NoError(t, err)
+
+	require.Equal(t, `Parallelism:
+  - Gather: 2 of 2 planned workers launched, leader not participating
+    - effective speedup: 1.38x with 2 processes (9.000 ms of work in 6.500 ms)
+    - Parallel Append: the slowest of 2 members is Seq Scan on p1, 6.000 ms (67% of the work)
+`, explain.RenderParallelism())
+}
+
+func TestParallelismRescannedGather(t *testing.T) {
+	// The Gather runs once per outer row. Workers Launched is the count of the
+	// last execution, not a sum, so all planned workers were launched.
+	explain, err := NewExplain(`[{"Plan": {
+		"Node Type": "Nested Loop", "Join Type": "Inner",
+		"Actual Total Time": 40.0, "Actual Rows": 50, "Actual Loops": 1,
+		"Plans": [
+			{"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Relation Name": "users", "Alias": "users",
+				"Actual Total Time": 0.5, "Actual Rows": 5, "Actual Loops": 1},
+			{"Node Type": "Gather", "Parent Relationship": "Inner", "Workers Planned": 2, "Workers Launched": 2,
+				"Actual Total Time": 7.5, "Actual Rows": 10, "Actual Loops": 5,
+				"Plans": [{
+					"Node Type": "Seq Scan", "Parent Relationship": "Outer", "Parallel Aware": true,
+					"Relation Name": "orders", "Alias": "orders",
+					"Actual Total Time": 6.0, "Actual Rows": 3, "Actual Loops": 15
+				}]}
+		]
+	}}]`)
+	require.NoError(t, err)
+
+	regions := explain.Parallelism()
+	require.Len(t, regions, 1)
+
+	region := regions[0]
+	require.Equal(t, uint(2), region.WorkersPlanned)
+	require.Equal(t, uint(2), region.WorkersLaunched)
+	require.Zero(t, region.Shortfall())
+	require.Contains(t, explain.RenderParallelism(),
+		"  - Gather: 2 of 2 planned workers launched in the last of 5 executions, leader participating\n")
+}
+
+func TestParallelismWithoutParallelism(t *testing.T) {
+	explain, err := NewExplain(inputJSONMisestimate)
+	require.NoError(t, err)
+
+	require.Empty(t, explain.Parallelism())
+	require.Equal(t, "No parallel workers in the plan.\n", explain.RenderParallelism())
+	require.NotContains(t, explain.RenderStats(), "Parallelism")
+
+	// Without ANALYZE nothing ran, so there is nothing to analyze.
+	explain, err = NewExplain(`[{"Plan": {"Node Type": "Gather", "Workers Planned": 2,
+		"Plans": [{"Node Type": "Seq Scan", "Parallel Aware": true, "Relation Name": "t"}]}}]`)
+	require.NoError(t, err)
+
+	require.Empty(t, explain.Parallelism())
+}
@@ -12,7 +12,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -30,12 +32,16 @@ const (
 // callers (pkg/bot/command) choose which version-gated options to include.
 const (
 	// ExplainAnalyzeQuery is the EXPLAIN form joe issues; %s carries the
-	// version-gated options (SETTINGS, WAL).
+	// version-gated options (SETTINGS, WAL, SERIALIZE, MEMORY).
 	ExplainAnalyzeQuery = "EXPLAIN (ANALYZE, COSTS, VERBOSE, BUFFERS, FORMAT JSON %s) "
 	// ExplainSettingsOption enables SETTINGS output (PostgreSQL 12+).
 	ExplainSettingsOption = ", SETTINGS TRUE"
 	// ExplainWALOption enables WAL output (PostgreSQL 13+).
 	ExplainWALOption = ", WAL"
+	// ExplainSerializeOption enables SERIALIZE output (PostgreSQL 17+).
+	ExplainSerializeOption = ", SERIALIZE"
+	// ExplainMemoryOption enables MEMORY output (PostgreSQL 17+).
+	ExplainMemoryOption = ", MEMORY"
 )
 
 type NodeType string
@@ -92,6 +98,10 @@ const (
 type Explain struct {
 	Plan     Plan      `json:"Plan"`
 	Triggers []Trigger `json:"Triggers"`
+	Planning *Planning `json:"Planning"` // PostgreSQL 13+
+	JIT      *JIT      `json:"JIT"`
+
+	Serialization *Serialization `json:"Serialization"` // PostgreSQL 17+
 
 	QueryIdentifier int64             `json:"Query Identifier"` // PostgreSQL queryid is a signed 64-bit value and is frequently negative.
 	Settings        map[string]string `json:"Settings"`
@@ -136,28 +146,8 @@ type Trigger struct {
 type Plan struct {
 	Plans []Plan `json:"Plans"`
 
-	// Buffers.
-	SharedHitBlocks     uint64 `json:"Shared Hit Blocks"`
-	SharedReadBlocks    uint64 `json:"Shared Read Blocks"`
-	SharedDirtiedBlocks uint64 `json:"Shared Dirtied Blocks"`
-	SharedWrittenBlocks uint64 `json:"Shared Written Blocks"`
-	LocalHitBlocks      uint64 `json:"Local Hit Blocks"`
-	LocalReadBlocks     uint64 `json:"Local Read Blocks"`
-	LocalDirtiedBlocks  uint64 `json:"Local Dirtied Blocks"`
-	LocalWrittenBlocks  uint64 `json:"Local Written Blocks"`
-	TempReadBlocks      uint64 `json:"Temp Read Blocks"`
-	TempWrittenBlocks   uint64 `json:"Temp Written Blocks"`
-
-	// IO timing. PostgreSQL 17+ replaces the aggregate fields with per-buffer-type splits;
-	// normalizeIOTiming folds the splits back into IOReadTime/IOWriteTime when those are nil.
-	IOReadTime        *float64 `json:"I/O Read Time,omitempty"`         // ms
-	IOWriteTime       *float64 `json:"I/O Write Time,omitempty"`        // ms
-	SharedIOReadTime  *float64 `json:"Shared I/O Read Time,omitempty"`  // ms
-	SharedIOWriteTime *float64 `json:"Shared I/O Write Time,omitempty"` // ms
-	LocalIOReadTime   *float64 `json:"Local I/O Read Time,omitempty"`   // ms
-	LocalIOWriteTime  *float64 `json:"Local I/O Write Time,omitempty"`  // ms
-	TempIOReadTime    *float64 `json:"Temp I/O Read Time,omitempty"`    // ms
-	TempIOWriteTime   *float64 `json:"Temp I/O Write Time,omitempty"`   // ms
+	// Buffers and IO timing.
+	BufferUsage
 
 	// Actual.
 	ActualLoops       uint64  `json:"Actual Loops"`
@@ -172,10 +162,10 @@ type Plan struct {
 	TotalCost   float64 `json:"Total Cost"`
 
 	// WAL.
-	WALRecords     uint64 `json:"WAL Records,omitempty"`
-	WALFPI         uint64 `json:"WAL FPI,omitempty"`
-	WALBytes       uint64 `json:"WAL Bytes,omitempty"`
-	WALBuffersFull uint64 `json:"WAL Buffers Full,omitempty"` // PostgreSQL 18+
+	WALUsage
+
+	// Parallel workers, reported by parallel-aware nodes and those below them.
+	Workers []Worker `json:"Workers"`
 
 	// PostgreSQL 18+ per-node fields, absent on older servers; renderers emit them
 	// only when present (true / non-zero / non-empty), so pre-18 output is unchanged.
@@ -259,8 +249,16 @@ type Plan struct {
 	RunCondition string `json:"Run Condition"`
 
 	// Calculated params.
-	ActualCost                  float64
-	ActualDuration              float64
+	ActualCost float64
+	// ActualDuration is the exclusive (self) time of the node over all its
+	// loops, ms; see calculateExclusives.
+	ActualDuration float64
+	// InclusiveDuration is the time of the node and everything below it over
+	// all its loops, ms. Inside a parallel region it is the per-process average.
+	InclusiveDuration float64
+	// ExclusiveBufferUsage holds the buffers and I/O time of the node itself,
+	// summed over the leader and the workers like the reported counters.
+	ExclusiveBufferUsage        BufferUsage
 	Costliest                   bool
 	Largest                     bool
 	PlannerRowEstimateDirection EstimateDirection
@@ -268,6 +266,129 @@ type Plan struct {
 	Slowest                     bool
 }
 
+// BufferUsage holds the buffer counters and I/O timings (BUFFERS) of a plan
+// node, a parallel worker or the planning phase.
+type BufferUsage struct {
+	SharedHitBlocks     uint64 `json:"Shared Hit Blocks"`
+	SharedReadBlocks    uint64 `json:"Shared Read Blocks"`
+	SharedDirtiedBlocks uint64 `json:"Shared Dirtied Blocks"`
+	SharedWrittenBlocks uint64 `json:"Shared Written Blocks"`
+	LocalHitBlocks      uint64 `json:"Local Hit Blocks"`
+	LocalReadBlocks     uint64 `json:"Local Read Blocks"`
+	LocalDirtiedBlocks  uint64 `json:"Local Dirtied Blocks"`
+	LocalWrittenBlocks  uint64 `json:"Local Written Blocks"`
+	TempReadBlocks      uint64 `json:"Temp Read Blocks"`
+	TempWrittenBlocks   uint64 `json:"Temp Written Blocks"`
+
+	// IO timing. PostgreSQL 17+ replaces the aggregate fields with per-buffer-type splits;
+	// foldIOTiming folds the splits back into IOReadTime/IOWriteTime when those are nil.
+	IOReadTime        *float64 `json:"I/O Read Time,omitempty"`         // ms
+	IOWriteTime       *float64 `json:"I/O Write Time,omitempty"`        // ms
+	SharedIOReadTime  *float64 `json:"Shared I/O Read Time,omitempty"`  // ms
+	SharedIOWriteTime *float64 `json:"Shared I/O Write Time,omitempty"` // ms
+	LocalIOReadTime   *float64 `json:"Local I/O Read Time,omitempty"`   // ms
+	LocalIOWriteTime  *float64 `json:"Local I/O Write Time,omitempty"`  // ms
+	TempIOReadTime    *float64 `json:"Temp I/O Read Time,omitempty"`    // ms
+	TempIOWriteTime   *float64 `json:"Temp I/O Write Time,omitempty"`   // ms
+}
+
+// WALUsage holds the WAL counters (WAL, PostgreSQL 13+) of a plan node or a
+// parallel worker.
+type WALUsage struct {
+	WALRecords     uint64 `json:"WAL Records,omitempty"`
+	WALFPI         uint64 `json:"WAL FPI,omitempty"`
+	WALBytes       uint64 `json:"WAL Bytes,omitempty"`
+	WALBuffersFull uint64 `json:"WAL Buffers Full,omitempty"` // PostgreSQL 18+
+}
+
+// Worker holds what one parallel worker reported for a plan node: timing,
+// buffers and WAL under VERBOSE, sort, hash aggregate and memoize details
+// whenever the worker ran them.
+type Worker struct {
+	WorkerNumber      int     `json:"Worker Number"`
+	ActualStartupTime float64 `json:"Actual Startup Time"`
+	ActualTotalTime   float64 `json:"Actual Total Time"`
+	ActualRows        float64 `json:"Actual Rows"`
+	ActualLoops       uint64  `json:"Actual Loops"`
+
+	SortMethod    string `json:"Sort Method"`
+	SortSpaceType string `json:"Sort Space Type"`
+	SortSpaceUsed uint64 `json:"Sort Space Used"` // kB
+
+	HashAggBatches  uint64 `json:"HashAgg Batches"`
+	PeakMemoryUsage uint64 `json:"Peak Memory Usage"` // kB
+	DiskUsage       uint64 `json:"Disk Usage"`        // kB
+
+	CacheHits      uint64 `json:"Cache Hits"`
+	CacheMisses    uint64 `json:"Cache Misses"`
+	CacheEvictions uint64 `json:"Cache Evictions"`
+	CacheOverflows uint64 `json:"Cache Overflows"`
+
+	BufferUsage
+	WALUsage
+}
+
+// Planning holds the resources used by the planner (BUFFERS, PostgreSQL 13+,
+// and MEMORY, PostgreSQL 17+).
+type Planning struct {
+	BufferUsage
+
+	MemoryUsed      uint64 `json:"Memory Used"`      // kB
+	MemoryAllocated uint64 `json:"Memory Allocated"` // kB
+}
+
+// Serialization holds the cost of converting the result rows to the wire
+// format (SERIALIZE, PostgreSQL 17+). The rows are not sent to the client.
+type Serialization struct {
+	Time         *float64 `json:"Time"`          // ms; absent with TIMING OFF.
+	OutputVolume uint64   `json:"Output Volume"` // kB
+	Format       string   `json:"Format"`
+
+	BufferUsage
+}
+
+// JIT describes the just-in-time compilation of the query.
+type JIT struct {
+	Functions uint64     `json:"Functions"`
+	Options   JITOptions `json:"Options"`
+	Timing    *JITTiming `json:"Timing"` // EXPLAIN ANALYZE only.
+}
+
+// JITOptions are the JIT features enabled for the query.
+type JITOptions struct {
+	Inlining     bool `json:"Inlining"`
+	Optimization bool `json:"Optimization"`
+	Expressions  bool `json:"Expressions"`
+	Deforming    bool `json:"Deforming"`
+}
+
+// JITTiming holds the time spent on each JIT stage, in ms.
+type JITTiming struct {
+	Generation   JITGeneration `json:"Generation"`
+	Inlining     float64       `json:"Inlining"`
+	Optimization float64       `json:"Optimization"`
+	Emission     float64       `json:"Emission"`
+	Total        float64       `json:"Total"`
+}
+
+// JITGeneration is the code generation time. PostgreSQL 17+ reports it as an
+// object that splits out tuple deforming; older versions as a plain number.
+type JITGeneration struct {
+	Deform *float64 `json:"Deform"` // PostgreSQL 17+
+	Total  float64  `json:"Total"`
+}
+
+// UnmarshalJSON accepts both forms of the generation time.
+func (g *JITGeneration) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return json.Unmarshal(data, &g.Total)
+	}
+
+	type generation JITGeneration
+
+	return json.Unmarshal(data, (*generation)(g))
+}
+
 // SortGroups holds the per-group statistics PostgreSQL reports under EXPLAIN
 // ANALYZE for one category of Incremental Sort groups ("Full-sort" or
 // "Pre-sorted"); a Plan carries one pointer per category.
@@ -293,24 +414,78 @@ type Tip struct {
 }
 
 // Explain Processing.
-func NewExplain(explainJSON string) (*Explain, error) {
-	var explains []Explain
-
-	err := json.NewDecoder(strings.NewReader(explainJSON)).Decode(&explains)
 
+// NewExplain processes an EXPLAIN document in JSON, YAML or XML format, as
+// returned by EXPLAIN (FORMAT ...) or logged by auto_explain; the format is
+// detected from the input. Text plans are parsed with NewExplainFromText.
+// Documents with several plans yield the first one; see NewExplains.
+func NewExplain(explain string) (*Explain, error) {
+	explains, err := NewExplains(explain)
 	if err != nil {
 		return nil, err
 	}
 
+	return explains[0], nil
+}
+
+// NewExplains processes every plan of an EXPLAIN document in JSON, YAML or XML
+// format: a multi-statement EXPLAIN result, auto_explain output of nested
+// statements, or several dumps concatenated together.
+func NewExplains(explain string) ([]*Explain, error) {
+	format := DetectFormat(explain)
+	if format == FormatText {
+		format = FormatJSON
+	}
+
+	return ParseExplains(explain, format)
+}
+
+// newExplainsFromJSON decodes a sequence of JSON values, each either a list of
+// plans as returned by EXPLAIN or a single plan as logged by auto_explain.
+func newExplainsFromJSON(explainJSON string) ([]*Explain, error) {
+	var explains []*Explain
+
+	decoder := json.NewDecoder(strings.NewReader(explainJSON))
+
+	for {
+		var document json.RawMessage
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		var batch []Explain
+
+		if bytes.HasPrefix(document, []byte("{")) {
+			batch = make([]Explain, 1)
+			err = json.Unmarshal(document, &batch[0])
+		} else {
+			err = json.Unmarshal(document, &batch)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for index := range batch {
+			explains = append(explains, &batch[index])
+		}
+	}
+
 	if len(explains) == 0 {
 		return nil, errors.New("Empty explain")
 	}
 
-	// TODO(anatoly): Is it possible to have more than one explain?
-	var ex = &explains[0]
-	ex.processExplain()
+	for _, ex := range explains {
+		ex.processExplain()
+	}
 
-	return ex, nil
+	return explains, nil
 }
 
 func (ex *Explain) RenderPlanText() string {
@@ -325,24 +500,52 @@ func (ex *Explain) RenderStats() string {
 	return buf.String()
 }
 
+// RenderExplains renders the plan and stats of each explain in turn, headed by
+// its position when there is more than one.
+func RenderExplains(explains []*Explain) string {
+	buf := new(bytes.Buffer)
+
+	for index, ex := range explains {
+		if len(explains) > 1 {
+			if index > 0 {
+				buf.WriteString("\n")
+			}
+
+			fmt.Fprintf(buf, "Plan %d of %d:\n", index+1, len(explains))
+		}
+
+		ex.writeExplainText(buf)
+		ex.writeStatsText(buf)
+	}
+
+	return buf.String()
+}
+
 func (ex *Explain) processExplain() {
 	ex.Plan.normalizeIOTiming()
+
+	if ex.Planning != nil {
+		ex.Planning.foldIOTiming()
+	}
+
+	if ex.Serialization != nil {
+		ex.Serialization.foldIOTiming()
+	}
+
 	ex.calculateParams()
+	ex.calculateExclusives()
 
 	ex.processPlan(&ex.Plan)
 	ex.calculateOutlierNodes(&ex.Plan)
 }
 
-// normalizeIOTiming folds PostgreSQL 17+ per-buffer-type I/O timings
-// (Shared/Local/Temp) into the legacy IOReadTime/IOWriteTime fields when those
-// are absent, so downstream rendering stays version-agnostic.
+// normalizeIOTiming folds the I/O timings of the node, its workers and its
+// descendants (see foldIOTiming).
 func (plan *Plan) normalizeIOTiming() {
-	if plan.IOReadTime == nil {
-		plan.IOReadTime = sumFloat64Pointers(plan.SharedIOReadTime, plan.LocalIOReadTime, plan.TempIOReadTime)
-	}
+	plan.foldIOTiming()
 
-	if plan.IOWriteTime == nil {
-		plan.IOWriteTime = sumFloat64Pointers(plan.SharedIOWriteTime, plan.LocalIOWriteTime, plan.TempIOWriteTime)
+	for index := range plan.Workers {
+		plan.Workers[index].foldIOTiming()
 	}
 
 	for index := range plan.Plans {
@@ -350,6 +553,19 @@ func (plan *Plan) normalizeIOTiming() {
 	}
 }
 
+// foldIOTiming folds PostgreSQL 17+ per-buffer-type I/O timings
+// (Shared/Local/Temp) into the legacy IOReadTime/IOWriteTime fields when those
+// are absent, so downstream rendering stays version-agnostic.
+func (u *BufferUsage) foldIOTiming() {
+	if u.IOReadTime == nil {
+		u.IOReadTime = sumFloat64Pointers(u.SharedIOReadTime, u.LocalIOReadTime, u.TempIOReadTime)
+	}
+
+	if u.IOWriteTime == nil {
+		u.IOWriteTime = sumFloat64Pointers(u.SharedIOWriteTime, u.LocalIOWriteTime, u.TempIOWriteTime)
+	}
+}
+
 func sumFloat64Pointers(values ...*float64) *float64 {
 	var (
 		total float64
@@ -429,13 +645,13 @@ func (ex *Explain) calculatePlannerEstimate(plan *Plan) {
 	}
 }
 
+// calculateActuals sets the exclusive cost of the node; its exclusive time is
+// set by calculateExclusives.
 func (ex *Explain) calculateActuals(plan *Plan) {
-	plan.ActualDuration = plan.ActualTotalTime
 	plan.ActualCost = plan.TotalCost
 
 	for _, child := range plan.Plans {
 		if child.NodeType != CTEScan {
-			plan.ActualDuration = plan.ActualDuration - child.ActualTotalTime
 			plan.ActualCost = plan.ActualCost - child.TotalCost
 		}
 	}
@@ -445,8 +661,6 @@ func (ex *Explain) calculateActuals(plan *Plan) {
 	}
 
 	ex.TotalCost = ex.TotalCost + plan.ActualCost
-
-	plan.ActualDuration = plan.ActualDuration * float64(plan.ActualLoops)
 }
 
 func (ex *Explain) calculateMaximums(plan *Plan) {
@@ -485,6 +699,87 @@ func (ex *Explain) writeExplainText(writer io.Writer) {
 	if ex.QueryIdentifier != 0 {
 		_, _ = fmt.Fprintf(writer, "Query ID: %d\n", ex.QueryIdentifier)
 	}
+
+	ex.writePlanningText(writer)
+	ex.writeJITText(writer)
+	ex.writeSerializationText(writer)
+}
+
+// writePlanningText renders the resources used by the planner. Like
+// PostgreSQL, the block is omitted when there is nothing to report.
+func (ex *Explain) writePlanningText(writer io.Writer) {
+	if ex.Planning == nil {
+		return
+	}
+
+	buffers := bufferUsageText(&ex.Planning.BufferUsage)
+	ioTimings := ioTimingsText(&ex.Planning.BufferUsage)
+
+	if buffers == "" && ioTimings == "" && ex.Planning.MemoryAllocated == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprint(writer, "Planning:\n")
+
+	if buffers != "" {
+		_, _ = fmt.Fprintf(writer, "  Buffers: %s\n", buffers)
+	}
+
+	if ioTimings != "" {
+		_, _ = fmt.Fprintf(writer, "  I/O Timings: %s\n", ioTimings)
+	}
+
+	if ex.Planning.MemoryAllocated != 0 {
+		_, _ = fmt.Fprintf(writer, "  Memory: used=%dkB  allocated=%dkB\n", ex.Planning.MemoryUsed, ex.Planning.MemoryAllocated)
+	}
+}
+
+// writeSerializationText renders the SERIALIZE summary and the buffers it used.
+func (ex *Explain) writeSerializationText(writer io.Writer) {
+	serialization := ex.Serialization
+	if serialization == nil {
+		return
+	}
+
+	timing := ""
+	if serialization.Time != nil {
+		timing = fmt.Sprintf("time=%.3f ms  ", *serialization.Time)
+	}
+
+	_, _ = fmt.Fprintf(writer, "Serialization: %soutput=%dkB  format=%s\n", timing, serialization.OutputVolume, serialization.Format)
+
+	if buffers := bufferUsageText(&serialization.BufferUsage); buffers != "" {
+		_, _ = fmt.Fprintf(writer, "  Buffers: %s\n", buffers)
+	}
+
+	if ioTimings := ioTimingsText(&serialization.BufferUsage); ioTimings != "" {
+		_, _ = fmt.Fprintf(writer, "  I/O Timings: %s\n", ioTimings)
+	}
+}
+
+// writeJITText renders the JIT summary; the timing line is only there under ANALYZE.
+func (ex *Explain) writeJITText(writer io.Writer) {
+	if ex.JIT == nil {
+		return
+	}
+
+	jit := ex.JIT
+
+	_, _ = fmt.Fprintf(writer, "JIT:\n  Functions: %d\n", jit.Functions)
+	_, _ = fmt.Fprintf(writer, "  Options: Inlining %t, Optimization %t, Expressions %t, Deforming %t\n",
+		jit.Options.Inlining, jit.Options.Optimization, jit.Options.Expressions, jit.Options.Deforming)
+
+	if jit.Timing == nil {
+		return
+	}
+
+	generation := fmt.Sprintf("%.3f ms", jit.Timing.Generation.Total)
+	if jit.Timing.Generation.Deform != nil { // PostgreSQL 17+
+		generation += fmt.Sprintf(" (Deform %.3f ms)", *jit.Timing.Generation.Deform)
+	}
+
+	_, _ = fmt.Fprintf(writer, "  Timing: Generation %s, Inlining %.3f ms, Optimization %.3f ms, Emission %.3f ms, Total %.3f ms\n",
+		generation, jit.Timing.Inlining, jit.Timing.Optimization, jit.Timing.Emission, jit.Timing.Total)
 }
 
 func (ex *Explain) writeExplainTextWithoutCosts(writer io.Writer) {
@@ -503,6 +798,12 @@ func (ex *Explain) writeExplainTextWithoutCosts(writer io.Writer) {
 func (ex *Explain) writeStatsText(writer io.Writer) {
 	fmt.Fprintf(writer, "\nTime: %s\n", util.MillisecondsToString(ex.TotalTime))
 	fmt.Fprintf(writer, "  - planning: %s\n", util.MillisecondsToString(ex.PlanningTime))
+
+	if ex.Planning != nil && ex.Planning.MemoryAllocated != 0 {
+		fmt.Fprintf(writer, "    - memory: %s used, %s allocated\n",
+			kilobytesToBytes(ex.Planning.MemoryUsed), kilobytesToBytes(ex.Planning.MemoryAllocated))
+	}
+
 	fmt.Fprintf(writer, "  - execution: %s\n", util.MillisecondsToString(ex.ExecutionTime))
 
 	ioRead := util.NA
@@ -519,6 +820,20 @@ func (ex *Explain) writeStatsText(writer io.Writer) {
 
 	fmt.Fprintf(writer, "    - I/O write: %s\n", ioWrite)
 
+	if ex.JIT != nil && ex.JIT.Timing != nil {
+		fmt.Fprintf(writer, "    - JIT: %s (%d functions)\n", util.MillisecondsToString(ex.JIT.Timing.Total), ex.JIT.Functions)
+	}
+
+	if ex.Serialization != nil {
+		serializationTime := util.NA
+		if ex.Serialization.Time != nil {
+			serializationTime = util.MillisecondsToString(*ex.Serialization.Time)
+		}
+
+		fmt.Fprintf(writer, "    - serialization: %s (%s of %s output)\n", serializationTime,
+			kilobytesToBytes(ex.Serialization.OutputVolume), ex.Serialization.Format)
+	}
+
 	fmt.Fprintf(writer, "\nShared buffers:\n")
 	ex.writeBlocks(writer, "hits", ex.SharedHitBlocks, "from the buffer pool")
 	ex.writeBlocks(writer, "reads", ex.SharedReadBlocks, "from the OS file cache, including disk I/O")
@@ -551,6 +866,31 @@ func (ex *Explain) writeStatsText(writer io.Writer) {
 	if ex.TempWrittenBlocks > 0 {
 		ex.writeBlocks(writer, "writes", ex.TempWrittenBlocks, "")
 	}
+
+	if ex.Planning != nil && (ex.Planning.SharedHitBlocks > 0 || ex.Planning.SharedReadBlocks > 0 ||
+		ex.Planning.SharedDirtiedBlocks > 0 || ex.Planning.SharedWrittenBlocks > 0) {
+		fmt.Fprintf(writer, "\nPlanning buffers:\n")
+		ex.writeBlocks(writer, "hits", ex.Planning.SharedHitBlocks, "")
+		ex.writeBlocks(writer, "reads", ex.Planning.SharedReadBlocks, "")
+		if ex.Planning.SharedDirtiedBlocks > 0 {
+			ex.writeBlocks(writer, "dirtied", ex.Planning.SharedDirtiedBlocks, "")
+		}
+		if ex.Planning.SharedWrittenBlocks > 0 {
+			ex.writeBlocks(writer, "writes", ex.Planning.SharedWrittenBlocks, "")
+		}
+	}
+
+	ex.writeNodeTypeBreakdown(writer)
+
+	parallelism := new(bytes.Buffer)
+	if ex.writeParallelism(parallelism) {
+		fmt.Fprintf(writer, "\n%s", parallelism)
+	}
+
+	misestimates := new(bytes.Buffer)
+	if ex.writeMisestimates(misestimates) {
+		fmt.Fprintf(writer, "\n%s", misestimates)
+	}
 }
 
 func (ex *Explain) writeBlocks(writer io.Writer, name string, blocks uint64, cmmt string) {
@@ -746,6 +1086,20 @@ func writePlanTextNodeCaption(outputFn func(string, ...interface{}) (int, error)
 	_, _ = outputFn("%s%v%s%s%s%s", parallel, nodeType, details, using, on, costsAndTiming)
 }
 
+// nodeCaption returns the node's text-plan caption without costs and timing,
+// e.g. "Index Scan using orders_pkey on public.orders", so analyses can name a
+// node exactly as the rendered plan does.
+func nodeCaption(plan *Plan) string {
+	caption := ""
+
+	writePlanTextNodeCaption(func(format string, a ...interface{}) (int, error) {
+		caption = fmt.Sprintf(format, a...)
+		return len(caption), nil
+	}, plan, false)
+
+	return caption
+}
+
 // bufferCounter is one labelled counter ("hit=42") within a Buffers section.
 type bufferCounter struct {
 	label string
@@ -932,45 +1286,127 @@ func writePlanTextNodeDetails(outputFn func(string, ...interface{}) (int, error)
 		_, _ = outputFn("Workers Launched: %d", plan.WorkersLaunched)
 	}
 
+	if buffers := bufferUsageText(&plan.BufferUsage); buffers != "" {
+		outputFn("Buffers: %s", buffers)
+	}
+
+	if wal := walUsageText(&plan.WALUsage); wal != "" {
+		_, _ = outputFn("WAL: %s", wal)
+	}
+
+	if ioTimings := ioTimingsText(&plan.BufferUsage); ioTimings != "" {
+		outputFn("I/O Timings: %s", ioTimings)
+	}
+
+	// Per-worker statistics come last, one block per worker.
+	for index := range plan.Workers {
+		writeWorkerText(outputFn, &plan.Workers[index])
+	}
+}
+
+// bufferUsageText renders the value of a Buffers line; empty when every
+// counter is zero.
+func bufferUsageText(usage *BufferUsage) string {
 	buffers := ""
 	buffers = appendBufferSection(buffers, "shared",
-		bufferCounter{"hit", plan.SharedHitBlocks},
-		bufferCounter{"read", plan.SharedReadBlocks},
-		bufferCounter{"dirtied", plan.SharedDirtiedBlocks},
-		bufferCounter{"written", plan.SharedWrittenBlocks})
+		bufferCounter{"hit", usage.SharedHitBlocks},
+		bufferCounter{"read", usage.SharedReadBlocks},
+		bufferCounter{"dirtied", usage.SharedDirtiedBlocks},
+		bufferCounter{"written", usage.SharedWrittenBlocks})
 	buffers = appendBufferSection(buffers, "local",
-		bufferCounter{"hit", plan.LocalHitBlocks},
-		bufferCounter{"read", plan.LocalReadBlocks},
-		bufferCounter{"dirtied", plan.LocalDirtiedBlocks},
-		bufferCounter{"written", plan.LocalWrittenBlocks})
+		bufferCounter{"hit", usage.LocalHitBlocks},
+		bufferCounter{"read", usage.LocalReadBlocks},
+		bufferCounter{"dirtied", usage.LocalDirtiedBlocks},
+		bufferCounter{"written", usage.LocalWrittenBlocks})
 	buffers = appendBufferSection(buffers, "temp",
-		bufferCounter{"read", plan.TempReadBlocks},
-		bufferCounter{"written", plan.TempWrittenBlocks})
+		bufferCounter{"read", usage.TempReadBlocks},
+		bufferCounter{"written", usage.TempWrittenBlocks})
 
-	if buffers != "" {
-		outputFn("Buffers: %s", buffers)
+	return buffers
+}
+
+// ioTimingsText renders the value of an I/O Timings line; empty when no
+// timing was tracked.
+func ioTimingsText(usage *BufferUsage) string {
+	var timings []string
+
+	if usage.IOReadTime != nil {
+		timings = append(timings, fmt.Sprintf("read=%.3f", *usage.IOReadTime))
+	}
+
+	if usage.IOWriteTime != nil {
+		timings = append(timings, fmt.Sprintf("write=%.3f", *usage.IOWriteTime))
 	}
 
-	if plan.WALRecords != 0 || plan.WALFPI != 0 || plan.WALBytes != 0 || plan.WALBuffersFull != 0 {
-		walLine := fmt.Sprintf("WAL: records=%d fpi=%d bytes=%d", plan.WALRecords, plan.WALFPI, plan.WALBytes)
-		if plan.WALBuffersFull != 0 { // PostgreSQL 18+
-			walLine += fmt.Sprintf(" buffers-full=%d", plan.WALBuffersFull)
+	return strings.Join(timings, " ")
+}
+
+// walUsageText renders the value of a WAL line; empty when no WAL was generated.
+func walUsageText(usage *WALUsage) string {
+	if usage.WALRecords == 0 && usage.WALFPI == 0 && usage.WALBytes == 0 && usage.WALBuffersFull == 0 {
+		return ""
+	}
+
+	wal := fmt.Sprintf("records=%d fpi=%d bytes=%d", usage.WALRecords, usage.WALFPI, usage.WALBytes)
+	if usage.WALBuffersFull != 0 { // PostgreSQL 18+
+		wal += fmt.Sprintf(" buffers-full=%d", usage.WALBuffersFull)
+	}
+
+	return wal
+}
+
+// writeWorkerText renders what a parallel worker reported for a node the way
+// PostgreSQL does: the first item follows the "Worker N:" label and the rest
+// are indented below it, e.g.
+//
+//	Worker 0:  actual time=0.090..0.095 rows=7 loops=1
+//	  Sort Method: quicksort  Memory: 26kB
+//	  Buffers: shared hit=999
+func writeWorkerText(outputFn func(string, ...interface{}) (int, error), worker *Worker) {
+	var items []string
+
+	if worker.ActualLoops != 0 {
+		items = append(items, fmt.Sprintf("actual time=%.3f..%.3f rows=%s loops=%d",
+			worker.ActualStartupTime, worker.ActualTotalTime, formatActualRows(worker.ActualRows), worker.ActualLoops))
+	}
+
+	if worker.SortMethod != "" {
+		items = append(items, fmt.Sprintf("Sort Method: %s  %s: %dkB", worker.SortMethod, worker.SortSpaceType, worker.SortSpaceUsed))
+	}
+
+	if worker.HashAggBatches != 0 {
+		item := fmt.Sprintf("Batches: %d  Memory Usage: %dkB", worker.HashAggBatches, worker.PeakMemoryUsage)
+		if worker.DiskUsage > 0 {
+			item += fmt.Sprintf("  Disk Usage: %dkB", worker.DiskUsage)
 		}
 
-		_, _ = outputFn(walLine)
+		items = append(items, item)
 	}
 
-	ioTiming := ""
-	if plan.IOReadTime != nil {
-		ioTiming += fmt.Sprintf(" read=%.3f", *plan.IOReadTime)
+	if worker.CacheHits > 0 || worker.CacheMisses > 0 {
+		items = append(items, fmt.Sprintf("Hits: %d  Misses: %d  Evictions: %d  Overflows: %d  Memory Usage: %dkB",
+			worker.CacheHits, worker.CacheMisses, worker.CacheEvictions, worker.CacheOverflows, worker.PeakMemoryUsage))
 	}
 
-	if plan.IOWriteTime != nil {
-		ioTiming += fmt.Sprintf(" write=%.3f", *plan.IOWriteTime)
+	if buffers := bufferUsageText(&worker.BufferUsage); buffers != "" {
+		items = append(items, "Buffers: "+buffers)
 	}
 
-	if len(ioTiming) > 0 {
-		outputFn("I/O Timings:%s", ioTiming)
+	if ioTimings := ioTimingsText(&worker.BufferUsage); ioTimings != "" {
+		items = append(items, "I/O Timings: "+ioTimings)
+	}
+
+	if wal := walUsageText(&worker.WALUsage); wal != "" {
+		items = append(items, "WAL: "+wal)
+	}
+
+	for index, item := range items {
+		if index == 0 {
+			_, _ = outputFn("Worker %d:  %s", worker.WorkerNumber, item)
+			continue
+		}
+
+		_, _ = outputFn("  %s", item)
 	}
 }
 
@@ -1042,11 +1478,12 @@ func formatDetails(plan *Plan) string {
 	return ""
 }
 
+// printMap lists the items sorted by key, so that the output does not depend on the order of the map.
 func printMap(items map[string]string) string {
 	list := make([]string, 0, len(items))
 
-	for key, value := range items {
-		list = append(list, fmt.Sprintf("%s = '%v'", key, value))
+	for _, key := range slices.Sorted(maps.Keys(items)) {
+		list = append(list, fmt.Sprintf("%s = '%v'", key, items[key]))
 	}
 
 	return strings.Join(list, ", ")
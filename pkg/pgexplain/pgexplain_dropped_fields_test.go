@@ -240,6 +240,97 @@ func TestDroppedFieldRunConditionDecodeSafety(t *testing.T) {
 		"Run Condition: ((row_number() OVER (?) <= 10) AND (rank() OVER (?) <= 20))")
 }
 
+// TestDroppedFieldJITLegacyGeneration covers the JIT summary of PostgreSQL
+// 11-16, where the generation time is a plain number rather than an object
+// with the deform split, and EXPLAIN without ANALYZE, which has no timing.
+func TestDroppedFieldJITLegacyGeneration(t *testing.T) {
+	const j = `[{
+		"Plan": {
+			"Node Type": "Result", "Parallel Aware": false,
+			"Startup Cost": 0.00, "Total Cost": 0.01, "Plan Rows": 1, "Plan Width": 4,
+			"Actual Startup Time": 0.001, "Actual Total Time": 0.001, "Actual Rows": 1, "Actual Loops": 1
+		},
+		"Planning Time": 0.1, "Triggers": [],
+		"JIT": {
+			"Functions": 2,
+			"Options": {"Inlining": true, "Optimization": true, "Expressions": true, "Deforming": true},
+			"Timing": {"Generation": 0.412, "Inlining": 10.5, "Optimization": 20.25, "Emission": 9.75, "Total": 40.912}
+		},
+		"Execution Time": 41.0
+	}]`
+
+	explain, err := NewExplain(j)
+	require.NoError(t, err)
+	require.Nil(t, explain.JIT.Timing.Generation.Deform)
+	require.Contains(t, explain.RenderPlanText(), "JIT:\n  Functions: 2\n"+
+		"  Options: Inlining true, Optimization true, Expressions true, Deforming true\n"+
+		"  Timing: Generation 0.412 ms, Inlining 10.500 ms, Optimization 20.250 ms, Emission 9.750 ms, Total 40.912 ms\n")
+
+	const withoutAnalyze = `[{
+		"Plan": {"Node Type": "Result", "Startup Cost": 0.00, "Total Cost": 0.01, "Plan Rows": 1, "Plan Width": 4},
+		"JIT": {
+			"Functions": 2,
+			"Options": {"Inlining": false, "Optimization": false, "Expressions": true, "Deforming": true}
+		}
+	}]`
+
+	explain, err = NewExplain(withoutAnalyze)
+	require.NoError(t, err)
+
+	out := explain.RenderPlanText()
+	require.Contains(t, out, "  Options: Inlining false, Optimization false, Expressions true, Deforming true\n")
+	require.NotContains(t, out, "Timing:")
+	require.NotContains(t, explain.RenderStats(), "JIT:")
+}
+
+// TestDroppedFieldJITXML checks that XML values are converted by the type of
+// the object they are in: "Inlining" is a flag among the options and a
+// duration in the timing.
+func TestDroppedFieldJITXML(t *testing.T) {
+	explain, err := ParseExplain(`<explain xmlns="http://www.postgresql.org/2009/explain">
+  <Query>
+    <Plan>
+      <Node-Type>Result</Node-Type>
+      <Total-Cost>0.01</Total-Cost>
+      <Workers>
+        <Worker>
+          <Worker-Number>0</Worker-Number>
+          <Actual-Loops>1</Actual-Loops>
+          <Shared-Hit-Blocks>7</Shared-Hit-Blocks>
+        </Worker>
+      </Workers>
+    </Plan>
+    <Planning>
+      <Shared-Hit-Blocks>12</Shared-Hit-Blocks>
+    </Planning>
+    <JIT>
+      <Functions>2</Functions>
+      <Options>
+        <Inlining>true</Inlining>
+        <Optimization>false</Optimization>
+        <Expressions>true</Expressions>
+        <Deforming>true</Deforming>
+      </Options>
+      <Timing>
+        <Generation>0.412</Generation>
+        <Inlining>10.500</Inlining>
+        <Optimization>0.000</Optimization>
+        <Emission>9.750</Emission>
+        <Total>20.662</Total>
+      </Timing>
+    </JIT>
+  </Query>
+</explain>`, FormatXML)
+	require.NoError(t, err)
+
+	require.True(t, explain.JIT.Options.Inlining)
+	require.Equal(t, 10.5, explain.JIT.Timing.Inlining)
+	require.Equal(t, 0.412, explain.JIT.Timing.Generation.Total)
+	require.Equal(t, uint64(12), explain.Planning.SharedHitBlocks)
+	require.Len(t, explain.Plan.Workers, 1)
+	require.Equal(t, uint64(7), explain.Plan.Workers[0].SharedHitBlocks)
+}
+
 // TestDroppedFieldsDecodeSafety guards against a wrong Go type for any added field:
 // every new fixture must decode cleanly (scalar-vs-array mismatches turn into a
 // whole-plan parse failure under the non-strict decoder).
@@ -285,6 +376,7 @@ func TestDroppedFieldsNoRegression(t *testing.T) {
 		"Rows Removed by Join Filter:", "Presorted Key:", "Full-sort Groups:",
 		"Pre-sorted Groups:", "Recheck Cond:", "Rows Removed by Index Recheck:",
 		"Heap Blocks:", "Planned Partitions:", "Disk Usage:", "Run Condition:",
+		"Worker ", "JIT:",
 	} {
 		require.NotContains(t, got, label,
 			"a Seq Scan plan must not trigger the %q dropped-field branch", label)
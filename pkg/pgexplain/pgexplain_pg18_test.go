@@ -164,3 +164,261 @@ func TestRenderPostgres18DetailFields(t *testing.T) {
 	require.Contains(t, out, "Storage: Memory  Maximum Storage: 17kB")
 	require.Contains(t, out, "WAL: records=6 fpi=0 bytes=369 buffers-full=2")
 }
+
+// inputJSONPostgres18JITWorkers is a parallel sort captured with
+// EXPLAIN (ANALYZE, VERBOSE, BUFFERS, WAL, FORMAT JSON) and JIT forced on: the
+// per-worker statistics, the planning buffers and the PostgreSQL 17+ JIT
+// timing with its deform split.
+const inputJSONPostgres18JITWorkers = `[
+  {
+    "Plan": {
+      "Node Type": "Gather Merge",
+      "Parallel Aware": false,
+      "Startup Cost": 1000.44,
+      "Total Cost": 9876.00,
+      "Plan Rows": 4000,
+      "Plan Width": 12,
+      "Actual Startup Time": 0.510,
+      "Actual Total Time": 12.010,
+      "Actual Rows": 10.00,
+      "Actual Loops": 1,
+      "Disabled": false,
+      "Workers Planned": 2,
+      "Workers Launched": 2,
+      "Shared Hit Blocks": 120,
+      "Shared Read Blocks": 30,
+      "Plans": [
+        {
+          "Node Type": "Sort",
+          "Parent Relationship": "Outer",
+          "Parallel Aware": false,
+          "Startup Cost": 0.42,
+          "Total Cost": 1.42,
+          "Plan Rows": 1667,
+          "Plan Width": 12,
+          "Actual Startup Time": 0.100,
+          "Actual Total Time": 0.105,
+          "Actual Rows": 3.33,
+          "Actual Loops": 3,
+          "Disabled": false,
+          "Sort Key": ["o.created_at DESC"],
+          "Sort Method": "quicksort",
+          "Sort Space Used": 25,
+          "Sort Space Type": "Memory",
+          "Shared Hit Blocks": 100,
+          "Shared Read Blocks": 30,
+          "Workers": [
+            {
+              "Worker Number": 0,
+              "Actual Startup Time": 0.090,
+              "Actual Total Time": 0.095,
+              "Actual Rows": 3.00,
+              "Actual Loops": 1,
+              "Sort Method": "quicksort",
+              "Sort Space Used": 26,
+              "Sort Space Type": "Memory",
+              "Shared Hit Blocks": 40,
+              "Shared Read Blocks": 10,
+              "Shared I/O Read Time": 0.750,
+              "WAL Records": 1,
+              "WAL FPI": 0,
+              "WAL Bytes": 99,
+              "WAL Buffers Full": 0
+            },
+            {
+              "Worker Number": 1,
+              "Actual Startup Time": 0.080,
+              "Actual Total Time": 0.085,
+              "Actual Rows": 3.50,
+              "Actual Loops": 1,
+              "Sort Method": "quicksort",
+              "Sort Space Used": 25,
+              "Sort Space Type": "Memory",
+              "Shared Hit Blocks": 30
+            }
+          ],
+          "Plans": [
+            {
+              "Node Type": "Seq Scan",
+              "Parent Relationship": "Outer",
+              "Parallel Aware": true,
+              "Relation Name": "orders",
+              "Schema": "public",
+              "Alias": "o",
+              "Startup Cost": 0.00,
+              "Total Cost": 800.00,
+              "Plan Rows": 1667,
+              "Plan Width": 12,
+              "Actual Startup Time": 0.010,
+              "Actual Total Time": 0.080,
+              "Actual Rows": 1667.00,
+              "Actual Loops": 3,
+              "Disabled": false,
+              "Shared Hit Blocks": 100,
+              "Shared Read Blocks": 30
+            }
+          ]
+        }
+      ]
+    },
+    "Planning": {
+      "Shared Hit Blocks": 12,
+      "Shared Read Blocks": 3,
+      "Shared Dirtied Blocks": 0,
+      "Shared Written Blocks": 0,
+      "Local Hit Blocks": 0,
+      "Local Read Blocks": 0,
+      "Local Dirtied Blocks": 0,
+      "Local Written Blocks": 0,
+      "Temp Read Blocks": 0,
+      "Temp Written Blocks": 0,
+      "Shared I/O Read Time": 0.250,
+      "Shared I/O Write Time": 0.000
+    },
+    "Planning Time": 0.321,
+    "Triggers": [],
+    "JIT": {
+      "Functions": 4,
+      "Options": {
+        "Inlining": false,
+        "Optimization": false,
+        "Expressions": true,
+        "Deforming": true
+      },
+      "Timing": {
+        "Generation": {
+          "Deform": 0.300,
+          "Total": 0.850
+        },
+        "Inlining": 0.000,
+        "Optimization": 0.400,
+        "Emission": 5.000,
+        "Total": 6.250
+      }
+    },
+    "Execution Time": 12.345
+  }
+]`
+
+func TestRenderPostgres18JITWorkers(t *testing.T) {
+	explain, err := NewExplain(inputJSONPostgres18JITWorkers)
+	require.NoError(t, err)
+
+	sortNode := explain.Plan.Plans[0]
+	require.Len(t, sortNode.Workers, 2)
+	require.Equal(t, "quicksort", sortNode.Workers[0].SortMethod)
+	require.NotNil(t, sortNode.Workers[0].IOReadTime, "per-worker I/O timings are folded too")
+	require.Equal(t, uint64(99), sortNode.Workers[0].WALBytes)
+
+	out := explain.RenderPlanText()
+
+	require.Contains(t, out, `
+         Buffers: shared hit=100 read=30
+         Worker 0:  actual time=0.090..0.095 rows=3 loops=1
+           Sort Method: quicksort  Memory: 26kB
+           Buffers: shared hit=40 read=10
+           I/O Timings: read=0.750
+           WAL: records=1 fpi=0 bytes=99
+         Worker 1:  actual time=0.080..0.085 rows=3.50 loops=1
+           Sort Method: quicksort  Memory: 25kB
+           Buffers: shared hit=30
+         ->  Parallel Seq Scan on public.orders o`)
+	require.Contains(t, out, `
+Planning:
+  Buffers: shared hit=12 read=3
+  I/O Timings: read=0.250 write=0.000
+JIT:
+  Functions: 4
+  Options: Inlining false, Optimization false, Expressions true, Deforming true
+  Timing: Generation 0.850 ms (Deform 0.300 ms), Inlining 0.000 ms, Optimization 0.400 ms, Emission 5.000 ms, Total 6.250 ms
+`)
+
+	stats := explain.RenderStats()
+	require.Contains(t, stats, "    - JIT: 6.250 ms (4 functions)\n")
+	require.Contains(t, stats, "\nPlanning buffers:\n  - hits: 12 (~96.00 KiB)\n  - reads: 3 (~24.00 KiB)\n")
+
+	// The text format carries the same blocks back.
+	fromText, err := NewExplainFromText(out)
+	require.NoError(t, err)
+	require.Equal(t, out, fromText.RenderPlanText())
+}
+
+// inputJSONPostgres18SerializeMemory is captured with the PostgreSQL 17+ form
+// joe issues, including SERIALIZE and MEMORY.
+const inputJSONPostgres18SerializeMemory = `[
+  {
+    "Plan": {
+      "Node Type": "Seq Scan",
+      "Parallel Aware": false,
+      "Relation Name": "t_items",
+      "Schema": "joecap",
+      "Alias": "t_items",
+      "Startup Cost": 0.00,
+      "Total Cost": 8.00,
+      "Plan Rows": 500,
+      "Plan Width": 8,
+      "Actual Startup Time": 0.005,
+      "Actual Total Time": 0.040,
+      "Actual Rows": 500.00,
+      "Actual Loops": 1,
+      "Disabled": false,
+      "Shared Hit Blocks": 3
+    },
+    "Planning": {
+      "Shared Hit Blocks": 52,
+      "Shared Read Blocks": 0,
+      "Shared Dirtied Blocks": 0,
+      "Shared Written Blocks": 0,
+      "Local Hit Blocks": 0,
+      "Local Read Blocks": 0,
+      "Local Dirtied Blocks": 0,
+      "Local Written Blocks": 0,
+      "Temp Read Blocks": 0,
+      "Temp Written Blocks": 0,
+      "Memory Used": 22,
+      "Memory Allocated": 32
+    },
+    "Planning Time": 0.091,
+    "Triggers": [],
+    "Serialization": {
+      "Time": 0.052,
+      "Output Volume": 12,
+      "Format": "text",
+      "Shared Hit Blocks": 2,
+      "Shared Read Blocks": 0,
+      "Shared Dirtied Blocks": 0,
+      "Shared Written Blocks": 0,
+      "Local Hit Blocks": 0,
+      "Local Read Blocks": 0,
+      "Local Dirtied Blocks": 0,
+      "Local Written Blocks": 0,
+      "Temp Read Blocks": 0,
+      "Temp Written Blocks": 0
+    },
+    "Execution Time": 0.145
+  }
+]`
+
+func TestRenderPostgres18SerializeMemory(t *testing.T) {
+	explain, err := NewExplain(inputJSONPostgres18SerializeMemory)
+	require.NoError(t, err)
+
+	out := explain.RenderPlanText()
+	require.Contains(t, out, `
+Planning:
+  Buffers: shared hit=52
+  Memory: used=22kB  allocated=32kB
+Serialization: time=0.052 ms  output=12kB  format=text
+  Buffers: shared hit=2
+`)
+
+	stats := explain.RenderStats()
+	require.Contains(t, stats, "  - planning: 0.091 ms\n    - memory: 22.00 KiB used, 32.00 KiB allocated\n")
+	require.Contains(t, stats, "    - serialization: 0.052 ms (12.00 KiB of text output)\n")
+
+	fromText, err := NewExplainFromText(out)
+	require.NoError(t, err)
+	require.Equal(t, out, fromText.RenderPlanText())
+	require.Equal(t, uint64(32), fromText.Planning.MemoryAllocated)
+	require.Equal(t, uint64(2), fromText.Serialization.SharedHitBlocks)
+}
@@ -1759,6 +1759,10 @@ Shared buffers:
   - reads: 71 (~568.00 KiB) from the OS file cache, including disk I/O
   - dirtied: 2 (~16.00 KiB)
   - writes: 2 (~16.00 KiB)
+
+Time by node type:
+  - 98% in Seq Scan on users: 9.196 ms, buffers: 79 (~632.00 KiB), I/O: 7.520 ms
+  - 2% in Limit: 0.161 ms
 `
 
 	assert.Equal(t, expected, explain.RenderStats())
@@ -0,0 +1,1036 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// textNodeLine splits a text plan node line into its caption, the estimate
+// clause and the actual clause. Both clauses are optional (COSTS OFF, EXPLAIN
+// without ANALYZE, TIMING OFF and "never executed" nodes).
+var textNodeLine = regexp.MustCompile(`^(->\s+)?(.*?)` +
+	`(?:\s+\(cost=(\d+\.\d+)\.\.(\d+\.\d+) rows=(\d+) width=(\d+)\))?` +
+	`(?:\s+\((?:actual (?:time=(\d+\.\d+)\.\.(\d+\.\d+) )?rows=(\d+(?:\.\d+)?) loops=(\d+)|(never executed))\))?$`)
+
+// Submatch indexes of textNodeLine.
+const (
+	textMatchArrow = iota + 1
+	textMatchCaption
+	textMatchStartupCost
+	textMatchTotalCost
+	textMatchPlanRows
+	textMatchPlanWidth
+	textMatchStartupTime
+	textMatchTotalTime
+	textMatchActualRows
+	textMatchLoops
+	textMatchNeverExecuted
+)
+
+var (
+	// textSubplanLine matches the label printed above an InitPlan, SubPlan or CTE subtree.
+	textSubplanLine = regexp.MustCompile(`^(InitPlan|SubPlan|CTE) [^:]+$`)
+
+	// textWorkerLine matches per-worker statistics, e.g. "Worker 0:  actual time=...".
+	textWorkerLine = regexp.MustCompile(`^Worker (\d+):\s+(.*)$`)
+
+	// textWorkerActual matches the actual clause of a worker, e.g. "actual time=0.090..0.095 rows=7 loops=1".
+	textWorkerActual = regexp.MustCompile(`^actual (?:time=(\d+\.\d+)\.\.(\d+\.\d+) )?rows=(\d+(?:\.\d+)?) loops=(\d+)$`)
+
+	// textSerialization matches the SERIALIZE summary; the time is missing with TIMING OFF.
+	textSerialization = regexp.MustCompile(`^(?:time=(\d+\.\d+) ms  )?output=(\d+)kB  format=(\S+)$`)
+
+	// textJITTiming matches the JIT timing line; the deform split is PostgreSQL 17+.
+	textJITTiming = regexp.MustCompile(`^Generation (\d+\.\d+) ms(?: \(Deform (\d+\.\d+) ms\))?, ` +
+		`Inlining (\d+\.\d+) ms, Optimization (\d+\.\d+) ms, Emission (\d+\.\d+) ms, Total (\d+\.\d+) ms$`)
+
+	// textTriggerLine matches "Trigger <name>[ for constraint <name>][ on <rel>]: time=... calls=...".
+	textTriggerLine = regexp.MustCompile(`^Trigger (.+?)(?: for constraint (.+?))?(?: on (.+?))?: time=(\d+(?:\.\d+)?) calls=(\d+)$`)
+
+	// textAggregateCaption matches the captions an Aggregate node is printed with.
+	textAggregateCaption = regexp.MustCompile(`^(Partial |Finalize )?(Hash|Group|Mixed)?Aggregate$`)
+
+	// textJoinCaption matches join captions, e.g. "Hash Left Join" or "Nested Loop Anti Join".
+	textJoinCaption = regexp.MustCompile(`^(Hash|Merge|Nested Loop)(?: (Left|Right|Full|Semi|Anti|Right Semi|Right Anti))? Join$`)
+
+	// textSetOpCaption matches SetOp captions, e.g. "HashSetOp Except All".
+	textSetOpCaption = regexp.MustCompile(`^(Hash)?SetOp \S.*$`)
+)
+
+// textModifyOperations are the operations a ModifyTable node is printed with.
+var textModifyOperations = []string{"Insert", "Update", "Delete", "Merge"}
+
+// textNodeTypes lists the node types recognized at the start of a caption,
+// longest first so that "Hash Join" wins over "Hash".
+var textNodeTypes = func() []NodeType {
+	types := []NodeType{
+		Limit, Append, MergeAppend, RecursiveUnion, Sort, IncrementalSort, NestedLoop, MergeJoin, Hash, HashJoin,
+		Aggregate, GroupAggregate, WindowAgg, Unique, SetOp, Gather, GatherMerge, Memoize, Materialize, Result,
+		ProjectSet, LockRows, BitmapAnd, BitmapOr, SequenceScan, SampleScan, IndexScan, IndexOnlyScan,
+		BitmapHeapScan, BitmapIndexScan, TidScan, TidRangeScan, CTEScan, NamedTuplestore, WorkTableScan,
+		FunctionScan, TableFuncScan, SubqueryScan, ValuesScan, ForeignScan, CustomScan,
+	}
+
+	sort.SliceStable(types, func(i, j int) bool { return len(types[i]) > len(types[j]) })
+
+	return types
+}()
+
+// textNode is a plan node under construction. Children are kept as pointers
+// while the tree grows and are copied into Plan.Plans once parsing is done.
+type textNode struct {
+	plan     Plan
+	children []*textNode
+	arrow    int // Column of the node's "->" marker; -1 for the root.
+}
+
+// textParser holds the state of a text plan being parsed line by line.
+type textParser struct {
+	explain *Explain
+	stack   []*textNode
+
+	subplanName   string // Label of the subtree whose node comes next.
+	subplanColumn int
+
+	worker       *Worker // Worker whose statistics the following deeper lines belong to.
+	workerColumn int
+
+	footer      bool   // Set once the plan tree is over and top-level lines begin.
+	footerBlock string // Top-level block ("Planning", "JIT") the indented footer lines belong to.
+}
+
+// NewExplainFromText parses the text output of EXPLAIN (optionally with
+// ANALYZE and BUFFERS), as printed by psql or logged by auto_explain, and
+// processes it like NewExplain does for JSON. Lines the parser does not
+// recognize are ignored, so values PostgreSQL only reports in the structured
+// formats stay empty. Input with several plans yields the first one.
+func NewExplainFromText(explainText string) (*Explain, error) {
+	explains, err := newExplainsFromText(explainText)
+	if err != nil {
+		return nil, err
+	}
+
+	return explains[0], nil
+}
+
+// newExplainsFromText parses every text plan of the input; each plan starts at
+// a root node line.
+func newExplainsFromText(explainText string) ([]*Explain, error) {
+	lines := textPlanLines(explainText)
+
+	roots := textRootIndexes(lines)
+	if len(roots) == 0 {
+		return nil, errors.New("Empty explain")
+	}
+
+	explains := make([]*Explain, 0, len(roots))
+
+	for index, rootIndex := range roots {
+		end := len(lines)
+		if index+1 < len(roots) {
+			end = roots[index+1]
+		}
+
+		ex, err := newExplainFromTextLines(lines[rootIndex:end])
+		if err != nil {
+			return nil, err
+		}
+
+		explains = append(explains, ex)
+	}
+
+	return explains, nil
+}
+
+// newExplainFromTextLines parses a single plan whose root node is the first line.
+func newExplainFromTextLines(lines []string) (*Explain, error) {
+	base := textIndent(lines[0])
+	root := &textNode{arrow: -1}
+
+	parser := &textParser{
+		explain: &Explain{},
+		stack:   []*textNode{root},
+	}
+
+	match := textNodeLine.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if match == nil {
+		return nil, fmt.Errorf("failed to parse plan node %q", lines[0])
+	}
+
+	applyTextNodeLine(&root.plan, match)
+
+	for _, line := range lines[1:] {
+		if err := parser.parseLine(line, base); err != nil {
+			return nil, err
+		}
+	}
+
+	ex := parser.explain
+	ex.Plan = root.build()
+	ex.processExplain()
+
+	return ex, nil
+}
+
+// textPlanLines splits the input into lines without trailing whitespace and
+// drops psql decorations: the "QUERY PLAN" header, its dashed underline and
+// the "(N rows)" footer.
+func textPlanLines(input string) []string {
+	rawLines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	lines := make([]string, 0, len(rawLines))
+
+	for _, line := range rawLines {
+		line = strings.TrimRight(line, " \t")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			continue
+		case trimmed == "QUERY PLAN":
+			continue
+		case strings.Trim(trimmed, "-+") == "":
+			continue
+		case strings.HasPrefix(trimmed, "(") && (strings.HasSuffix(trimmed, " rows)") || strings.HasSuffix(trimmed, " row)")):
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// textRootIndexes finds the root nodes: lines carrying an estimate or actual
+// clause without the "->" marker of child nodes. Anything auto_explain prints
+// before a plan ("Query Text: ...") is skipped this way. Plans without either
+// clause (COSTS OFF) cannot be told apart, so the input is one plan starting at
+// the first line.
+func textRootIndexes(lines []string) []int {
+	var roots []int
+
+	for index, line := range lines {
+		match := textNodeLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil || match[textMatchArrow] != "" {
+			continue
+		}
+
+		if match[textMatchTotalCost] != "" || match[textMatchLoops] != "" || match[textMatchNeverExecuted] != "" {
+			roots = append(roots, index)
+		}
+	}
+
+	if len(roots) == 0 && len(lines) > 0 {
+		roots = append(roots, 0)
+	}
+
+	return roots
+}
+
+// textIndent counts leading whitespace characters.
+func textIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func (p *textParser) parseLine(line string, base int) error {
+	column := textIndent(line) - base
+	if column < 0 {
+		column = 0
+	}
+
+	text := strings.TrimSpace(line)
+
+	if column == 0 {
+		p.footer = true
+	}
+
+	if p.footer {
+		if column == 0 {
+			p.parseFooterLine(text)
+		} else {
+			p.parseFooterBlockLine(text)
+		}
+
+		return nil
+	}
+
+	if p.worker != nil {
+		if column > p.workerColumn {
+			applyTextWorkerDetail(p.worker, text)
+			return nil
+		}
+
+		p.worker = nil
+	}
+
+	switch {
+	case strings.HasPrefix(text, "->"):
+		return p.addNode(text, column)
+
+	case textSubplanLine.MatchString(text):
+		p.popTo(column)
+		p.subplanName = text
+		p.subplanColumn = column
+
+	case textWorkerLine.MatchString(text):
+		// Per-worker statistics are kept apart from the node totals.
+		match := textWorkerLine.FindStringSubmatch(text)
+
+		p.popTo(column)
+		node := &p.top().plan
+		node.Workers = append(node.Workers, Worker{WorkerNumber: int(parseTextUint(match[1]))})
+
+		p.worker = &node.Workers[len(node.Workers)-1]
+		p.workerColumn = column
+		applyTextWorkerDetail(p.worker, match[2])
+
+	default:
+		p.popTo(column)
+		applyTextDetail(&p.top().plan, text)
+	}
+
+	return nil
+}
+
+func (p *textParser) addNode(text string, column int) error {
+	match := textNodeLine.FindStringSubmatch(text)
+	if match == nil {
+		return fmt.Errorf("failed to parse plan node %q", text)
+	}
+
+	p.popTo(column)
+	parent := p.top()
+
+	node := &textNode{arrow: column}
+	applyTextNodeLine(&node.plan, match)
+
+	if p.subplanName != "" && p.subplanColumn < column {
+		node.plan.SubplanName = p.subplanName
+		node.plan.ParentRelationship = "SubPlan"
+
+		if !strings.HasPrefix(p.subplanName, "SubPlan") {
+			node.plan.ParentRelationship = "InitPlan"
+		}
+	} else {
+		node.plan.ParentRelationship = textParentRelationship(parent)
+	}
+
+	p.subplanName = ""
+
+	parent.children = append(parent.children, node)
+	p.stack = append(p.stack, node)
+
+	return nil
+}
+
+// popTo leaves on the stack only the nodes a line at the given column belongs to.
+func (p *textParser) popTo(column int) {
+	for len(p.stack) > 1 && p.top().arrow >= column {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+}
+
+func (p *textParser) top() *textNode {
+	return p.stack[len(p.stack)-1]
+}
+
+// parseFooterLine reads the top-level lines printed after the plan tree.
+func (p *textParser) parseFooterLine(text string) {
+	ex := p.explain
+	p.footerBlock = ""
+
+	switch text {
+	case "Planning:":
+		p.footerBlock = "Planning"
+		ex.Planning = &Planning{}
+
+		return
+
+	case "JIT:":
+		p.footerBlock = "JIT"
+		ex.JIT = &JIT{}
+
+		return
+	}
+
+	if match := textTriggerLine.FindStringSubmatch(text); match != nil {
+		ex.Triggers = append(ex.Triggers, Trigger{
+			Name:           match[1],
+			ConstraintName: match[2],
+			Relation:       match[3],
+			Time:           parseTextFloat(match[4]),
+			Calls:          parseTextUint(match[5]),
+		})
+
+		return
+	}
+
+	key, value, found := strings.Cut(text, ": ")
+	if !found {
+		return
+	}
+
+	switch strings.ToLower(key) {
+	case "planning time":
+		ex.PlanningTime = parseTextFloat(strings.TrimSuffix(value, " ms"))
+
+	case "execution time", "total runtime":
+		ex.ExecutionTime = parseTextFloat(strings.TrimSuffix(value, " ms"))
+
+	case "query identifier", "query id":
+		ex.QueryIdentifier, _ = strconv.ParseInt(value, 10, 64)
+
+	case "settings":
+		ex.Settings = parseTextSettings(value)
+
+	case "serialization":
+		match := textSerialization.FindStringSubmatch(value)
+		if match == nil {
+			return
+		}
+
+		ex.Serialization = &Serialization{OutputVolume: parseTextUint(match[2]), Format: match[3]}
+
+		if match[1] != "" {
+			serializationTime := parseTextFloat(match[1])
+			ex.Serialization.Time = &serializationTime
+		}
+
+		p.footerBlock = "Serialization"
+	}
+}
+
+// parseFooterBlockLine reads a line of the "Planning:", "JIT:" or
+// "Serialization:" block.
+func (p *textParser) parseFooterBlockLine(text string) {
+	key, value, found := strings.Cut(text, ": ")
+	if !found {
+		return
+	}
+
+	switch p.footerBlock {
+	case "Planning":
+		switch key {
+		case "Buffers":
+			parseTextBuffers(&p.explain.Planning.BufferUsage, value)
+		case "I/O Timings":
+			parseTextIOTimings(&p.explain.Planning.BufferUsage, value)
+		case "Memory":
+			counters := parseTextCounters(strings.ReplaceAll(value, "kB", ""))
+			p.explain.Planning.MemoryUsed = counters["used"]
+			p.explain.Planning.MemoryAllocated = counters["allocated"]
+		}
+
+	case "Serialization":
+		switch key {
+		case "Buffers":
+			parseTextBuffers(&p.explain.Serialization.BufferUsage, value)
+		case "I/O Timings":
+			parseTextIOTimings(&p.explain.Serialization.BufferUsage, value)
+		}
+
+	case "JIT":
+		parseTextJIT(p.explain.JIT, key, value)
+	}
+}
+
+// parseTextJIT reads a line of the JIT block, e.g. "Functions: 4" or
+// "Options: Inlining false, Optimization false, Expressions true, Deforming true".
+func parseTextJIT(jit *JIT, key, value string) {
+	switch key {
+	case "Functions":
+		jit.Functions = parseTextUint(value)
+
+	case "Options":
+		for _, option := range strings.Split(value, ", ") {
+			name, enabled, _ := strings.Cut(option, " ")
+
+			switch name {
+			case "Inlining":
+				jit.Options.Inlining = enabled == "true"
+			case "Optimization":
+				jit.Options.Optimization = enabled == "true"
+			case "Expressions":
+				jit.Options.Expressions = enabled == "true"
+			case "Deforming":
+				jit.Options.Deforming = enabled == "true"
+			}
+		}
+
+	case "Timing":
+		match := textJITTiming.FindStringSubmatch(value)
+		if match == nil {
+			return
+		}
+
+		timing := &JITTiming{
+			Generation:   JITGeneration{Total: parseTextFloat(match[1])},
+			Inlining:     parseTextFloat(match[3]),
+			Optimization: parseTextFloat(match[4]),
+			Emission:     parseTextFloat(match[5]),
+			Total:        parseTextFloat(match[6]),
+		}
+
+		if match[2] != "" {
+			deform := parseTextFloat(match[2])
+			timing.Generation.Deform = &deform
+		}
+
+		jit.Timing = timing
+	}
+}
+
+// textParentRelationship infers how a regular child relates to its parent,
+// which the text format does not print.
+func textParentRelationship(parent *textNode) string {
+	switch parent.plan.NodeType {
+	case NestedLoop, HashJoin, MergeJoin:
+		for _, child := range parent.children {
+			if child.plan.SubplanName == "" {
+				return "Inner"
+			}
+		}
+
+	case Append, MergeAppend:
+		return "Member"
+
+	case SubqueryScan:
+		return "Subquery"
+	}
+
+	return "Outer"
+}
+
+func (n *textNode) build() Plan {
+	plan := n.plan
+
+	if len(n.children) > 0 {
+		plan.Plans = make([]Plan, 0, len(n.children))
+
+		for _, child := range n.children {
+			plan.Plans = append(plan.Plans, child.build())
+		}
+	}
+
+	return plan
+}
+
+func applyTextNodeLine(plan *Plan, match []string) {
+	parseTextCaption(plan, match[textMatchCaption])
+
+	if match[textMatchTotalCost] != "" {
+		plan.StartupCost = parseTextFloat(match[textMatchStartupCost])
+		plan.TotalCost = parseTextFloat(match[textMatchTotalCost])
+		plan.PlanRows = parseTextUint(match[textMatchPlanRows])
+		plan.PlanWidth = parseTextUint(match[textMatchPlanWidth])
+	}
+
+	if match[textMatchLoops] != "" {
+		plan.ActualStartupTime = parseTextFloat(match[textMatchStartupTime])
+		plan.ActualTotalTime = parseTextFloat(match[textMatchTotalTime])
+		plan.ActualRows = parseTextFloat(match[textMatchActualRows])
+		plan.ActualLoops = parseTextUint(match[textMatchLoops])
+	}
+}
+
+// parseTextCaption is the inverse of writePlanTextNodeCaption.
+func parseTextCaption(plan *Plan, caption string) {
+	if rest, ok := strings.CutPrefix(caption, "Parallel "); ok {
+		plan.ParallelAware = true
+		caption = rest
+	}
+
+	for _, operation := range textModifyOperations {
+		if target, ok := strings.CutPrefix(caption, operation+" on "); ok {
+			plan.NodeType = ModifyTable
+			plan.Operation = operation
+			plan.Schema, plan.RelationName, plan.Alias = parseTextTarget(target)
+
+			return
+		}
+	}
+
+	if match := textAggregateCaption.FindStringSubmatch(caption); match != nil {
+		plan.NodeType = Aggregate
+		plan.Strategy = map[string]string{"": "Plain", "Hash": "Hashed", "Group": "Sorted", "Mixed": "Mixed"}[match[2]]
+		plan.PartialMode = strings.TrimSpace(match[1])
+
+		if plan.PartialMode == "" {
+			plan.PartialMode = "Simple"
+		}
+
+		return
+	}
+
+	if match := textJoinCaption.FindStringSubmatch(caption); match != nil {
+		plan.NodeType = NodeType(match[1] + " Join")
+		if match[1] == string(NestedLoop) {
+			plan.NodeType = NestedLoop
+		}
+
+		plan.JoinType = match[2]
+		if plan.JoinType == "" {
+			plan.JoinType = "Inner"
+		}
+
+		return
+	}
+
+	if caption == string(NestedLoop) {
+		plan.NodeType = NestedLoop
+		plan.JoinType = "Inner"
+
+		return
+	}
+
+	if textSetOpCaption.MatchString(caption) {
+		plan.NodeType = SetOp
+		plan.Strategy = "Sorted"
+
+		if strings.HasPrefix(caption, "Hash") {
+			plan.Strategy = "Hashed"
+		}
+
+		return
+	}
+
+	rest := caption
+	plan.NodeType = NodeType(caption)
+
+	for _, nodeType := range textNodeTypes {
+		if tail, ok := strings.CutPrefix(caption, string(nodeType)); ok && (tail == "" || tail[0] == ' ') {
+			plan.NodeType = nodeType
+			rest = tail
+
+			break
+		}
+	}
+
+	if rest == caption {
+		return
+	}
+
+	parseTextScan(plan, strings.TrimSpace(rest))
+}
+
+// parseTextScan reads the "[Backward] [using <index>] [on <target>]" caption
+// tail of scan nodes.
+func parseTextScan(plan *Plan, rest string) {
+	if tail, ok := strings.CutPrefix(rest, "Backward"); ok {
+		plan.ScanDirection = "Backward"
+		rest = strings.TrimSpace(tail)
+	}
+
+	if tail, ok := strings.CutPrefix(rest, "using "); ok {
+		plan.IndexName, rest, _ = strings.Cut(tail, " ")
+	}
+
+	target := ""
+
+	if tail, ok := strings.CutPrefix(rest, "on "); ok {
+		target = tail
+	} else if _, tail, ok := strings.Cut(rest, " on "); ok {
+		target = tail // E.g. "Custom Scan (provider) on rel".
+	}
+
+	if target == "" {
+		return
+	}
+
+	switch plan.NodeType {
+	case BitmapIndexScan:
+		plan.IndexName = target
+
+	case ValuesScan:
+		if alias, err := strconv.Unquote(target); err == nil {
+			target = alias
+		}
+
+		plan.Alias = target
+
+	case SubqueryScan:
+		plan.Alias = target
+
+	case CTEScan, WorkTableScan:
+		plan.Schema, plan.CteName, plan.Alias = parseTextTarget(target)
+
+	case FunctionScan:
+		plan.Schema, plan.FunctionName, plan.Alias = parseTextTarget(target)
+
+	default:
+		plan.Schema, plan.RelationName, plan.Alias = parseTextTarget(target)
+	}
+}
+
+// parseTextTarget splits "[schema.]name [alias]"; a missing alias equals the name.
+func parseTextTarget(target string) (schema, name, alias string) {
+	name, alias, _ = strings.Cut(target, " ")
+
+	if qualifier, relation, ok := strings.Cut(name, "."); ok {
+		schema, name = qualifier, relation
+	}
+
+	if alias == "" {
+		alias = name
+	}
+
+	return schema, name, alias
+}
+
+// applyTextDetail reads one "Key: value" detail line of a node.
+// nolint:gocyclo
+func applyTextDetail(plan *Plan, text string) {
+	key, value, found := strings.Cut(text, ": ")
+	if !found {
+		return
+	}
+
+	pairs := parseTextPairs(text)
+
+	switch key {
+	case "Disabled":
+		plan.Disabled = value == "true"
+	case "Output":
+		plan.Output = splitTextList(value)
+	case "Sort Key":
+		plan.SortKey = splitTextList(value)
+	case "Presorted Key":
+		plan.PresortedKey = splitTextList(value)
+	case "Group Key":
+		plan.GroupKey = splitTextList(value)
+	case "Full-sort Groups":
+		plan.FullSortGroups = parseTextSortGroups(pairs, key)
+	case "Pre-sorted Groups":
+		plan.PresortedGroups = parseTextSortGroups(pairs, key)
+	case "Sort Method":
+		plan.SortMethod = pairs["Sort Method"]
+
+		for _, spaceType := range []string{"Memory", "Disk"} {
+			if used, ok := pairs[spaceType]; ok {
+				plan.SortSpaceType = spaceType
+				plan.SortSpaceUsed = parseTextKilobytes(used)
+			}
+		}
+	case "Buckets":
+		plan.HashBuckets, plan.OriginalHashBuckets = parseTextOriginally(pairs["Buckets"])
+		plan.HashBatches, plan.OriginalHashBatches = parseTextOriginally(pairs["Batches"])
+		plan.PeakMemoryUsage = parseTextKilobytes(pairs["Memory Usage"])
+	case "Planned Partitions", "Batches":
+		plan.PlannedPartitions = parseTextUint(pairs["Planned Partitions"])
+		plan.HashAggBatches = parseTextUint(pairs["Batches"])
+		plan.PeakMemoryUsage = parseTextKilobytes(pairs["Memory Usage"])
+		plan.DiskUsage = parseTextKilobytes(pairs["Disk Usage"])
+	case "Cache Key":
+		plan.CacheKey = value
+	case "Cache Mode":
+		plan.CacheMode = value
+	case "Hits":
+		plan.CacheHits = parseTextUint(pairs["Hits"])
+		plan.CacheMisses = parseTextUint(pairs["Misses"])
+		plan.CacheEvictions = parseTextUint(pairs["Evictions"])
+		plan.CacheOverflows = parseTextUint(pairs["Overflows"])
+		plan.PeakMemoryUsage = parseTextKilobytes(pairs["Memory Usage"])
+	case "Index Cond":
+		plan.IndexCondition = value
+	case "Merge Cond":
+		plan.MergeCondition = value
+	case "Hash Cond":
+		plan.HashCondition = value
+	case "Recheck Cond":
+		plan.RecheckCond = value
+	case "Join Filter":
+		plan.JoinFilter = value
+	case "Filter":
+		plan.Filter = value
+	case "Run Condition":
+		plan.RunCondition = value
+	case "Rows Removed by Filter":
+		plan.RowsRemovedByFilter = parseTextUint(value)
+	case "Rows Removed by Index Recheck":
+		plan.RowsRemovedByIndexRecheck = parseTextUint(value)
+	case "Rows Removed by Join Filter":
+		plan.RowsRemovedByJoinFilter = parseTextUint(value)
+	case "Heap Fetches":
+		plan.HeapFetches = parseTextUint(value)
+	case "Index Searches":
+		plan.IndexSearches = parseTextUint(value)
+	case "Storage":
+		plan.Storage = pairs["Storage"]
+		plan.MaximumStorage = parseTextKilobytes(pairs["Maximum Storage"])
+	case "Heap Blocks":
+		counters := parseTextCounters(value)
+		plan.ExactHeapBlocks = counters["exact"]
+		plan.LossyHeapBlocks = counters["lossy"]
+	case "Workers Planned":
+		plan.WorkersPlanned = uint(parseTextUint(value))
+	case "Workers Launched":
+		plan.WorkersLaunched = uint(parseTextUint(value))
+	case "Buffers":
+		parseTextBuffers(&plan.BufferUsage, value)
+	case "WAL":
+		parseTextWAL(&plan.WALUsage, value)
+	case "I/O Timings":
+		parseTextIOTimings(&plan.BufferUsage, value)
+	}
+}
+
+// applyTextWorkerDetail reads one item of a worker's statistics.
+func applyTextWorkerDetail(worker *Worker, text string) {
+	if match := textWorkerActual.FindStringSubmatch(text); match != nil {
+		worker.ActualStartupTime = parseTextFloat(match[1])
+		worker.ActualTotalTime = parseTextFloat(match[2])
+		worker.ActualRows = parseTextFloat(match[3])
+		worker.ActualLoops = parseTextUint(match[4])
+
+		return
+	}
+
+	key, value, found := strings.Cut(text, ": ")
+	if !found {
+		return
+	}
+
+	pairs := parseTextPairs(text)
+
+	switch key {
+	case "Sort Method":
+		worker.SortMethod = pairs["Sort Method"]
+
+		for _, spaceType := range []string{"Memory", "Disk"} {
+			if used, ok := pairs[spaceType]; ok {
+				worker.SortSpaceType = spaceType
+				worker.SortSpaceUsed = parseTextKilobytes(used)
+			}
+		}
+	case "Batches":
+		worker.HashAggBatches = parseTextUint(pairs["Batches"])
+		worker.PeakMemoryUsage = parseTextKilobytes(pairs["Memory Usage"])
+		worker.DiskUsage = parseTextKilobytes(pairs["Disk Usage"])
+	case "Hits":
+		worker.CacheHits = parseTextUint(pairs["Hits"])
+		worker.CacheMisses = parseTextUint(pairs["Misses"])
+		worker.CacheEvictions = parseTextUint(pairs["Evictions"])
+		worker.CacheOverflows = parseTextUint(pairs["Overflows"])
+		worker.PeakMemoryUsage = parseTextKilobytes(pairs["Memory Usage"])
+	case "Buffers":
+		parseTextBuffers(&worker.BufferUsage, value)
+	case "WAL":
+		parseTextWAL(&worker.WALUsage, value)
+	case "I/O Timings":
+		parseTextIOTimings(&worker.BufferUsage, value)
+	}
+}
+
+// parseTextPairs reads a line of "Key: value" pairs separated by two spaces,
+// e.g. "Buckets: 1024  Batches: 1  Memory Usage: 9kB".
+func parseTextPairs(text string) map[string]string {
+	pairs := make(map[string]string)
+
+	for _, pair := range strings.Split(text, "  ") {
+		if key, value, found := strings.Cut(strings.TrimSpace(pair), ": "); found {
+			pairs[key] = value
+		}
+	}
+
+	return pairs
+}
+
+// parseTextCounters reads space-separated "name=value" counters, e.g. "hit=5 read=2".
+func parseTextCounters(text string) map[string]uint64 {
+	counters := make(map[string]uint64)
+
+	for _, field := range strings.Fields(text) {
+		if name, value, found := strings.Cut(field, "="); found {
+			counters[name] = parseTextUint(value)
+		}
+	}
+
+	return counters
+}
+
+// parseTextBuffers reads "shared hit=5 read=2, local hit=1, temp read=3 written=3".
+func parseTextBuffers(usage *BufferUsage, value string) {
+	for _, section := range strings.Split(value, ", ") {
+		name, counters, _ := strings.Cut(section, " ")
+		values := parseTextCounters(counters)
+
+		switch name {
+		case "shared":
+			usage.SharedHitBlocks = values["hit"]
+			usage.SharedReadBlocks = values["read"]
+			usage.SharedDirtiedBlocks = values["dirtied"]
+			usage.SharedWrittenBlocks = values["written"]
+		case "local":
+			usage.LocalHitBlocks = values["hit"]
+			usage.LocalReadBlocks = values["read"]
+			usage.LocalDirtiedBlocks = values["dirtied"]
+			usage.LocalWrittenBlocks = values["written"]
+		case "temp":
+			usage.TempReadBlocks = values["read"]
+			usage.TempWrittenBlocks = values["written"]
+		}
+	}
+}
+
+// parseTextWAL reads "records=3 fpi=1 bytes=420 buffers-full=0".
+func parseTextWAL(usage *WALUsage, value string) {
+	counters := parseTextCounters(value)
+	usage.WALRecords = counters["records"]
+	usage.WALFPI = counters["fpi"]
+	usage.WALBytes = counters["bytes"]
+	usage.WALBuffersFull = counters["buffers-full"]
+}
+
+// parseTextIOTimings reads both "read=1.5 write=0.2" and the PostgreSQL 16+
+// per-buffer-type form "shared read=1.5, local write=0.1, temp read=0.3".
+func parseTextIOTimings(usage *BufferUsage, value string) {
+	for _, section := range strings.Split(value, ", ") {
+		read, write := (*float64)(nil), (*float64)(nil)
+		name := ""
+
+		for _, field := range strings.Fields(section) {
+			timing, amount, found := strings.Cut(field, "=")
+			if !found {
+				name = field
+				continue
+			}
+
+			parsed := parseTextFloat(amount)
+
+			switch timing {
+			case "read":
+				read = &parsed
+			case "write":
+				write = &parsed
+			}
+		}
+
+		switch name {
+		case "":
+			usage.IOReadTime, usage.IOWriteTime = read, write
+		case "shared":
+			usage.SharedIOReadTime, usage.SharedIOWriteTime = read, write
+		case "local":
+			usage.LocalIOReadTime, usage.LocalIOWriteTime = read, write
+		case "temp":
+			usage.TempIOReadTime, usage.TempIOWriteTime = read, write
+		}
+	}
+}
+
+// parseTextSortGroups reads an Incremental Sort group statistics line.
+func parseTextSortGroups(pairs map[string]string, key string) *SortGroups {
+	groups := &SortGroups{
+		GroupCount:      parseTextUint(pairs[key]),
+		SortMethodsUsed: splitTextList(pairs["Sort Method"]),
+	}
+
+	for _, spaceType := range []string{"Memory", "Disk"} {
+		average, ok := pairs["Average "+spaceType]
+		if !ok {
+			continue
+		}
+
+		usage := &SortSpaceUsage{
+			AverageSortSpaceUsed: parseTextKilobytes(average),
+			PeakSortSpaceUsed:    parseTextKilobytes(pairs["Peak "+spaceType]),
+		}
+
+		if spaceType == "Memory" {
+			groups.SortSpaceMemory = usage
+		} else {
+			groups.SortSpaceDisk = usage
+		}
+	}
+
+	return groups
+}
+
+// parseTextSettings reads "work_mem = '64MB', search_path = 'public'".
+func parseTextSettings(value string) map[string]string {
+	settings := make(map[string]string)
+
+	for _, setting := range splitTextList(value) {
+		name, quoted, found := strings.Cut(setting, " = ")
+		if !found {
+			continue
+		}
+
+		settings[name] = strings.ReplaceAll(strings.Trim(quoted, "'"), "''", "'")
+	}
+
+	return settings
+}
+
+// splitTextList splits a ", "-separated list, keeping separators inside
+// parentheses and quotes, so that "lower(name), (a, b)" yields two items.
+func splitTextList(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var (
+		items   []string
+		depth   int
+		quoted  bool
+		current strings.Builder
+	)
+
+	for index := 0; index < len(value); index++ {
+		char := value[index]
+
+		switch {
+		case char == '\'':
+			quoted = !quoted
+		case quoted:
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == ',' && depth == 0 && strings.HasPrefix(value[index:], ", "):
+			items = append(items, current.String())
+			current.Reset()
+			index++
+
+			continue
+		}
+
+		current.WriteByte(char)
+	}
+
+	return append(items, current.String())
+}
+
+// parseTextOriginally reads "1024 (originally 512)"; the original value is
+// zero when the line does not mention it.
+func parseTextOriginally(value string) (current, original uint64) {
+	number, rest, _ := strings.Cut(value, " ")
+	current = parseTextUint(number)
+
+	if tail, ok := strings.CutPrefix(rest, "(originally "); ok {
+		original = parseTextUint(strings.TrimSuffix(tail, ")"))
+	}
+
+	return current, original
+}
+
+func parseTextKilobytes(value string) uint64 {
+	return parseTextUint(strings.TrimSuffix(value, "kB"))
+}
+
+func parseTextUint(value string) uint64 {
+	number, _ := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	return number
+}
+
+func parseTextFloat(value string) float64 {
+	number, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return number
+}
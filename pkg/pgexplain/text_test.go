@@ -0,0 +1,159 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inputTextPsql is EXPLAIN (ANALYZE, BUFFERS) output as copied from psql,
+// including the header, per-worker lines, an InitPlan and the footer.
+const inputTextPsql = `                                                              QUERY PLAN
+---------------------------------------------------------------------------------------------------------------------------------------
+ Limit  (cost=1000.45..1020.12 rows=10 width=12) (actual time=0.512..12.020 rows=10 loops=1)
+   Buffers: shared hit=120 read=30
+   InitPlan 1
+     ->  Result  (cost=0.00..0.01 rows=1 width=4) (actual time=0.001..0.001 rows=1 loops=1)
+   ->  Gather Merge  (cost=1000.44..9876.00 rows=4000 width=12) (actual time=0.510..12.010 rows=10 loops=1)
+         Workers Planned: 2
+         Workers Launched: 2
+         Buffers: shared hit=120 read=30
+         ->  Sort  (cost=0.42..1.42 rows=1667 width=12) (actual time=0.100..0.105 rows=8 loops=3)
+               Sort Key: o.created_at DESC, (lower(o.note))
+               Sort Method: top-N heapsort  Memory: 25kB
+               Worker 0:  Sort Method: quicksort  Memory: 26kB
+               Worker 1:  actual time=0.090..0.095 rows=7 loops=1
+                 Buffers: shared hit=999
+               Buffers: shared hit=100 read=30
+               ->  Parallel Seq Scan on public.orders o  (cost=0.00..800.00 rows=1667 width=12) (actual time=0.010..0.080 rows=1667 loops=3)
+                     Filter: ((o.status = 'new, old'::text) AND (o.id > $0))
+                     Rows Removed by Filter: 3333
+                     Buffers: shared hit=100 read=30
+                     I/O Timings: shared read=1.250
+ Planning:
+   Buffers: shared hit=12
+ Planning Time: 0.321 ms
+ Trigger audit_orders on orders: time=0.050 calls=10
+ Execution Time: 12.345 ms
+(25 rows)
+`
+
+func TestNewExplainFromText(t *testing.T) {
+	explain, err := NewExplainFromText(inputTextPsql)
+	require.NoError(t, err)
+
+	require.Equal(t, 0.321, explain.PlanningTime)
+	require.Equal(t, 12.345, explain.ExecutionTime)
+	require.Equal(t, uint64(12), explain.Planning.SharedHitBlocks)
+	require.Equal(t, []Trigger{{Name: "audit_orders", Relation: "orders", Time: 0.05, Calls: 10}}, explain.Triggers)
+
+	limit := explain.Plan
+	require.Equal(t, Limit, limit.NodeType)
+	require.Equal(t, uint64(120), limit.SharedHitBlocks)
+	require.Len(t, limit.Plans, 2)
+
+	initPlan := limit.Plans[0]
+	require.Equal(t, Result, initPlan.NodeType)
+	require.Equal(t, "InitPlan 1", initPlan.SubplanName)
+	require.Equal(t, "InitPlan", initPlan.ParentRelationship)
+
+	gather := limit.Plans[1]
+	require.Equal(t, GatherMerge, gather.NodeType)
+	require.Equal(t, uint(2), gather.WorkersLaunched)
+	require.Len(t, gather.Plans, 1)
+
+	sortNode := gather.Plans[0]
+	require.Equal(t, []string{"o.created_at DESC", "(lower(o.note))"}, sortNode.SortKey)
+	require.Equal(t, "top-N heapsort", sortNode.SortMethod, "worker lines must not override the node")
+	require.Equal(t, uint64(25), sortNode.SortSpaceUsed)
+	require.Equal(t, uint64(100), sortNode.SharedHitBlocks)
+	require.Equal(t, uint64(3), sortNode.ActualLoops)
+	require.Len(t, sortNode.Workers, 2)
+	require.Equal(t, Worker{WorkerNumber: 0, SortMethod: "quicksort", SortSpaceType: "Memory", SortSpaceUsed: 26},
+		sortNode.Workers[0])
+	require.Equal(t, 0.095, sortNode.Workers[1].ActualTotalTime)
+	require.Equal(t, uint64(999), sortNode.Workers[1].SharedHitBlocks)
+
+	scan := sortNode.Plans[0]
+	require.Equal(t, SequenceScan, scan.NodeType)
+	require.True(t, scan.ParallelAware)
+	require.Equal(t, "public", scan.Schema)
+	require.Equal(t, "orders", scan.RelationName)
+	require.Equal(t, "o", scan.Alias)
+	require.Equal(t, "((o.status = 'new, old'::text) AND (o.id > $0))", scan.Filter)
+	require.Equal(t, uint64(3333), scan.RowsRemovedByFilter)
+	require.NotNil(t, scan.IOReadTime)
+	require.Equal(t, 1.25, *scan.IOReadTime)
+
+	// Calculated params work on text plans as well.
+	require.True(t, explain.ContainsSeqScan)
+	require.True(t, scan.Largest)
+	require.Contains(t, explain.RenderStats(), "hits: 120")
+}
+
+func TestNewExplainFromTextWithoutAnalyze(t *testing.T) {
+	explain, err := NewExplainFromText(`Hash Left Join  (cost=1.09..2.23 rows=4 width=8)
+  Hash Cond: (a.id = b.a_id)
+  ->  Index Scan Backward using a_pkey on a  (cost=0.00..1.04 rows=4 width=4)
+  ->  Hash  (cost=1.04..1.04 rows=4 width=8)
+        ->  Seq Scan on b  (cost=0.00..1.04 rows=4 width=8)`)
+	require.NoError(t, err)
+
+	join := explain.Plan
+	require.Equal(t, HashJoin, join.NodeType)
+	require.Equal(t, "Left", join.JoinType)
+	require.Equal(t, "(a.id = b.a_id)", join.HashCondition)
+	require.Len(t, join.Plans, 2)
+	require.Equal(t, "Outer", join.Plans[0].ParentRelationship)
+	require.Equal(t, "Inner", join.Plans[1].ParentRelationship)
+
+	index := join.Plans[0]
+	require.Equal(t, IndexScan, index.NodeType)
+	require.Equal(t, "Backward", index.ScanDirection)
+	require.Equal(t, "a_pkey", index.IndexName)
+	require.Equal(t, "a", index.RelationName)
+	require.Equal(t, uint64(0), index.ActualLoops)
+}
+
+func TestNewExplainFromTextEmpty(t *testing.T) {
+	_, err := NewExplainFromText("QUERY PLAN\n----------\n(0 rows)\n")
+	require.Error(t, err)
+}
+
+func TestDetectFormat(t *testing.T) {
+	require.Equal(t, FormatJSON, DetectFormat("  [\n{\"Plan\": {}}]"))
+	require.Equal(t, FormatText, DetectFormat(inputTextPsql))
+}
+
+// TestNewExplainFromTextRoundTrip parses the text rendering of every fixture
+// back and checks that it renders to the very same plan text.
+func TestNewExplainFromTextRoundTrip(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/*/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, jsonPath := range fixtures {
+		jsonPath := jsonPath
+
+		t.Run(goldenName(jsonPath), func(t *testing.T) {
+			raw, err := os.ReadFile(jsonPath)
+			require.NoError(t, err)
+
+			fromJSON, err := NewExplain(string(raw))
+			require.NoError(t, err)
+
+			planText := fromJSON.RenderPlanText()
+
+			fromText, err := NewExplainFromText(planText)
+			require.NoError(t, err)
+
+			require.Equal(t, goldenCanonicalize(planText), goldenCanonicalize(fromText.RenderPlanText()))
+		})
+	}
+}
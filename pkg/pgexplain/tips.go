@@ -0,0 +1,272 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tip codes produced by the plan analyzer.
+const (
+	TipSeqScanFilter   = "SEQSCAN_FILTER"
+	TipRowMisestimate  = "ROW_MISESTIMATE"
+	TipSortDisk        = "SORT_DISK"
+	TipHashDisk        = "HASH_DISK"
+	TipBitmapLossy     = "BITMAP_LOSSY"
+	TipHeapFetches     = "HEAP_FETCHES"
+	TipNestedLoopLoops = "NESTED_LOOP_LOOPS"
+)
+
+// Thresholds the analyzer rules fire at. They are deliberately conservative:
+// a tip should point at something worth a reviewer's attention, not at every
+// imperfect estimate in a small plan.
+const (
+	// seqScanFilterMinRemoved is the minimum number of rows (across all loops)
+	// a Seq Scan filter has to discard before it is reported.
+	seqScanFilterMinRemoved = 10000
+	// seqScanFilterMinRatio is the minimum share of scanned rows discarded by the filter.
+	seqScanFilterMinRatio = 0.5
+	// misestimateMinFactor is the minimum planned-vs-actual rows ratio to report.
+	misestimateMinFactor = 10
+	// misestimateMinRows ignores misestimates where both sides are tiny.
+	misestimateMinRows = 100
+	// heapFetchesMinCount is the minimum number of heap fetches to report.
+	heapFetchesMinCount = 1000
+	// heapFetchesMinRatio is the minimum share of returned rows that needed a heap fetch.
+	heapFetchesMinRatio = 0.1
+	// nestedLoopMinLoops is the minimum number of inner-side loops to report.
+	nestedLoopMinLoops = 10000
+)
+
+// Documentation links attached to tips.
+const (
+	tipURLIndexes      = "https://www.postgresql.org/docs/current/indexes.html"
+	tipURLStatistics   = "https://www.postgresql.org/docs/current/planner-stats.html"
+	tipURLWorkMem      = "https://www.postgresql.org/docs/current/runtime-config-resource.html#GUC-WORK-MEM"
+	tipURLIndexOnly    = "https://www.postgresql.org/docs/current/indexes-index-only-scans.html"
+	tipURLUsingExplain = "https://www.postgresql.org/docs/current/using-explain.html"
+)
+
+// tipRule inspects a single processed plan node and returns the tips it triggers.
+type tipRule func(plan *Plan) []Tip
+
+// tipRules lists the analyzer rules in the order their tips are reported for a node.
+var tipRules = []tipRule{
+	seqScanFilterTip,
+	rowMisestimateTip,
+	sortDiskTip,
+	hashDiskTip,
+	bitmapLossyTip,
+	heapFetchesTip,
+	nestedLoopLoopsTip,
+}
+
+// Tips walks the processed plan tree and returns recommendations in plan order.
+func (ex *Explain) Tips() []Tip {
+	tips := []Tip{}
+
+	ex.Plan.walk(func(plan *Plan) {
+		for _, rule := range tipRules {
+			tips = append(tips, rule(plan)...)
+		}
+	})
+
+	return tips
+}
+
+// RenderTips renders the recommendations as a bulleted list; it is empty when
+// the analyzer found nothing to report.
+func (ex *Explain) RenderTips() string {
+	return RenderTips(ex.Tips())
+}
+
+// RenderTips renders the given tips as a bulleted list.
+func RenderTips(tips []Tip) string {
+	sb := strings.Builder{}
+
+	for _, tip := range tips {
+		fmt.Fprintf(&sb, "• %s (%s): %s", tip.Name, tip.Code, tip.Description)
+
+		if tip.DetailsUrl != "" {
+			fmt.Fprintf(&sb, " Details: %s", tip.DetailsUrl)
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// walk calls fn for the node and then for every descendant, depth-first.
+func (plan *Plan) walk(fn func(plan *Plan)) {
+	fn(plan)
+
+	for index := range plan.Plans {
+		plan.Plans[index].walk(fn)
+	}
+}
+
+// totalRows returns the number of rows the node produced across all loops;
+// EXPLAIN reports Actual Rows as a per-loop average.
+func (plan *Plan) totalRows() float64 {
+	return plan.ActualRows * float64(plan.ActualLoops)
+}
+
+func seqScanFilterTip(plan *Plan) []Tip {
+	if plan.NodeType != SequenceScan || plan.RowsRemovedByFilter == 0 {
+		return nil
+	}
+
+	// Rows Removed by Filter is a per-loop average, like Actual Rows.
+	removed := float64(plan.RowsRemovedByFilter) * float64(plan.ActualLoops)
+	scanned := removed + plan.totalRows()
+
+	if removed < seqScanFilterMinRemoved || removed/scanned < seqScanFilterMinRatio {
+		return nil
+	}
+
+	return []Tip{{
+		Code: TipSeqScanFilter,
+		Name: "Seq Scan with a selective filter",
+		Description: fmt.Sprintf("%s discarded %.0f of %.0f rows (%.0f%%) with `Filter: %s`. "+
+			"An index on the filtered columns can avoid reading the whole table.",
+			nodeCaption(plan), removed, scanned, removed/scanned*100, plan.Filter),
+		DetailsUrl: tipURLIndexes,
+	}}
+}
+
+func rowMisestimateTip(plan *Plan) []Tip {
+	if plan.ActualLoops == 0 || plan.PlannerRowEstimateFactor < misestimateMinFactor {
+		return nil
+	}
+
+	if float64(plan.PlanRows) < misestimateMinRows && plan.ActualRows < misestimateMinRows {
+		return nil
+	}
+
+	direction := "underestimated"
+	if plan.PlannerRowEstimateDirection == Over {
+		direction = "overestimated"
+	}
+
+	return []Tip{{
+		Code: TipRowMisestimate,
+		Name: "Row count misestimate",
+		Description: fmt.Sprintf("The planner %s rows for %s by %.0fx (planned %d, actual %s). "+
+			"Run ANALYZE, raise the statistics target or create extended statistics for correlated columns.",
+			direction, nodeCaption(plan), plan.PlannerRowEstimateFactor, plan.PlanRows, formatActualRows(plan.ActualRows)),
+		DetailsUrl: tipURLStatistics,
+	}}
+}
+
+func sortDiskTip(plan *Plan) []Tip {
+	spilled := plan.SortSpaceType == "Disk" || strings.HasPrefix(plan.SortMethod, "external")
+
+	for _, groups := range []*SortGroups{plan.FullSortGroups, plan.PresortedGroups} {
+		if groups != nil && groups.SortSpaceDisk != nil {
+			spilled = true
+		}
+	}
+
+	if !spilled {
+		return nil
+	}
+
+	return []Tip{{
+		Code: TipSortDisk,
+		Name: "Sort spilled to disk",
+		Description: fmt.Sprintf("%s did not fit into work_mem and used temporary files. "+
+			"Increase work_mem for this query or sort fewer/narrower rows.", nodeCaption(plan)),
+		DetailsUrl: tipURLWorkMem,
+	}}
+}
+
+func hashDiskTip(plan *Plan) []Tip {
+	switch {
+	case plan.NodeType == Hash && plan.HashBatches > 1:
+		return []Tip{{
+			Code: TipHashDisk,
+			Name: "Hash spilled to disk",
+			Description: fmt.Sprintf("%s used %d batches (originally planned %d), so the hash table did not fit into work_mem. "+
+				"Increase work_mem or hash_mem_multiplier.", nodeCaption(plan), plan.HashBatches, plan.OriginalHashBatches),
+			DetailsUrl: tipURLWorkMem,
+		}}
+
+	case plan.DiskUsage > 0:
+		return []Tip{{
+			Code: TipHashDisk,
+			Name: "Hash aggregate spilled to disk",
+			Description: fmt.Sprintf("%s wrote %dkB to disk in %d batches. "+
+				"Increase work_mem or hash_mem_multiplier.", nodeCaption(plan), plan.DiskUsage, plan.HashAggBatches),
+			DetailsUrl: tipURLWorkMem,
+		}}
+	}
+
+	return nil
+}
+
+func bitmapLossyTip(plan *Plan) []Tip {
+	if plan.LossyHeapBlocks == 0 {
+		return nil
+	}
+
+	return []Tip{{
+		Code: TipBitmapLossy,
+		Name: "Lossy bitmap heap scan",
+		Description: fmt.Sprintf("%s had %d lossy heap blocks, so every row on them was rechecked "+
+			"(%d rows removed by recheck). Increase work_mem to keep the bitmap exact.",
+			nodeCaption(plan), plan.LossyHeapBlocks, plan.RowsRemovedByIndexRecheck),
+		DetailsUrl: tipURLWorkMem,
+	}}
+}
+
+func heapFetchesTip(plan *Plan) []Tip {
+	if plan.NodeType != IndexOnlyScan || plan.HeapFetches < heapFetchesMinCount {
+		return nil
+	}
+
+	// Heap Fetches is a total across loops, unlike Actual Rows.
+	rows := plan.totalRows()
+	if rows > 0 && float64(plan.HeapFetches)/rows < heapFetchesMinRatio {
+		return nil
+	}
+
+	return []Tip{{
+		Code: TipHeapFetches,
+		Name: "Index Only Scan with many heap fetches",
+		Description: fmt.Sprintf("%s fetched %d rows from the heap because the visibility map is not up to date. "+
+			"VACUUM the table (or tune autovacuum) to make the scan index-only again.", nodeCaption(plan), plan.HeapFetches),
+		DetailsUrl: tipURLIndexOnly,
+	}}
+}
+
+func nestedLoopLoopsTip(plan *Plan) []Tip {
+	if plan.NodeType != NestedLoop {
+		return nil
+	}
+
+	var inner *Plan
+
+	for index := range plan.Plans {
+		child := &plan.Plans[index]
+		if child.ParentRelationship == "Inner" {
+			inner = child
+		}
+	}
+
+	if inner == nil || inner.ActualLoops < nestedLoopMinLoops {
+		return nil
+	}
+
+	return []Tip{{
+		Code: TipNestedLoopLoops,
+		Name: "Nested Loop with many iterations",
+		Description: fmt.Sprintf("The inner side of %s (%s) ran %d times. "+
+			"If the outer row estimate is off, a Hash Join or Merge Join may be cheaper; check the join condition indexes.",
+			nodeCaption(plan), nodeCaption(inner), inner.ActualLoops),
+		DetailsUrl: tipURLUsingExplain,
+	}}
+}
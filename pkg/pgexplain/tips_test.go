@@ -0,0 +1,114 @@
+/*
+2026 © Postgres.ai
+*/
+
+package pgexplain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inputJSONTips is a synthetic plan that trips every analyzer rule once: a
+// Nested Loop whose inner Index Only Scan runs 20000 times with many heap
+// fetches, over a Sort that spilled to disk on top of a Seq Scan that discards
+// most of the table and is badly misestimated, plus a Hash Join whose Hash
+// spilled into batches above a lossy Bitmap Heap Scan.
+const inputJSONTips = `[
+  {
+    "Plan": {
+      "Node Type": "Hash Join", "Join Type": "Inner", "Parallel Aware": false,
+      "Startup Cost": 10.0, "Total Cost": 900.0, "Plan Rows": 100, "Plan Width": 8,
+      "Actual Startup Time": 1.0, "Actual Total Time": 80.0, "Actual Rows": 100, "Actual Loops": 1,
+      "Hash Cond": "(a.id = b.a_id)",
+      "Plans": [
+        {
+          "Node Type": "Nested Loop", "Join Type": "Inner", "Parent Relationship": "Outer", "Parallel Aware": false,
+          "Startup Cost": 5.0, "Total Cost": 500.0, "Plan Rows": 100, "Plan Width": 8,
+          "Actual Startup Time": 1.0, "Actual Total Time": 60.0, "Actual Rows": 100, "Actual Loops": 1,
+          "Plans": [
+            {
+              "Node Type": "Sort", "Parent Relationship": "Outer", "Parallel Aware": false,
+              "Startup Cost": 5.0, "Total Cost": 6.0, "Plan Rows": 20000, "Plan Width": 8,
+              "Actual Startup Time": 10.0, "Actual Total Time": 20.0, "Actual Rows": 20000, "Actual Loops": 1,
+              "Sort Key": ["a.id"], "Sort Method": "external merge", "Sort Space Type": "Disk", "Sort Space Used": 1648,
+              "Plans": [
+                {
+                  "Node Type": "Seq Scan", "Parent Relationship": "Outer", "Parallel Aware": false,
+                  "Relation Name": "orders", "Schema": "public", "Alias": "a",
+                  "Startup Cost": 0.0, "Total Cost": 4.0, "Plan Rows": 100, "Plan Width": 8,
+                  "Actual Startup Time": 0.1, "Actual Total Time": 5.0, "Actual Rows": 20000, "Actual Loops": 1,
+                  "Filter": "(a.status = 'new'::text)", "Rows Removed by Filter": 180000
+                }
+              ]
+            },
+            {
+              "Node Type": "Index Only Scan", "Parent Relationship": "Inner", "Parallel Aware": false,
+              "Scan Direction": "Forward", "Index Name": "items_order_id_idx", "Relation Name": "items", "Schema": "public", "Alias": "i",
+              "Startup Cost": 0.0, "Total Cost": 0.1, "Plan Rows": 1, "Plan Width": 4,
+              "Actual Startup Time": 0.001, "Actual Total Time": 0.002, "Actual Rows": 1, "Actual Loops": 20000,
+              "Index Cond": "(i.order_id = a.id)", "Heap Fetches": 15000
+            }
+          ]
+        },
+        {
+          "Node Type": "Hash", "Parent Relationship": "Inner", "Parallel Aware": false,
+          "Startup Cost": 1.0, "Total Cost": 2.0, "Plan Rows": 50, "Plan Width": 8,
+          "Actual Startup Time": 5.0, "Actual Total Time": 5.0, "Actual Rows": 50, "Actual Loops": 1,
+          "Hash Buckets": 1024, "Original Hash Buckets": 1024, "Hash Batches": 4, "Original Hash Batches": 1, "Peak Memory Usage": 64,
+          "Plans": [
+            {
+              "Node Type": "Bitmap Heap Scan", "Parent Relationship": "Outer", "Parallel Aware": false,
+              "Relation Name": "b", "Schema": "public", "Alias": "b",
+              "Startup Cost": 0.5, "Total Cost": 1.0, "Plan Rows": 50, "Plan Width": 8,
+              "Actual Startup Time": 1.0, "Actual Total Time": 4.0, "Actual Rows": 50, "Actual Loops": 1,
+              "Recheck Cond": "(b.flag)", "Rows Removed by Index Recheck": 700,
+              "Exact Heap Blocks": 10, "Lossy Heap Blocks": 30
+            }
+          ]
+        }
+      ]
+    },
+    "Planning Time": 0.1, "Triggers": [], "Execution Time": 80.0
+  }
+]`
+
+func TestTips(t *testing.T) {
+	explain, err := NewExplain(inputJSONTips)
+	require.NoError(t, err)
+
+	codes := []string{}
+	for _, tip := range explain.Tips() {
+		codes = append(codes, tip.Code)
+	}
+
+	// Plan order: the Nested Loop is visited before its children, the Sort
+	// before the Seq Scan, and the Hash before the Bitmap Heap Scan.
+	require.Equal(t, []string{
+		TipNestedLoopLoops,
+		TipSortDisk,
+		TipSeqScanFilter,
+		TipRowMisestimate,
+		TipHeapFetches,
+		TipHashDisk,
+		TipBitmapLossy,
+	}, codes)
+
+	out := explain.RenderTips()
+	require.Contains(t, out, "Seq Scan on public.orders a discarded 180000 of 200000 rows (90%)")
+	require.Contains(t, out, "underestimated rows for Seq Scan on public.orders a by 200x (planned 100, actual 20000)")
+	require.Contains(t, out, "Index Only Scan using items_order_id_idx on public.items i fetched 15000 rows from the heap")
+	require.Contains(t, out, "Hash used 4 batches (originally planned 1)")
+	require.Contains(t, out, "had 30 lossy heap blocks")
+	require.Contains(t, out, "ran 20000 times")
+	require.Contains(t, out, "Details: "+tipURLWorkMem)
+}
+
+func TestTipsQuietPlan(t *testing.T) {
+	explain, err := NewExplain(inputJSONPostgres18FractionalRows)
+	require.NoError(t, err)
+
+	require.Empty(t, explain.Tips())
+	require.Empty(t, explain.RenderTips())
+}
@@ -103,3 +103,7 @@ func blocksToBytes(blocks uint64) string {
 	bytes := blocks * 1024 * 8
 	return IBytes(bytes, "%.02f %s")
 }
+
+func kilobytesToBytes(kilobytes uint64) string {
+	return IBytes(kilobytes*1024, "%.02f %s")
+}
@@ -0,0 +1,442 @@
+/*
+2026 © Postgres.ai
+*/
+
+package msgproc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize/english"
+	"github.com/pkg/errors"
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/log"
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/util"
+
+	"gitlab.com/postgres-ai/joe/features/definition"
+	"gitlab.com/postgres-ai/joe/pkg/bot/command"
+	"gitlab.com/postgres-ai/joe/pkg/models"
+	"gitlab.com/postgres-ai/joe/pkg/services/platform"
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
+	"gitlab.com/postgres-ai/joe/pkg/transmission/pgtransmission"
+	"gitlab.com/postgres-ai/joe/pkg/util/operator"
+)
+
+// errSessionRebooted tells that a command failed and the session has been
+// rebooted, so the command is not to be finished as usual.
+var errSessionRebooted = errors.New("session rebooted")
+
+// commandRequest is a received command to execute.
+type commandRequest struct {
+	user        *usermanager.User
+	platformCmd *platform.Command // The command as received, e.g. an alias.
+	msg         *models.Message
+}
+
+// commandExecutor executes a command. The executor of a command that needs a
+// session gets the published message of the command, the executor of one that
+// does not has to publish the prepared message itself.
+type commandExecutor func(ctx context.Context, req *commandRequest) error
+
+// commandHint suggests a command for the messages that start with a keyword it matches.
+type commandHint struct {
+	match func(keyword string) bool
+	text  string
+}
+
+// botCommand defines a command of the bot.
+type botCommand struct {
+	name         string
+	aliases      []string
+	usage        string // The arguments shown in the help message after each name, e.g. "[pid]".
+	help         string // Shown in the help message after the names.
+	needsSession bool
+	cancellable  bool // Tracked as running, so that `stop` can cancel it.
+	hint         *commandHint
+	execute      commandExecutor
+}
+
+// commandRegistry holds the commands of the bot by their names and aliases.
+type commandRegistry struct {
+	commands []*botCommand
+	byName   map[string]*botCommand
+}
+
+func newCommandRegistry() *commandRegistry {
+	return &commandRegistry{byName: make(map[string]*botCommand)}
+}
+
+// register adds the command; its names and aliases must not be taken yet.
+func (r *commandRegistry) register(cmd botCommand) error {
+	names := append([]string{cmd.name}, cmd.aliases...)
+
+	for _, name := range names {
+		if _, ok := r.byName[name]; ok {
+			return errors.Errorf("command %q is already registered", name)
+		}
+	}
+
+	registered := &cmd
+
+	for _, name := range names {
+		r.byName[name] = registered
+	}
+
+	r.commands = append(r.commands, registered)
+
+	return nil
+}
+
+// lookup finds a command by its name or alias.
+func (r *commandRegistry) lookup(name string) (*botCommand, bool) {
+	cmd, ok := r.byName[name]
+
+	return cmd, ok
+}
+
+// hinted returns the commands to suggest for a message: the ones whose hints
+// match an SQL statement sent without a command or executed with `exec`.
+func (r *commandRegistry) hinted(command, query string) []*botCommand {
+	parts := strings.SplitN(query, " ", 2)
+	firstQueryWord := strings.ToLower(parts[0])
+
+	keywords := []string{command}
+
+	received, ok := r.lookup(command)
+	if ok && received.name == CommandExec && len(firstQueryWord) > 0 {
+		keywords = append(keywords, firstQueryWord)
+	}
+
+	var hinted []*botCommand
+
+	for _, cmd := range r.commands {
+		if cmd.hint != nil && cmd != received && slices.ContainsFunc(keywords, cmd.hint.match) {
+			hinted = append(hinted, cmd)
+		}
+	}
+
+	return hinted
+}
+
+// writeHelp writes a help line per command in the order of registration.
+func (r *commandRegistry) writeHelp(sb *strings.Builder) {
+	for _, cmd := range r.commands {
+		if cmd.help == "" {
+			continue
+		}
+
+		names := make([]string, 0, len(cmd.aliases)+1)
+
+		for _, name := range append([]string{cmd.name}, cmd.aliases...) {
+			if cmd.usage != "" {
+				name += " " + cmd.usage
+			}
+
+			names = append(names, "`"+name+"`")
+		}
+
+		fmt.Fprintf(sb, "• %s — %s\n", strings.Join(names, ", "), cmd.help)
+	}
+}
+
+// buildCommandRegistry registers the commands of the bot, then the Enterprise
+// commands of the feature pack.
+func (s *ProcessingService) buildCommandRegistry() *commandRegistry {
+	registry := newCommandRegistry()
+
+	for _, cmd := range s.builtinCommands() {
+		if err := registry.register(cmd); err != nil {
+			log.Err(err)
+		}
+	}
+
+	for _, description := range s.featurePack.Commands() {
+		if err := registry.register(s.enterpriseCommand(description)); err != nil {
+			log.Err(errors.Wrap(err, "failed to register an Enterprise command"))
+		}
+	}
+
+	return registry
+}
+
+// enterpriseCommand defines a command built by the command builder of the feature pack.
+func (s *ProcessingService) enterpriseCommand(description definition.CommandDescription) botCommand {
+	return botCommand{
+		name:         description.Name,
+		aliases:      description.Aliases,
+		usage:        description.Usage,
+		help:         description.Help,
+		needsSession: description.NeedsSession,
+		execute:      s.executeEnterprise,
+	}
+}
+
+// builtinCommands defines the commands of the bot in the order of the help message.
+func (s *ProcessingService) builtinCommands() []botCommand {
+	return []botCommand{
+		{
+			name:         CommandExplain,
+			help:         "analyze your query (SELECT, INSERT, DELETE, UPDATE or WITH) and generate recommendations",
+			needsSession: true,
+			cancellable:  true,
+			hint:         &commandHint{match: operator.IsDML, text: HintExplain},
+			execute:      s.executeExplain,
+		},
+		{
+			name:         CommandPlan,
+			help:         "analyze your query (SELECT, INSERT, DELETE, UPDATE or WITH) without execution",
+			needsSession: true,
+			execute: func(ctx context.Context, req *commandRequest) error {
+				return command.NewPlan(req.platformCmd, req.msg, req.user.Session.CloneConnection,
+					req.user.Session.DBVersion, s.messenger).Execute(ctx)
+			},
+		},
+		{
+			name:         CommandExec,
+			help:         "execute any query (for example, CREATE INDEX)",
+			needsSession: true,
+			cancellable:  true,
+			hint:         &commandHint{match: operator.IsDDL, text: HintExec},
+			execute: func(ctx context.Context, req *commandRequest) error {
+				timeout, err := s.statementTimeout(CommandExec, req.platformCmd)
+				if err != nil {
+					return err
+				}
+
+				return command.NewExec(req.platformCmd, req.msg, &req.user.Session, s.messenger, timeout,
+					s.config.Channel.ExecResults).Execute(ctx)
+			},
+		},
+		{
+			name:         CommandBegin,
+			help:         "open a transaction: `exec` and `explain` run inside it until `commit` or `rollback`",
+			needsSession: true,
+			execute:      s.executeTransaction,
+		},
+		{
+			name:         CommandSavepoint,
+			help:         "create a savepoint in the transaction, e.g. `savepoint step1`",
+			needsSession: true,
+			execute:      s.executeTransaction,
+		},
+		{
+			name:         CommandRollback,
+			help:         "roll back the transaction, or its changes after a savepoint with `rollback to step1`",
+			needsSession: true,
+			execute:      s.executeTransaction,
+		},
+		{
+			name:         CommandCommit,
+			help:         "commit the transaction",
+			needsSession: true,
+			execute:      s.executeTransaction,
+		},
+		{
+			name:         CommandMigration,
+			aliases:      []string{CommandDDLCheck},
+			help:         "run a DDL script statement by statement and report the locks, durations and table rewrites",
+			needsSession: true,
+			cancellable:  true,
+			execute: func(ctx context.Context, req *commandRequest) error {
+				timeout, err := s.statementTimeout(CommandMigration, req.platformCmd)
+				if err != nil {
+					return err
+				}
+
+				return command.NewMigrationCmd(req.platformCmd, req.msg, &req.user.Session, s.messenger, timeout).Execute(ctx)
+			},
+		},
+		{
+			name:    CommandStop,
+			aliases: []string{CommandCancel},
+			help:    "cancel your running `explain`, `exec` or `migration` command",
+			execute: func(ctx context.Context, req *commandRequest) error {
+				return command.NewStopCmd(req.platformCmd, req.msg, req.user.Session.Running, s.messenger).Execute(ctx)
+			},
+		},
+		{
+			name:    CommandQueue,
+			help:    "show your running and queued commands, `queue clear` removes the queued ones",
+			execute: s.executeQueue,
+		},
+		{
+			name:         CommandActivity,
+			help:         "show currently running sessions in Postgres (states: `active`, `idle in transaction`, `disabled`)",
+			needsSession: true,
+			execute: func(_ context.Context, req *commandRequest) error {
+				return command.NewActivityCmd(req.platformCmd, req.msg, req.user.Session.Pool, s.messenger).Execute()
+			},
+		},
+		{
+			name:         CommandTerminate,
+			usage:        "[pid]",
+			help:         "terminate Postgres backend that has the specified PID.",
+			needsSession: true,
+			execute: func(_ context.Context, req *commandRequest) error {
+				return command.NewTerminateCmd(req.platformCmd, req.msg, req.user.Session.Pool, s.messenger).Execute()
+			},
+		},
+		{
+			name:         CommandReset,
+			help:         "revert the database to the initial state (usually takes less than a minute, :warning: all changes will be lost)",
+			needsSession: true,
+			execute:      s.executeReset,
+		},
+		{
+			name: CommandPsqlD,
+			aliases: []string{CommandPsqlDP, CommandPsqlDT, CommandPsqlDTP, CommandPsqlDI, CommandPsqlDIP,
+				CommandPsqlL, CommandPsqlLP, CommandPsqlDV, CommandPsqlDVP, CommandPsqlDM, CommandPsqlDMP},
+			help:         "psql meta information commands",
+			needsSession: true,
+			execute: func(_ context.Context, req *commandRequest) error {
+				runner := pgtransmission.NewPgTransmitter(req.user.Session.ConnParams, pgtransmission.LogsEnabledDefault)
+				return command.Transmit(req.platformCmd, req.msg, s.messenger, runner)
+			},
+		},
+		{
+			name:         CommandHypo,
+			help:         "create hypothetical indexes using the HypoPG extension, `hypo advise` suggests indexes for a query",
+			needsSession: true,
+			execute: func(_ context.Context, req *commandRequest) error {
+				return command.NewHypo(req.platformCmd, req.msg, req.user.Session.Pool, s.messenger).Execute()
+			},
+		},
+		{
+			name:         CommandDiff,
+			help:         "compare the last two `explain` plans of the session node by node",
+			needsSession: true,
+			execute: func(_ context.Context, req *commandRequest) error {
+				return command.NewDiffCmd(req.platformCmd, req.msg, req.user.Session, s.messenger).Execute()
+			},
+		},
+		{
+			name:    CommandHelp,
+			help:    "this message",
+			execute: s.executeHelp,
+		},
+	}
+}
+
+func (s *ProcessingService) executeExplain(ctx context.Context, req *commandRequest) error {
+	timeout, err := s.statementTimeout(CommandExplain, req.platformCmd)
+	if err != nil {
+		return err
+	}
+
+	if err := command.Explain(ctx, s.messenger, req.platformCmd, req.msg, req.user.Session, s.config.Channel.PlanArtifacts,
+		timeout); err != nil {
+		return err
+	}
+
+	req.user.Session.AddExplainResult(usermanager.ExplainResult{
+		Query:       req.platformCmd.Query,
+		PlanJSON:    req.platformCmd.PlanExecJSON,
+		Fingerprint: req.platformCmd.PlanFingerprint,
+	})
+
+	return nil
+}
+
+func (s *ProcessingService) executeReset(ctx context.Context, req *commandRequest) error {
+	err := command.ResetSession(ctx, req.platformCmd, req.msg, s.DBLab, s.messenger, &req.user.Session, s.config.App.Version,
+		s.featurePack.Entertainer().GetEdition())
+
+	// TODO(akartasov): Find permanent solution,
+	//  it's a temporary fix for https://gitlab.com/postgres-ai/joe/-/issues/132.
+	if err != nil {
+		log.Err(fmt.Sprintf("Failed to reset session: %v. Trying to reboot session.", err))
+
+		// Try to reboot the session.
+		if err := s.rebootSession(ctx, req.msg, req.user); err != nil {
+			log.Err(err)
+		}
+
+		return errSessionRebooted
+	}
+
+	return nil
+}
+
+// executeQueue shows or clears the commands of the user in the queue, it does not need a session.
+func (s *ProcessingService) executeQueue(_ context.Context, req *commandRequest) error {
+	userID := req.user.UserInfo.ID
+
+	switch option := strings.ToLower(req.platformCmd.Query); option {
+	case "":
+		req.msg.AppendText(renderQueue(s.queue.list(userID)))
+
+	case "clear":
+		req.msg.AppendText(fmt.Sprintf("Removed %s from the queue.", english.Plural(s.queue.clear(userID), "command", "")))
+
+	default:
+		return errors.Errorf("unknown option %q, use `queue` or `queue clear`", option)
+	}
+
+	return s.messenger.Publish(req.msg)
+}
+
+// renderQueue describes the commands of a user in the queue.
+func renderQueue(items []queueItem) string {
+	if len(items) == 0 {
+		return "You have no running or queued commands."
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("Your commands:\n")
+
+	for _, item := range items {
+		state := "running"
+		if item.Position > 0 {
+			state = fmt.Sprintf("queued (position %d in the channel queue)", item.Position)
+		}
+
+		fmt.Fprintf(&sb, "• `%s` — %s, received %s ago\n", item.Command, state,
+			util.DurationToString(time.Since(item.EnqueuedAt).Truncate(time.Second)))
+	}
+
+	return sb.String()
+}
+
+// executeTransaction runs the commands controlling the transaction of the user.
+func (s *ProcessingService) executeTransaction(ctx context.Context, req *commandRequest) error {
+	return command.NewTransactionCmd(req.platformCmd, req.msg, &req.user.Session, s.messenger).Execute(ctx)
+}
+
+// statementTimeout returns the statement timeout of the queries of a command in the channel,
+// or the one requested with command.TimeoutOption, which it removes from the query.
+func (s *ProcessingService) statementTimeout(name string, platformCmd *platform.Command) (command.StatementTimeout, error) {
+	timeout, maxTimeout := s.config.Channel.Limits.CommandTimeout(name)
+	limit := command.StatementTimeout{Timeout: timeout, Max: maxTimeout}
+
+	requested, query, ok, err := command.ParseTimeoutOption(platformCmd.Query)
+	if err != nil || !ok {
+		return limit, err
+	}
+
+	platformCmd.Query = query
+
+	return limit.Request(requested)
+}
+
+// executeHelp shows the help message, it does not need a session.
+func (s *ProcessingService) executeHelp(_ context.Context, req *commandRequest) error {
+	req.msg.SetText(appendSessionID(s.appendHelp(req.msg.Text), req.user))
+
+	return s.messenger.Publish(req.msg)
+}
+
+// executeEnterprise executes an Enterprise command with the command builder of the feature pack.
+func (s *ProcessingService) executeEnterprise(_ context.Context, req *commandRequest) error {
+	builder := s.featurePack.CmdBuilder()(req.platformCmd, req.msg, req.user.Session.Pool, s.messenger)
+
+	executor, ok := builder.(definition.Executor)
+	if !ok {
+		return errors.Errorf("command %q is not supported by the %s edition", req.platformCmd.Command,
+			s.featurePack.Entertainer().GetEdition())
+	}
+
+	return executor.Execute()
+}
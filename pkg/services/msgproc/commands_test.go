@@ -0,0 +1,224 @@
+/*
+2026 © Postgres.ai
+*/
+
+package msgproc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/postgres-ai/joe/features"
+	"gitlab.com/postgres-ai/joe/features/definition"
+	"gitlab.com/postgres-ai/joe/pkg/connection"
+	"gitlab.com/postgres-ai/joe/pkg/models"
+	"gitlab.com/postgres-ai/joe/pkg/services/platform"
+	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
+)
+
+func newTestRegistry() *commandRegistry {
+	s := &ProcessingService{featurePack: features.NewPack()}
+
+	return s.buildCommandRegistry()
+}
+
+func TestCommandRegistryLookup(t *testing.T) {
+	registry := newTestRegistry()
+
+	explain, ok := registry.lookup(CommandExplain)
+	require.True(t, ok)
+	assert.Equal(t, CommandExplain, explain.name)
+	assert.True(t, explain.needsSession)
+
+	psql, ok := registry.lookup(CommandPsqlDTP)
+	require.True(t, ok)
+	assert.Equal(t, CommandPsqlD, psql.name)
+
+	stop, ok := registry.lookup(CommandCancel)
+	require.True(t, ok)
+	assert.Equal(t, CommandStop, stop.name)
+	assert.False(t, stop.needsSession, "stop must not wait for a session")
+
+	help, ok := registry.lookup(CommandHelp)
+	require.True(t, ok)
+	assert.False(t, help.needsSession)
+
+	_, ok = registry.lookup("select")
+	assert.False(t, ok)
+}
+
+func TestCommandRegistryRegister(t *testing.T) {
+	registry := newCommandRegistry()
+
+	require.NoError(t, registry.register(botCommand{name: "quota", aliases: []string{"q"}}))
+	require.EqualError(t, registry.register(botCommand{name: "q"}), `command "q" is already registered`)
+	require.EqualError(t, registry.register(botCommand{name: "usage", aliases: []string{"quota"}}),
+		`command "quota" is already registered`)
+
+	_, ok := registry.lookup("usage")
+	assert.False(t, ok, "a rejected command must not be registered partially")
+	assert.Len(t, registry.commands, 1)
+}
+
+func TestCommandRegistryEnterpriseCommands(t *testing.T) {
+	s := &ProcessingService{featurePack: features.NewPack()}
+	registry := s.buildCommandRegistry()
+
+	require.NoError(t, registry.register(s.enterpriseCommand(definition.CommandDescription{
+		Name: "quota", Aliases: []string{"usage"}, Usage: "[period]", Help: "show the quota usage", NeedsSession: true,
+	})))
+
+	quota, ok := registry.lookup("usage")
+	require.True(t, ok)
+	assert.Equal(t, "quota", quota.name)
+	assert.True(t, quota.needsSession)
+
+	sb := strings.Builder{}
+	registry.writeHelp(&sb)
+
+	assert.True(t, strings.HasSuffix(sb.String(), "• `help` — this message\n• `quota [period]`, `usage [period]` — show the quota usage\n"))
+
+	// Enterprise commands must not take the names of the commands of the bot.
+	assert.Error(t, registry.register(s.enterpriseCommand(definition.CommandDescription{Name: CommandExplain})))
+}
+
+func TestCommandRegistryHelp(t *testing.T) {
+	sb := strings.Builder{}
+	newTestRegistry().writeHelp(&sb)
+
+	assert.Equal(t, "• `explain` — analyze your query (SELECT, INSERT, DELETE, UPDATE or WITH) and generate recommendations\n"+
+		"• `plan` — analyze your query (SELECT, INSERT, DELETE, UPDATE or WITH) without execution\n"+
+		"• `exec` — execute any query (for example, CREATE INDEX)\n"+
+		"• `begin` — open a transaction: `exec` and `explain` run inside it until `commit` or `rollback`\n"+
+		"• `savepoint` — create a savepoint in the transaction, e.g. `savepoint step1`\n"+
+		"• `rollback` — roll back the transaction, or its changes after a savepoint with `rollback to step1`\n"+
+		"• `commit` — commit the transaction\n"+
+		"• `migration`, `ddl-check` — run a DDL script statement by statement and report the locks, durations and table rewrites\n"+
+		"• `stop`, `cancel` — cancel your running `explain`, `exec` or `migration` command\n"+
+		"• `queue` — show your running and queued commands, `queue clear` removes the queued ones\n"+
+		"• `activity` — show currently running sessions in Postgres (states: `active`, `idle in transaction`, `disabled`)\n"+
+		"• `terminate [pid]` — terminate Postgres backend that has the specified PID.\n"+
+		"• `reset` — revert the database to the initial state (usually takes less than a minute, :warning: all changes will be lost)\n"+
+		"• `\\d`, `\\d+`, `\\dt`, `\\dt+`, `\\di`, `\\di+`, `\\l`, `\\l+`, `\\dv`, `\\dv+`, `\\dm`, `\\dm+` — psql meta information commands\n"+
+		"• `hypo` — create hypothetical indexes using the HypoPG extension, `hypo advise` suggests indexes for a query\n"+
+		"• `diff` — compare the last two `explain` plans of the session node by node\n"+
+		"• `help` — this message\n", sb.String())
+}
+
+func TestCommandRegistryHints(t *testing.T) {
+	registry := newTestRegistry()
+
+	hints := func(command, query string) []string {
+		var names []string
+
+		for _, cmd := range registry.hinted(command, query) {
+			names = append(names, cmd.name)
+		}
+
+		return names
+	}
+
+	assert.Equal(t, []string{CommandExplain}, hints("select", "* from t"))
+	assert.Equal(t, []string{CommandExec}, hints("create", "index on t (id)"))
+	assert.Equal(t, []string{CommandExplain}, hints(CommandExec, "SELECT 1"))
+	assert.Empty(t, hints(CommandExec, "create index on t (id)"))
+	assert.Empty(t, hints(CommandExplain, "select 1"))
+	assert.Empty(t, hints(CommandHelp, ""))
+}
+
+// testMessenger records the messages published by the bot.
+type testMessenger struct {
+	connection.Messenger
+	published []string
+}
+
+func (m *testMessenger) Publish(message *models.Message) error {
+	m.published = append(m.published, message.Text)
+	return nil
+}
+
+func (m *testMessenger) Fail(message *models.Message, text string) error {
+	m.published = append(m.published, message.Text+text)
+	return nil
+}
+
+type testValidator struct{}
+
+func (testValidator) Validate(*models.IncomingMessage) error { return nil }
+
+type testUserInformer struct{}
+
+func (testUserInformer) GetUserInfo(userID string) (models.UserInfo, error) {
+	return models.UserInfo{ID: userID}, nil
+}
+
+// testQuotaCmd is an Enterprise command replying with the period it is given.
+type testQuotaCmd struct {
+	command   *platform.Command
+	message   *models.Message
+	messenger connection.Messenger
+}
+
+func (c *testQuotaCmd) Execute() error {
+	c.message.AppendText("quota for " + c.command.Query)
+	return c.messenger.Publish(c.message)
+}
+
+func TestEnterpriseCommandDispatch(t *testing.T) {
+	builder := func(cmd *platform.Command, msg *models.Message, _ *pgxpool.Pool, msgSvc connection.Messenger) definition.CmdBuilder {
+		return &testQuotaCmd{command: cmd, message: msg, messenger: msgSvc}
+	}
+
+	pack := features.BuildPack(builder, []definition.CommandDescription{
+		{Name: "quota", Usage: "[period]", Help: "show the quota usage"},
+	}, features.GetEntertainer())
+
+	messenger := &testMessenger{}
+	s := NewProcessingService(messenger, testValidator{}, nil,
+		usermanager.NewUserManager(testUserInformer{}, definition.Quota{}, nil), nil, ProcessingConfig{}, pack)
+
+	s.ProcessMessageEvent(context.Background(), models.IncomingMessage{UserID: "U1", Text: "quota 1d"})
+	require.Len(t, messenger.published, 1)
+	assert.Equal(t, "```quota 1d```\n"+models.ChatAppendSeparator+"quota for 1d", messenger.published[0])
+
+	s.ProcessMessageEvent(context.Background(), models.IncomingMessage{UserID: "U1", Text: "help"})
+	require.Len(t, messenger.published, 2)
+	assert.Contains(t, messenger.published[1], "• `quota [period]` — show the quota usage\n")
+}
+
+// TestStopConcurrentWithSession runs `stop` while the goroutine of the session updates it,
+// e.g. when the session is stopped. Run it with -race.
+func TestStopConcurrentWithSession(t *testing.T) {
+	userManager := usermanager.NewUserManager(testUserInformer{}, definition.Quota{}, nil)
+	messenger := &testMessenger{}
+	s := NewProcessingService(messenger, testValidator{}, nil, userManager, nil, ProcessingConfig{}, features.NewPack())
+
+	user, err := userManager.CreateUser("U1")
+	require.NoError(t, err)
+
+	user.Session.Running.Start(CommandExec, &models.Message{}, user.Session.Pool)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for range 100 {
+			user.Session.Pool = nil
+			user.Session.LastActionTs = time.Now()
+		}
+	}()
+
+	s.ProcessMessageEvent(context.Background(), models.IncomingMessage{UserID: "U1", Text: "stop"})
+	<-done
+
+	require.Len(t, messenger.published, 1)
+	assert.Contains(t, messenger.published[0], "Cancelled `exec` started")
+	assert.Contains(t, messenger.published[0], "before its query started.")
+}
@@ -27,18 +27,11 @@ import (
 	"gitlab.com/postgres-ai/joe/pkg/util"
 )
 
-// HelpMessage defines available commands provided with the help message.
-const HelpMessage = "\n• `explain` — analyze your query (SELECT, INSERT, DELETE, UPDATE or WITH) and generate recommendations\n" +
-	"• `plan` — analyze your query (SELECT, INSERT, DELETE, UPDATE or WITH) without execution\n" +
-	"• `exec` — execute any query (for example, CREATE INDEX)\n" +
-	"• `activity` — show currently running sessions in Postgres (states: `active`, `idle in transaction`, `disabled`)\n" +
-	"• `terminate [pid]` — terminate Postgres backend that has the specified PID.\n" +
-	"• `reset` — revert the database to the initial state (usually takes less than a minute, :warning: all changes will be lost)\n" +
-	"• `\\d`, `\\d+`, `\\dt`, `\\dt+`, `\\di`, `\\di+`, `\\l`, `\\l+`, `\\dv`, `\\dv+`, `\\dm`, `\\dm+` — psql meta information commands\n" +
-	"• `hypo` — create hypothetical indexes using the HypoPG extension\n" +
-	"• `help` — this message\n\n" +
-	"• Sessions are fully independent. Feel free to do anything.\n" +
+// HelpNotes provides notes shown in the help message after the list of commands.
+const HelpNotes = "• Sessions are fully independent. Feel free to do anything.\n" +
 	"• The session will be destroyed after the certain amount of time ('idle timeout') of inactivity.\n" +
+	"• Add `--timeout=5m` after `explain`, `exec` or `migration` to change the statement timeout of its queries.\n" +
+	"• `plan` shows a generic plan for a query with `$1` placeholders; add `--params 42,'abc'` after `plan` for a custom plan.\n" +
 	"• EXPLAIN plans here are expected to be identical to production plans.\n" +
 	"• The actual timing values may differ from production because actual caches in DB Lab are smaller. " +
 	"However, the number of bytes and pages/buffers in plans match the production database.\n"
@@ -46,6 +39,15 @@ const HelpMessage = "\n• `explain` — analyze your query (SELECT, INSERT, DEL
 // MsgSessionStarting provides a message for a session start.
 const MsgSessionStarting = "Starting a new session..."
 
+// MsgCommandCancelled provides a message for a command cancelled with `stop`.
+const MsgCommandCancelled = "The command has been cancelled with `stop`."
+
+// MsgCommandQueued provides a message for a command waiting for the previous commands.
+const MsgCommandQueued = "Queued (position %d in the channel queue), the command will start after the previous ones."
+
+// MsgCommandDequeued provides a message for a command removed from the queue.
+const MsgCommandDequeued = "Removed from the queue with `queue clear`."
+
 // SeparatorEllipsis provides a separator for cut messages.
 const SeparatorEllipsis = "\n[...SKIP...]\n"
 
@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"slices"
 	"strings"
 	"time"
 	"unicode"
@@ -28,8 +27,6 @@ import (
 	"gitlab.com/postgres-ai/joe/pkg/models"
 	"gitlab.com/postgres-ai/joe/pkg/services/platform"
 	"gitlab.com/postgres-ai/joe/pkg/services/usermanager"
-	"gitlab.com/postgres-ai/joe/pkg/transmission/pgtransmission"
-	"gitlab.com/postgres-ai/joe/pkg/util/operator"
 	"gitlab.com/postgres-ai/joe/pkg/util/text"
 )
 
@@ -43,6 +40,16 @@ const (
 	CommandActivity  = "activity"
 	CommandTerminate = "terminate"
 	CommandPlan      = "plan"
+	CommandDiff      = "diff"
+	CommandStop      = "stop"
+	CommandCancel    = "cancel"
+	CommandQueue     = "queue"
+	CommandBegin     = "begin"
+	CommandSavepoint = "savepoint"
+	CommandRollback  = "rollback"
+	CommandCommit    = "commit"
+	CommandMigration = "migration"
+	CommandDDLCheck  = "ddl-check"
 
 	CommandPsqlD   = `\d`
 	CommandPsqlDP  = `\d+`
@@ -58,45 +65,6 @@ const (
 	CommandPsqlDMP = `\dm+`
 )
 
-var supportedCommands = []string{
-	CommandExplain,
-	CommandPlan,
-	CommandHypo,
-	CommandExec,
-	CommandReset,
-	CommandActivity,
-	CommandTerminate,
-	CommandHelp,
-
-	CommandPsqlD,
-	CommandPsqlDP,
-	CommandPsqlDT,
-	CommandPsqlDTP,
-	CommandPsqlDI,
-	CommandPsqlDIP,
-	CommandPsqlL,
-	CommandPsqlLP,
-	CommandPsqlDV,
-	CommandPsqlDVP,
-	CommandPsqlDM,
-	CommandPsqlDMP,
-}
-
-var allowedPsqlCommands = []string{
-	CommandPsqlD,
-	CommandPsqlDP,
-	CommandPsqlDT,
-	CommandPsqlDTP,
-	CommandPsqlDI,
-	CommandPsqlDIP,
-	CommandPsqlL,
-	CommandPsqlLP,
-	CommandPsqlDV,
-	CommandPsqlDVP,
-	CommandPsqlDM,
-	CommandPsqlDMP,
-}
-
 type ProcessingService struct {
 	featurePack      *features.Pack
 	messageValidator connection.MessageValidator
@@ -105,6 +73,8 @@ type ProcessingService struct {
 	UserManager      *usermanager.UserManager
 	platformManager  *platform.Client
 	config           ProcessingConfig
+	commands         *commandRegistry
+	queue            *commandQueue
 
 	// TODO (akartasov): Add specific services.
 	//Auditor
@@ -118,13 +88,14 @@ type ProcessingConfig struct {
 	DBLab    config.DBLabParams
 	EntOpts  definition.EnterpriseOptions
 	Project  string
+	Channel  config.Channel
 }
 
 // NewProcessingService creates a new processing service.
 func NewProcessingService(messengerSvc connection.Messenger, msgValidator connection.MessageValidator, dblab *dblabapi.Client,
 	userSvc *usermanager.UserManager, platform *platform.Client, cfg ProcessingConfig,
 	featurePack *features.Pack) *ProcessingService {
-	return &ProcessingService{
+	s := &ProcessingService{
 		featurePack:      featurePack,
 		messageValidator: msgValidator,
 		messenger:        messengerSvc,
@@ -132,7 +103,12 @@ func NewProcessingService(messengerSvc connection.Messenger, msgValidator connec
 		UserManager:      userSvc,
 		platformManager:  platform,
 		config:           cfg,
+		queue:            newCommandQueue(cfg.Channel.Limits.MaxRunningCommands),
 	}
+
+	s.commands = s.buildCommandRegistry()
+
+	return s
 }
 
 // ProcessMessageEvent replies to a message.
@@ -156,11 +132,6 @@ func (s *ProcessingService) ProcessMessageEvent(ctx context.Context, incomingMes
 		return
 	}
 
-	if err := s.prepareUserSession(ctx, user, incomingMessage); err != nil {
-		log.Err(err)
-		return
-	}
-
 	// Filter and prepare message.
 	message := strings.TrimSpace(incomingMessage.Text)
 	message = strings.Trim(message, "`")
@@ -194,22 +165,12 @@ func (s *ProcessingService) ProcessMessageEvent(ctx context.Context, incomingMes
 
 	s.showBotHints(incomingMessage, receivedCommand, query)
 
-	if !slices.Contains(supportedCommands, receivedCommand) {
+	cmd, ok := s.commands.lookup(receivedCommand)
+	if !ok {
 		log.Dbg("Message filtered: Not a command")
 		return
 	}
 
-	if err := user.RequestQuota(); err != nil {
-		log.Err("Quota: ", err)
-
-		if err := s.messenger.Fail(models.NewMessage(incomingMessage), err.Error()); err != nil {
-			log.Err(errors.Wrap(err, "failed to request quotas"))
-			return
-		}
-
-		return
-	}
-
 	// We want to save message height space for more valuable info.
 	queryPreview := strings.ReplaceAll(query, "\n", " ")
 	queryPreview = strings.ReplaceAll(queryPreview, "\t", " ")
@@ -238,33 +199,72 @@ func (s *ProcessingService) ProcessMessageEvent(ctx context.Context, incomingMes
 
 	msgText := fmt.Sprintf("```%s %s```\n", receivedCommand, queryPreview)
 
-	// Show `help` command without initializing of a session.
-	if receivedCommand == CommandHelp {
+	// Some commands, like `help`, are shown without initializing of a session.
+	if !cmd.needsSession {
 		msg := models.NewMessage(incomingMessage)
-
-		msgText = s.appendHelp(msgText)
-		msgText = appendSessionID(msgText, user)
 		msg.SetText(msgText)
 
-		if err := s.messenger.Publish(msg); err != nil {
-			// TODO(anatoly): Retry.
-			log.Err("Bot: Cannot publish a message", err)
+		req := &commandRequest{
+			user:        user,
+			platformCmd: &platform.Command{Command: receivedCommand, Query: query, Timestamp: incomingMessage.Timestamp},
+			msg:         msg,
+		}
+
+		if err := cmd.execute(ctx, req); err != nil {
+			log.Err("Bot: Cannot execute a command", err)
+
+			if err := s.messenger.Fail(msg, err.Error()); err != nil {
+				log.Err(err)
+			}
 		}
 
 		return
 	}
 
-	if err := s.runSession(ctx, user, incomingMessage); err != nil {
+	msg := models.NewMessage(incomingMessage)
+
+	// The commands of a user share the session, so they run one by one.
+	release, err := s.queue.acquire(ctx, user.UserInfo.ID, receivedCommand, func(position int) {
+		s.showQueued(msg, msgText, position)
+	})
+	if err != nil {
+		s.markDequeued(msg, msgText, err)
+		return
+	}
+
+	defer release()
+
+	// The session and the quota are shared by the commands of the user, so they are only touched
+	// once the previous commands have finished. Commands without a session, e.g. `stop`, touch neither.
+	if err := s.prepareUserSession(ctx, user, incomingMessage); err != nil {
 		log.Err(err)
+		s.failMessage(msg, msgText, err)
+
 		return
 	}
 
-	msg := models.NewMessage(incomingMessage)
+	if err := user.RequestQuota(); err != nil {
+		log.Err("Quota: ", err)
+		s.failMessage(msg, msgText, err)
+
+		return
+	}
+
+	if err := s.runSession(ctx, user, incomingMessage); err != nil {
+		log.Err(err)
+		return
+	}
 
 	msgText = appendSessionID(msgText, user)
 	msg.SetText(msgText)
 
-	if err := s.messenger.Publish(msg); err != nil {
+	if msg.IsPublished() {
+		err = s.messenger.UpdateText(msg)
+	} else {
+		err = s.messenger.Publish(msg)
+	}
+
+	if err != nil {
 		// TODO(anatoly): Retry.
 		log.Err("Bot: Cannot publish a message", err)
 		return
@@ -287,55 +287,40 @@ func (s *ProcessingService) ProcessMessageEvent(ctx context.Context, incomingMes
 		Timestamp: incomingMessage.Timestamp,
 	}
 
-	switch {
-	case receivedCommand == CommandExplain:
-		err = command.Explain(ctx, s.messenger, platformCmd, msg, user.Session)
-
-	case receivedCommand == CommandPlan:
-		planCmd := command.NewPlan(platformCmd, msg, user.Session.CloneConnection, s.messenger)
-		err = planCmd.Execute(ctx)
-
-	case receivedCommand == CommandExec:
-		execCmd := command.NewExec(platformCmd, msg, user.Session, s.messenger)
-		err = execCmd.Execute(ctx)
-
-	case receivedCommand == CommandReset:
-		err = command.ResetSession(ctx, platformCmd, msg, s.DBLab, s.messenger, &user.Session, s.config.App.Version,
-			s.featurePack.Entertainer().GetEdition())
+	var running *usermanager.RunningCommand
 
-		// TODO(akartasov): Find permanent solution,
-		//  it's a temporary fix for https://gitlab.com/postgres-ai/joe/-/issues/132.
-		if err != nil {
-			log.Err(fmt.Sprintf("Failed to reset session: %v. Trying to reboot session.", err))
+	if cmd.cancellable {
+		running = user.Session.Running.Start(cmd.name, msg, user.Session.Pool)
+		defer user.Session.Running.Finish(running)
+	}
 
-			// Try to reboot the session.
-			if err := s.rebootSession(ctx, msg, user); err != nil {
-				log.Err(err)
-			}
+	err = cmd.execute(ctx, &commandRequest{user: user, platformCmd: platformCmd, msg: msg})
 
-			return
-		}
+	// Restore the timeout of the session once the transaction opened by statements with their own timeout has ended.
+	command.RestoreStatementTimeout(context.WithoutCancel(ctx), &user.Session)
 
-	case receivedCommand == CommandHypo:
-		hypoCmd := command.NewHypo(platformCmd, msg, user.Session.Pool, s.messenger)
-		err = hypoCmd.Execute()
+	if errors.Is(err, errSessionRebooted) {
+		return
+	}
 
-	case receivedCommand == CommandActivity:
-		activityCmd := command.NewActivityCmd(platformCmd, msg, user.Session.Pool, s.messenger)
-		err = activityCmd.Execute()
+	if err != nil && running != nil && user.Session.Running.Cancelled(running) {
+		s.markCancelled(msg)
 
-	case receivedCommand == CommandTerminate:
-		terminateCmd := command.NewTerminateCmd(platformCmd, msg, user.Session.Pool, s.messenger)
-		err = terminateCmd.Execute()
+		user.Session.LastActionTs = time.Now()
 
-	case slices.Contains(allowedPsqlCommands, receivedCommand):
-		runner := pgtransmission.NewPgTransmitter(user.Session.ConnParams, pgtransmission.LogsEnabledDefault)
-		err = command.Transmit(platformCmd, msg, s.messenger, runner)
+		return
 	}
 
 	if err != nil {
 		if _, ok := err.(*net.OpError); !ok && !errors.As(err, &runners.RunnerError{}) {
-			if err := s.messenger.Fail(msg, err.Error()); err != nil {
+			errText := err.Error()
+
+			// The report of the command may end with the state already, e.g. the one of `migration`.
+			if state := user.Session.TransactionState(); state != "" && !strings.HasSuffix(msg.Text, state) {
+				errText += models.ChatAppendSeparator + state
+			}
+
+			if err := s.messenger.Fail(msg, errText); err != nil {
 				log.Err(err)
 			}
 
@@ -374,6 +359,15 @@ func (s *ProcessingService) ProcessMessageEvent(ctx context.Context, incomingMes
 
 	user.Session.LastActionTs = time.Now()
 
+	// Remind of the open transaction in the footer.
+	if state := user.Session.TransactionState(); state != "" {
+		msg.AppendText(state)
+
+		if err := s.messenger.UpdateText(msg); err != nil {
+			log.Err(err)
+		}
+	}
+
 	if err := s.messenger.OK(msg); err != nil {
 		log.Err(err)
 	}
@@ -390,7 +384,9 @@ func (s *ProcessingService) saveHistory(ctx context.Context, msg *models.Message
 		return errors.Wrap(err, "failed to post a command")
 	}
 
-	if commandResponse.CommandLink != "" && platformCmd.Command == CommandExplain {
+	cmd, ok := s.commands.lookup(platformCmd.Command)
+
+	if commandResponse.CommandLink != "" && ok && cmd.name == CommandExplain {
 		msg.AppendText(fmt.Sprintf("Details and visualization: %s.", commandResponse.CommandLink))
 
 		if err := s.messenger.UpdateText(msg); err != nil {
@@ -421,6 +417,68 @@ func (s *ProcessingService) prepareUserSession(ctx context.Context, user *userma
 	return nil
 }
 
+// markCancelled marks the message of a command cancelled with `stop`.
+func (s *ProcessingService) markCancelled(msg *models.Message) {
+	msg.AppendText(MsgCommandCancelled)
+
+	if err := s.messenger.UpdateText(msg); err != nil {
+		log.Err(err)
+	}
+
+	if err := s.messenger.UpdateStatus(msg, models.StatusCancelled); err != nil {
+		log.Err(err)
+	}
+}
+
+// showQueued shows the position of a command waiting in the queue.
+func (s *ProcessingService) showQueued(msg *models.Message, msgText string, position int) {
+	msg.SetText(msgText + fmt.Sprintf(MsgCommandQueued, position))
+
+	if msg.IsPublished() {
+		if err := s.messenger.UpdateText(msg); err != nil {
+			log.Err(err)
+		}
+	} else if err := s.messenger.Publish(msg); err != nil {
+		log.Err("Bot: Cannot publish a message", err)
+		return
+	}
+
+	if err := s.messenger.UpdateStatus(msg, models.StatusQueued); err != nil {
+		log.Err(err)
+	}
+}
+
+// failMessage fails the message of a command that cannot run.
+func (s *ProcessingService) failMessage(msg *models.Message, msgText string, err error) {
+	msg.SetText(msgText)
+
+	if failErr := s.messenger.Fail(msg, err.Error()); failErr != nil {
+		log.Err(failErr)
+	}
+}
+
+// markDequeued marks the message of a command that left the queue without running.
+func (s *ProcessingService) markDequeued(msg *models.Message, msgText string, err error) {
+	if !errors.Is(err, errQueueCleared) {
+		log.Err(err)
+		return
+	}
+
+	msg.SetText(msgText + MsgCommandDequeued)
+
+	if !msg.IsPublished() {
+		return
+	}
+
+	if err := s.messenger.UpdateText(msg); err != nil {
+		log.Err(err)
+	}
+
+	if err := s.messenger.UpdateStatus(msg, models.StatusCancelled); err != nil {
+		log.Err(err)
+	}
+}
+
 // rebootSession stops a Joe session and creates a new one.
 func (s *ProcessingService) rebootSession(ctx context.Context, msg *models.Message, user *usermanager.User) error {
 	msg.AppendText("Session was closed by Database Lab.\n")
@@ -453,30 +511,14 @@ func (s *ProcessingService) ProcessAppMentionEvent(incomingMessage models.Incomi
 
 // Show bot usage hints.
 func (s *ProcessingService) showBotHints(incomingMessage models.IncomingMessage, command string, query string) {
-	parts := strings.SplitN(query, " ", 2)
-	firstQueryWord := strings.ToLower(parts[0])
-
-	checkQuery := len(firstQueryWord) > 0 && command == CommandExec
-
-	if (checkQuery && operator.IsDML(firstQueryWord)) || operator.IsDML(command) {
-		msg := models.NewMessage(incomingMessage)
-		msg.SetMessageType(models.MessageTypeEphemeral)
-		msg.SetUserID(incomingMessage.UserID)
-		msg.SetText(HintExplain)
-
-		if err := s.messenger.Publish(msg); err != nil {
-			log.Err("Hint explain:", err)
-		}
-	}
-
-	if operator.IsDDL(command) {
+	for _, cmd := range s.commands.hinted(command, query) {
 		msg := models.NewMessage(incomingMessage)
 		msg.SetMessageType(models.MessageTypeEphemeral)
 		msg.SetUserID(incomingMessage.UserID)
-		msg.SetText(HintExec)
+		msg.SetText(cmd.hint.text)
 
 		if err := s.messenger.Publish(msg); err != nil {
-			log.Err("Hint exec:", err)
+			log.Err("Hint "+cmd.name+":", err)
 		}
 	}
 }
@@ -486,7 +528,10 @@ func (s *ProcessingService) appendHelp(text string) string {
 	entertainerSvc := s.featurePack.Entertainer()
 
 	sb.WriteString(text)
-	sb.WriteString(HelpMessage)
+	sb.WriteString("\n")
+	s.commands.writeHelp(&sb)
+	sb.WriteString("\n")
+	sb.WriteString(HelpNotes)
 	sb.WriteString(entertainerSvc.GetEnterpriseHelpMessage())
 	fmt.Fprintf(&sb, "Version: %s (%s)\n", s.config.App.Version, entertainerSvc.GetEdition())
 
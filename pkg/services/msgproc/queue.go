@@ -0,0 +1,238 @@
+/*
+2026 © Postgres.ai
+*/
+
+package msgproc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errQueueCleared tells that a queued command has been removed from the queue with `queue clear`.
+var errQueueCleared = errors.New("removed from the queue")
+
+// queueState is the state of a command in the queue.
+type queueState int
+
+const (
+	queueWaiting queueState = iota
+	queueRunning
+	queueCleared
+)
+
+// queueEntry is a command in the queue.
+type queueEntry struct {
+	userID     string
+	command    string
+	enqueuedAt time.Time
+	state      queueState
+
+	// signal wakes up the waiting command when its state or position may have changed.
+	signal chan struct{}
+}
+
+// queueItem describes a command in the queue. Position is the place of a waiting command among
+// the waiting commands of all users of the channel, 0 for a running one.
+type queueItem struct {
+	Command    string
+	Position   int
+	EnqueuedAt time.Time
+}
+
+// commandQueue serializes the commands of every user, since the commands of a user share
+// the connection of the session, and caps the number of commands running in the channel.
+// Commands start in the order they were received.
+type commandQueue struct {
+	mu         sync.Mutex
+	maxRunning int // 0 for no cap.
+	running    []*queueEntry
+	waiting    []*queueEntry
+}
+
+func newCommandQueue(maxRunning int) *commandQueue {
+	return &commandQueue{maxRunning: maxRunning}
+}
+
+// acquire waits until the command of the user may run, calling onQueued with the position of the
+// command among the waiting commands of the channel whenever it changes while waiting. The returned function releases the queue
+// after the command has finished.
+func (q *commandQueue) acquire(ctx context.Context, userID, command string, onQueued func(position int)) (func(), error) {
+	entry := q.enqueue(userID, command)
+
+	lastPosition := 0
+
+	for {
+		state, position := q.state(entry)
+
+		switch state {
+		case queueRunning:
+			return func() { q.release(entry) }, nil
+
+		case queueCleared:
+			return nil, errQueueCleared
+
+		case queueWaiting:
+		}
+
+		if position != lastPosition {
+			onQueued(position)
+			lastPosition = position
+		}
+
+		select {
+		case <-entry.signal:
+		case <-ctx.Done():
+			q.release(entry)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (q *commandQueue) enqueue(userID, command string) *queueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry := &queueEntry{
+		userID:     userID,
+		command:    command,
+		enqueuedAt: time.Now(),
+		signal:     make(chan struct{}, 1),
+	}
+
+	q.waiting = append(q.waiting, entry)
+	q.schedule()
+
+	return entry
+}
+
+// state returns the state of the command and its position among the waiting ones.
+func (q *commandQueue) state(entry *queueEntry) (queueState, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, waiting := range q.waiting {
+		if waiting == entry {
+			return entry.state, i + 1
+		}
+	}
+
+	return entry.state, 0
+}
+
+// release removes a finished or abandoned command from the queue and starts the next ones.
+func (q *commandQueue) release(entry *queueEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.running = removeEntry(q.running, entry)
+	q.waiting = removeEntry(q.waiting, entry)
+	q.schedule()
+}
+
+// schedule starts the waiting commands of the users without running ones, within the cap,
+// and wakes up the others to update their positions. It must be called with the lock held.
+func (q *commandQueue) schedule() {
+	busy := make(map[string]bool, len(q.running))
+
+	for _, entry := range q.running {
+		busy[entry.userID] = true
+	}
+
+	waiting := q.waiting[:0]
+
+	for _, entry := range q.waiting {
+		if busy[entry.userID] || q.maxRunning > 0 && len(q.running) >= q.maxRunning {
+			// Later commands of the user wait for this one.
+			busy[entry.userID] = true
+			waiting = append(waiting, entry)
+
+			continue
+		}
+
+		entry.state = queueRunning
+		busy[entry.userID] = true
+		q.running = append(q.running, entry)
+	}
+
+	q.waiting = waiting
+
+	for _, entry := range q.running {
+		notify(entry)
+	}
+
+	for _, entry := range q.waiting {
+		notify(entry)
+	}
+}
+
+// list returns the running and waiting commands of the user.
+func (q *commandQueue) list(userID string) []queueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var items []queueItem
+
+	for _, entry := range q.running {
+		if entry.userID == userID {
+			items = append(items, queueItem{Command: entry.command, EnqueuedAt: entry.enqueuedAt})
+		}
+	}
+
+	for i, entry := range q.waiting {
+		if entry.userID == userID {
+			items = append(items, queueItem{Command: entry.command, Position: i + 1, EnqueuedAt: entry.enqueuedAt})
+		}
+	}
+
+	return items
+}
+
+// clear removes the waiting commands of the user from the queue and returns their number.
+func (q *commandQueue) clear(userID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var (
+		waiting []*queueEntry
+		cleared int
+	)
+
+	for _, entry := range q.waiting {
+		if entry.userID != userID {
+			waiting = append(waiting, entry)
+			continue
+		}
+
+		entry.state = queueCleared
+		cleared++
+
+		notify(entry)
+	}
+
+	q.waiting = waiting
+	q.schedule()
+
+	return cleared
+}
+
+// notify wakes up the waiting command without blocking.
+func notify(entry *queueEntry) {
+	select {
+	case entry.signal <- struct{}{}:
+	default:
+	}
+}
+
+func removeEntry(entries []*queueEntry, entry *queueEntry) []*queueEntry {
+	for i, e := range entries {
+		if e == entry {
+			return append(entries[:i], entries[i+1:]...)
+		}
+	}
+
+	return entries
+}
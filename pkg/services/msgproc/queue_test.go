@@ -0,0 +1,187 @@
+/*
+2026 © Postgres.ai
+*/
+
+package msgproc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queuedCommand runs acquire in the background and reports the positions and the outcome.
+type queuedCommand struct {
+	positions chan int
+	acquired  chan func()
+	failed    chan error
+}
+
+func acquireInBackground(ctx context.Context, q *commandQueue, userID, command string) *queuedCommand {
+	cmd := &queuedCommand{positions: make(chan int, 10), acquired: make(chan func(), 1), failed: make(chan error, 1)}
+
+	go func() {
+		release, err := q.acquire(ctx, userID, command, func(position int) { cmd.positions <- position })
+		if err != nil {
+			cmd.failed <- err
+			return
+		}
+
+		cmd.acquired <- release
+	}()
+
+	return cmd
+}
+
+func (c *queuedCommand) waitPosition(t *testing.T) int {
+	t.Helper()
+
+	select {
+	case position := <-c.positions:
+		return position
+	case <-time.After(time.Second):
+		t.Fatal("no position reported")
+		return 0
+	}
+}
+
+func (c *queuedCommand) waitAcquired(t *testing.T) func() {
+	t.Helper()
+
+	select {
+	case release := <-c.acquired:
+		return release
+	case err := <-c.failed:
+		t.Fatalf("failed to acquire: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("not acquired")
+	}
+
+	return nil
+}
+
+func (c *queuedCommand) assertWaiting(t *testing.T) {
+	t.Helper()
+
+	select {
+	case <-c.acquired:
+		t.Fatal("acquired while it had to wait")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestCommandQueueSerializesUserCommands(t *testing.T) {
+	q := newCommandQueue(0)
+	ctx := context.Background()
+
+	first := acquireInBackground(ctx, q, "alice", CommandExplain).waitAcquired(t)
+
+	// Other users are not held up.
+	acquireInBackground(ctx, q, "bob", CommandExec).waitAcquired(t)()
+
+	second := acquireInBackground(ctx, q, "alice", CommandExec)
+	assert.Equal(t, 1, second.waitPosition(t))
+	second.assertWaiting(t)
+
+	assert.Equal(t, []string{CommandExplain, CommandExec}, queueCommands(q.list("alice")))
+	assert.Equal(t, 1, q.list("alice")[1].Position)
+
+	first()
+	second.waitAcquired(t)()
+
+	assert.Empty(t, q.list("alice"))
+}
+
+func TestCommandQueueCap(t *testing.T) {
+	q := newCommandQueue(1)
+	ctx := context.Background()
+
+	release := acquireInBackground(ctx, q, "alice", CommandExplain).waitAcquired(t)
+
+	bob := acquireInBackground(ctx, q, "bob", CommandExplain)
+	assert.Equal(t, 1, bob.waitPosition(t))
+
+	carol := acquireInBackground(ctx, q, "carol", CommandExplain)
+	assert.Equal(t, 2, carol.waitPosition(t))
+
+	release()
+
+	releaseBob := bob.waitAcquired(t)
+	assert.Equal(t, 1, carol.waitPosition(t), "carol moves up")
+	carol.assertWaiting(t)
+
+	releaseBob()
+	carol.waitAcquired(t)()
+}
+
+func TestCommandQueueClear(t *testing.T) {
+	q := newCommandQueue(0)
+	ctx := context.Background()
+
+	release := acquireInBackground(ctx, q, "alice", CommandExplain).waitAcquired(t)
+
+	queued := acquireInBackground(ctx, q, "alice", CommandExec)
+	queued.waitPosition(t)
+
+	assert.Equal(t, 1, q.clear("alice"))
+
+	select {
+	case err := <-queued.failed:
+		assert.ErrorIs(t, err, errQueueCleared)
+	case <-time.After(time.Second):
+		t.Fatal("the cleared command is still waiting")
+	}
+
+	// The running command is not affected.
+	assert.Equal(t, []string{CommandExplain}, queueCommands(q.list("alice")))
+	assert.Zero(t, q.clear("alice"))
+
+	release()
+}
+
+func TestCommandQueueContextCancelled(t *testing.T) {
+	q := newCommandQueue(0)
+
+	release := acquireInBackground(context.Background(), q, "alice", CommandExplain).waitAcquired(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := acquireInBackground(ctx, q, "alice", CommandExec)
+	queued.waitPosition(t)
+
+	cancel()
+
+	select {
+	case err := <-queued.failed:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("the abandoned command is still waiting")
+	}
+
+	assert.Len(t, q.list("alice"), 1)
+
+	release()
+}
+
+func TestRenderQueue(t *testing.T) {
+	assert.Equal(t, "You have no running or queued commands.", renderQueue(nil))
+
+	receivedAt := time.Now().Add(-2 * time.Minute)
+
+	assert.Equal(t, "Your commands:\n"+
+		"• `explain` — running, received 2.000 min ago\n"+
+		"• `exec` — queued (position 2 in the channel queue), received 2.000 min ago\n",
+		renderQueue([]queueItem{{Command: "explain", EnqueuedAt: receivedAt}, {Command: "exec", Position: 2, EnqueuedAt: receivedAt}}))
+}
+
+func queueCommands(items []queueItem) []string {
+	commands := make([]string, 0, len(items))
+
+	for _, item := range items {
+		commands = append(commands, item.Command)
+	}
+
+	return commands
+}
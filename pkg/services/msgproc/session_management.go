@@ -221,6 +221,7 @@ func (s *ProcessingService) stopSession(ctx context.Context, user *usermanager.U
 
 	user.Session.CloneConnection = nil
 	user.Session.Pool = nil
+	user.Session.ExplainHistory = nil
 }
 
 // destroySession destroys a DatabaseLab session.
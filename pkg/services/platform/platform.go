@@ -44,6 +44,7 @@ type Command struct {
 	Recommendations string `json:"recommendations"`
 	Stats           string `json:"stats"`
 	QueryLocks      string `json:"query_locks"`
+	PlanFingerprint string `json:"plan_fingerprint"`
 
 	Error string `json:"error"`
 
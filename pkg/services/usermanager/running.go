@@ -0,0 +1,152 @@
+/*
+2026 © Postgres.ai
+*/
+
+package usermanager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+
+	"gitlab.com/postgres-ai/joe/pkg/models"
+)
+
+// ErrCommandCancelled is returned to a command that has been cancelled before its query started.
+var ErrCommandCancelled = errors.New("the command has been cancelled")
+
+// RunningCommands tracks the commands in flight in a session, so that they can be cancelled.
+// A nil RunningCommands tracks nothing.
+type RunningCommands struct {
+	mu       sync.Mutex
+	commands []*RunningCommand
+}
+
+// RunningCommand is a command in flight.
+type RunningCommand struct {
+	Command   string
+	Message   *models.Message
+	StartedAt time.Time
+
+	// pool is the pool of the session the command runs in, to cancel its query with.
+	pool *pgxpool.Pool
+
+	// backendPID is the Postgres backend running the query of the command, 0 until it is known.
+	backendPID int
+	cancelled  bool
+}
+
+// NewRunningCommands creates a new tracker of running commands.
+func NewRunningCommands() *RunningCommands {
+	return &RunningCommands{}
+}
+
+// Start starts tracking a command, the message of which has been published, running in the session with the pool.
+func (r *RunningCommands) Start(command string, msg *models.Message, pool *pgxpool.Pool) *RunningCommand {
+	running := &RunningCommand{Command: command, Message: msg, StartedAt: time.Now(), pool: pool}
+
+	if r == nil {
+		return running
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.commands = append(r.commands, running)
+
+	return running
+}
+
+// Finish stops tracking a command.
+func (r *RunningCommands) Finish(running *RunningCommand) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, command := range r.commands {
+		if command == running {
+			r.commands = append(r.commands[:i], r.commands[i+1:]...)
+			break
+		}
+	}
+}
+
+// SetBackendPID sets the backend PID of the command of the message once it starts its query.
+// It returns ErrCommandCancelled when the command has been cancelled in the meantime.
+func (r *RunningCommands) SetBackendPID(msg *models.Message, pid int) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, command := range r.commands {
+		if command.Message != msg {
+			continue
+		}
+
+		if command.cancelled {
+			return ErrCommandCancelled
+		}
+
+		command.backendPID = pid
+	}
+
+	return nil
+}
+
+// Cancel marks all running commands as cancelled and returns them with the backend PIDs to cancel;
+// the PID is 0 when the query of a command has not started yet.
+func (r *RunningCommands) Cancel() []CancelledCommand {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var cancelled []CancelledCommand
+
+	for _, command := range r.commands {
+		if command.cancelled {
+			continue
+		}
+
+		command.cancelled = true
+
+		cancelled = append(cancelled, CancelledCommand{
+			Command:    command.Command,
+			StartedAt:  command.StartedAt,
+			BackendPID: command.backendPID,
+			Pool:       command.pool,
+		})
+	}
+
+	return cancelled
+}
+
+// Cancelled tells whether the command has been cancelled.
+func (r *RunningCommands) Cancelled(running *RunningCommand) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return running.cancelled
+}
+
+// CancelledCommand describes a command marked as cancelled.
+type CancelledCommand struct {
+	Command    string
+	StartedAt  time.Time
+	BackendPID int
+	Pool       *pgxpool.Pool // To cancel the query with, nil without a session.
+}
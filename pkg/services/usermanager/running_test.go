@@ -0,0 +1,72 @@
+/*
+2026 © Postgres.ai
+*/
+
+package usermanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/postgres-ai/joe/features/definition"
+	"gitlab.com/postgres-ai/joe/pkg/models"
+)
+
+func TestRunningCommands(t *testing.T) {
+	running := NewRunningCommands()
+
+	explainMsg, execMsg := &models.Message{MessageID: "1"}, &models.Message{MessageID: "2"}
+
+	explain := running.Start("explain", explainMsg, nil)
+	exec := running.Start("exec", execMsg, nil)
+
+	require.NoError(t, running.SetBackendPID(explainMsg, 4242))
+
+	cancelled := running.Cancel()
+	require.Len(t, cancelled, 2)
+	assert.Equal(t, "explain", cancelled[0].Command)
+	assert.Equal(t, 4242, cancelled[0].BackendPID)
+	assert.Equal(t, "exec", cancelled[1].Command)
+	assert.Equal(t, 0, cancelled[1].BackendPID)
+
+	assert.True(t, running.Cancelled(explain))
+	assert.True(t, running.Cancelled(exec))
+
+	// A command cancelled before its query started must not start it.
+	assert.ErrorIs(t, running.SetBackendPID(execMsg, 4343), ErrCommandCancelled)
+
+	// Commands are cancelled once.
+	assert.Empty(t, running.Cancel())
+
+	running.Finish(explain)
+	running.Finish(exec)
+
+	next := running.Start("explain", explainMsg, nil)
+	assert.False(t, running.Cancelled(next))
+	assert.Len(t, running.Cancel(), 1)
+}
+
+func TestRunningCommandsNil(t *testing.T) {
+	var running *RunningCommands
+
+	msg := &models.Message{}
+	command := running.Start("explain", msg, nil)
+
+	assert.NoError(t, running.SetBackendPID(msg, 1))
+	assert.Empty(t, running.Cancel())
+	assert.False(t, running.Cancelled(command))
+
+	running.Finish(command)
+}
+
+func TestUserManagerTracksRestoredSessions(t *testing.T) {
+	restored := &User{UserInfo: models.UserInfo{ID: "U1"}}
+
+	um := NewUserManager(nil, definition.Quota{}, UserList{"U1": restored})
+
+	user, err := um.CreateUser("U1")
+	require.NoError(t, err)
+	assert.NotNil(t, user.Session.Running)
+}
@@ -0,0 +1,80 @@
+/*
+2026 © Postgres.ai
+*/
+
+package usermanager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/postgres-ai/database-lab/v3/pkg/util"
+)
+
+// Transaction statuses of the clone connection, as reported by Postgres.
+const (
+	TxStatusIdle          = 'I'
+	TxStatusInTransaction = 'T'
+	TxStatusFailed        = 'E'
+)
+
+// MsgTransactionFailed tells that the transaction of the user has failed.
+const MsgTransactionFailed = ":warning: The transaction has failed; use `rollback` or `rollback to <savepoint>`."
+
+// Transaction is the transaction opened by the user with `begin` on the clone connection.
+type Transaction struct {
+	StartedAt  time.Time
+	Savepoints []string
+}
+
+// RollbackTo forgets the savepoints created after the savepoint, which stays,
+// and reports whether the transaction has it.
+func (t *Transaction) RollbackTo(savepoint string) bool {
+	for index := len(t.Savepoints) - 1; index >= 0; index-- {
+		if t.Savepoints[index] == savepoint {
+			t.Savepoints = t.Savepoints[:index+1]
+			return true
+		}
+	}
+
+	return false
+}
+
+// TxStatus returns the transaction status of the clone connection, TxStatusIdle without one.
+func (s *UserSession) TxStatus() byte {
+	if s.CloneConnection == nil || s.CloneConnection.IsClosed() {
+		return TxStatusIdle
+	}
+
+	return s.CloneConnection.PgConn().TxStatus()
+}
+
+// TransactionState describes the open transaction of the session, if any, for the footer
+// of the messages. It forgets the transaction once the connection is out of it, e.g. after
+// `exec commit` or a session reset.
+func (s *UserSession) TransactionState() string {
+	status := s.TxStatus()
+
+	if status == TxStatusIdle {
+		s.Transaction = nil
+		return ""
+	}
+
+	if status == TxStatusFailed {
+		return MsgTransactionFailed
+	}
+
+	if s.Transaction == nil {
+		return ":arrows_counterclockwise: A transaction is open; use `commit` or `rollback` to finish it."
+	}
+
+	state := fmt.Sprintf(":arrows_counterclockwise: Transaction open for %s",
+		util.DurationToString(time.Since(s.Transaction.StartedAt).Truncate(time.Second)))
+
+	if len(s.Transaction.Savepoints) > 0 {
+		state += fmt.Sprintf(", savepoints: `%s`", strings.Join(s.Transaction.Savepoints, "`, `"))
+	}
+
+	return state + "; use `commit` or `rollback` to finish it."
+}
@@ -0,0 +1,32 @@
+/*
+2026 © Postgres.ai
+*/
+
+package usermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionRollbackTo(t *testing.T) {
+	tx := &Transaction{Savepoints: []string{"a", "b", "a", "c"}}
+
+	assert.True(t, tx.RollbackTo("a"))
+	assert.Equal(t, []string{"a", "b", "a"}, tx.Savepoints, "the latest savepoint of the name is kept")
+
+	assert.True(t, tx.RollbackTo("b"))
+	assert.Equal(t, []string{"a", "b"}, tx.Savepoints)
+
+	assert.False(t, tx.RollbackTo("c"))
+	assert.Equal(t, []string{"a", "b"}, tx.Savepoints)
+}
+
+func TestTransactionStateWithoutConnection(t *testing.T) {
+	session := UserSession{Transaction: &Transaction{StartedAt: time.Now()}}
+
+	assert.Empty(t, session.TransactionState())
+	assert.Nil(t, session.Transaction, "a transaction the connection is out of is forgotten")
+}
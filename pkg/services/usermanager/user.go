@@ -41,6 +41,33 @@ type UserSession struct {
 	Pool            *pgxpool.Pool `json:"-"`
 	CloneConnection *pgx.Conn     `json:"-"`
 	DBVersion       int           `json:"-"`
+
+	ExplainHistory []ExplainResult  `json:"-"`
+	Running        *RunningCommands `json:"-"`
+	Transaction    *Transaction     `json:"-"`
+
+	// RestoreTimeout is the statement timeout to restore on the clone connection once the transaction
+	// opened by the statements of a command with its own timeout ends, empty for none.
+	RestoreTimeout string `json:"-"`
+}
+
+// maxExplainHistory limits the number of explain results kept in a session.
+const maxExplainHistory = 10
+
+// ExplainResult keeps the result of an explain command for later comparisons within the session.
+type ExplainResult struct {
+	Query       string
+	PlanJSON    string
+	Fingerprint string // Plan shape fingerprint, see pgexplain.Explain.Fingerprint.
+}
+
+// AddExplainResult records an explain result, dropping the oldest ones beyond maxExplainHistory.
+func (s *UserSession) AddExplainResult(result ExplainResult) {
+	s.ExplainHistory = append(s.ExplainHistory, result)
+
+	if len(s.ExplainHistory) > maxExplainHistory {
+		s.ExplainHistory = s.ExplainHistory[len(s.ExplainHistory)-maxExplainHistory:]
+	}
 }
 
 // Quota defines a user quota for requests.
@@ -60,6 +87,7 @@ func NewUser(userInfo models.UserInfo, quota Quota) *User {
 		Session: UserSession{
 			Quota:        quota,
 			LastActionTs: ts,
+			Running:      NewRunningCommands(),
 		},
 	}
 
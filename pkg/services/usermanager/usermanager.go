@@ -36,11 +36,24 @@ func NewUserManager(informer UserInformer, quotaCfg definition.Quota, users User
 	if users == nil {
 		users = make(UserList)
 	}
-	return &UserManager{
+
+	um := &UserManager{
 		UserInformer: informer,
 		QuotaConfig:  quotaCfg,
 		users:        users,
 	}
+
+	um.usersMutex.Lock()
+	defer um.usersMutex.Unlock()
+
+	// Sessions restored from the storage do not track running commands yet.
+	for _, user := range users {
+		if user.Session.Running == nil {
+			user.Session.Running = NewRunningCommands()
+		}
+	}
+
+	return um
 }
 
 // Users returns all users.